@@ -9,25 +9,71 @@ import (
 
 var (
 	APIFilePath = "%s/api/v1/%s/"
+	BindPath    = "%s/api/v1/bind/"
+	// Frameworks are the routers the API layer is generated for, with the packages the service needs for them
+	Frameworks = map[string][]string{
+		"gin":    {"github.com/gin-gonic/gin", "github.com/gin-contrib/cors"},
+		"chi":    {"github.com/go-chi/chi/v5"},
+		"stdlib": {},
+	}
 )
 
 func Setup(apiInputs models.APIInputs) (err error) {
 	apiInputs.APIGroupTitle = common.ToCamelCase(apiInputs.APIGroup)
 	apiInputs.TableNameTitle = common.ToCamelCase(apiInputs.TableName)
 
-	err = createApiGroup(apiInputs)
+	// the groups of a spec with groups all have their Service and handlers in api/v1
+	groups := []models.APIInputs{apiInputs}
+	if apiInputs.Routes != nil {
+		groups = apiInputs.Routes.Groups
+	}
+	for _, group := range groups {
+		err = createApiGroup(group)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			return
+		}
+
+		err = createControllerFile(group)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			return
+		}
+
+		err = createServiceFile(group)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			return
+		}
+	}
+
+	if apiInputs.Routes != nil {
+		err = createVersionFiles(apiInputs.Routes.Versions)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			return
+		}
+	}
+
+	err = createBindFile(apiInputs.WrkDir)
+	if err != nil {
+		fmt.Println("Error: ", err)
+		return
+	}
+
+	err = CreateErrorsFile(apiInputs.WrkDir, apiInputs.DBMS)
 	if err != nil {
 		fmt.Println("Error: ", err)
 		return
 	}
 
-	err = createControllerFile(apiInputs)
+	err = createOpenAPIFile(apiInputs)
 	if err != nil {
 		fmt.Println("Error: ", err)
 		return
 	}
 
-	err = createServiceFile(apiInputs)
+	err = createDocsFiles(apiInputs.WrkDir, apiInputs.Framework)
 	if err != nil {
 		fmt.Println("Error: ", err)
 		return
@@ -45,9 +91,20 @@ const controllerContent = `// Generated By API Service Generator
 package {{.APIGroup}}
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+{{- if eq .Table.PrimaryKey "serial" "bigserial"}}
+	"strconv"
+{{- end}}
 
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
 	"github.com/gin-gonic/gin"
+{{- if eq .Table.PrimaryKey "uuid"}}
+	"github.com/google/uuid"
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	"github.com/oklog/ulid/v2"
+{{- end}}
 )
 
 type {{.APIGroupTitle}}Resource struct {
@@ -59,6 +116,10 @@ func RegisterHandler(r *gin.RouterGroup, service Service) {
 	resource := New{{.APIGroupTitle}}Resource(service)
 	
 	r.GET("/{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	r.GET("/{{.APIGroup}}/:id", resource.Get{{.APIGroupTitle}}ByID)
+	r.POST("/{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	r.PUT("/{{.APIGroup}}/:id", resource.Update{{.APIGroupTitle}})
+	r.DELETE("/{{.APIGroup}}/:id", resource.Delete{{.APIGroupTitle}})
 }
 
 func New{{.APIGroupTitle}}Resource(service Service){{.APIGroupTitle}}Resource {
@@ -68,31 +129,379 @@ func New{{.APIGroupTitle}}Resource(service Service){{.APIGroupTitle}}Resource {
 func (r *{{.APIGroupTitle}}Resource) Get{{.APIGroupTitle}}(c *gin.Context) {
 	{{.TableNameTitle}}, err := r.service.Get{{.APIGroupTitle}}(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, {{.TableNameTitle}})
+}
+
+func (r *{{.APIGroupTitle}}Resource) Get{{.APIGroupTitle}}ByID(c *gin.Context) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		errorResponse(c, apierrors.InvalidID(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := r.service.Get{{.APIGroupTitle}}ByID(c, id)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, {{.TableNameTitle}})
+}
+
+func (r *{{.APIGroupTitle}}Resource) Create{{.APIGroupTitle}}(c *gin.Context) {
+	var req {{.APIGroupTitle}}Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, apierrors.Binding(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := r.service.Create{{.APIGroupTitle}}(c, req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, {{.TableNameTitle}})
+}
+
+func (r *{{.APIGroupTitle}}Resource) Update{{.APIGroupTitle}}(c *gin.Context) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		errorResponse(c, apierrors.InvalidID(err))
+		return
+	}
+
+	var req {{if .Table.Versioned}}{{.APIGroupTitle}}UpdateRequest{{else}}{{.APIGroupTitle}}Request{{end}}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, apierrors.Binding(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := r.service.Update{{.APIGroupTitle}}(c, id, req)
+	if err != nil {
+		errorResponse(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, {{.TableNameTitle}})
 }
 
+func (r *{{.APIGroupTitle}}Resource) Delete{{.APIGroupTitle}}(c *gin.Context) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		errorResponse(c, apierrors.InvalidID(err))
+		return
+	}
+
+	if err := r.service.Delete{{.APIGroupTitle}}(c, id); err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseID reads the {{.Table.PrimaryKey}} primary key from the path
+func parseID(param string) ({{.Table.Key.GoType}}, error) {
+{{- if eq .Table.PrimaryKey "serial"}}
+	id, err := strconv.ParseInt(param, 10, 32)
+	return int32(id), err
+{{- else if eq .Table.PrimaryKey "bigserial"}}
+	return strconv.ParseInt(param, 10, 64)
+{{- else if eq .Table.PrimaryKey "uuid"}}
+	return uuid.Parse(param)
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	id, err := ulid.ParseStrict(param)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+{{- end}}
+}
+
+// errorResponse answers err as an application/problem+json, a missing row names the table
+func errorResponse(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = apierrors.NotFound("{{.TableName}}")
+	}
+	apierrors.Write(c.Writer, c.Request, err)
+}
+
+`
+
+// httpControllerContent is the controller of the net/http routers, chi and the ServeMux of the standard library
+const httpControllerContent = `// Generated By API Service Generator
+package {{.APIGroup}}
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+{{- if eq .Table.PrimaryKey "serial" "bigserial"}}
+	"strconv"
+{{- end}}
+
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/bind"
+{{- if eq .Framework "chi"}}
+	"github.com/go-chi/chi/v5"
+{{- end}}
+{{- if eq .Table.PrimaryKey "uuid"}}
+	"github.com/google/uuid"
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	"github.com/oklog/ulid/v2"
+{{- end}}
+)
+
+type {{.APIGroupTitle}}Resource struct {
+	service Service
+}
+{{if eq .Framework "chi"}}
+func RegisterHandler(r chi.Router, service Service) {
+	resource := New{{.APIGroupTitle}}Resource(service)
+
+	r.Get("/{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	r.Get("/{{.APIGroup}}/{id}", resource.Get{{.APIGroupTitle}}ByID)
+	r.Post("/{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	r.Put("/{{.APIGroup}}/{id}", resource.Update{{.APIGroupTitle}})
+	r.Delete("/{{.APIGroup}}/{id}", resource.Delete{{.APIGroupTitle}})
+}
+{{- else}}
+func RegisterHandler(mux *http.ServeMux, service Service) {
+	resource := New{{.APIGroupTitle}}Resource(service)
+
+	mux.HandleFunc("GET /{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	mux.HandleFunc("GET /{{.APIGroup}}/{id}", resource.Get{{.APIGroupTitle}}ByID)
+	mux.HandleFunc("POST /{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	mux.HandleFunc("PUT /{{.APIGroup}}/{id}", resource.Update{{.APIGroupTitle}})
+	mux.HandleFunc("DELETE /{{.APIGroup}}/{id}", resource.Delete{{.APIGroupTitle}})
+}
+{{- end}}
+
+func New{{.APIGroupTitle}}Resource(service Service) {{.APIGroupTitle}}Resource {
+	return {{.APIGroupTitle}}Resource{service}
+}
+
+func (h *{{.APIGroupTitle}}Resource) Get{{.APIGroupTitle}}(w http.ResponseWriter, r *http.Request) {
+	{{.TableNameTitle}}, err := h.service.Get{{.APIGroupTitle}}(r.Context())
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, {{.TableNameTitle}})
+}
+
+func (h *{{.APIGroupTitle}}Resource) Get{{.APIGroupTitle}}ByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID({{template "idParam" .}})
+	if err != nil {
+		errorResponse(w, r, apierrors.InvalidID(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := h.service.Get{{.APIGroupTitle}}ByID(r.Context(), id)
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, {{.TableNameTitle}})
+}
+
+func (h *{{.APIGroupTitle}}Resource) Create{{.APIGroupTitle}}(w http.ResponseWriter, r *http.Request) {
+	var req {{.APIGroupTitle}}Request
+	if err := bind.JSON(r, &req); err != nil {
+		errorResponse(w, r, apierrors.Binding(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := h.service.Create{{.APIGroupTitle}}(r.Context(), req)
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, {{.TableNameTitle}})
+}
+
+func (h *{{.APIGroupTitle}}Resource) Update{{.APIGroupTitle}}(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID({{template "idParam" .}})
+	if err != nil {
+		errorResponse(w, r, apierrors.InvalidID(err))
+		return
+	}
+
+	var req {{if .Table.Versioned}}{{.APIGroupTitle}}UpdateRequest{{else}}{{.APIGroupTitle}}Request{{end}}
+	if err := bind.JSON(r, &req); err != nil {
+		errorResponse(w, r, apierrors.Binding(err))
+		return
+	}
+
+	{{.TableNameTitle}}, err := h.service.Update{{.APIGroupTitle}}(r.Context(), id, req)
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, {{.TableNameTitle}})
+}
+
+func (h *{{.APIGroupTitle}}Resource) Delete{{.APIGroupTitle}}(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID({{template "idParam" .}})
+	if err != nil {
+		errorResponse(w, r, apierrors.InvalidID(err))
+		return
+	}
+
+	if err := h.service.Delete{{.APIGroupTitle}}(r.Context(), id); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseID reads the {{.Table.PrimaryKey}} primary key from the path
+func parseID(param string) ({{.Table.Key.GoType}}, error) {
+{{- if eq .Table.PrimaryKey "serial"}}
+	id, err := strconv.ParseInt(param, 10, 32)
+	return int32(id), err
+{{- else if eq .Table.PrimaryKey "bigserial"}}
+	return strconv.ParseInt(param, 10, 64)
+{{- else if eq .Table.PrimaryKey "uuid"}}
+	return uuid.Parse(param)
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	id, err := ulid.ParseStrict(param)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+{{- end}}
+}
+
+// errorResponse answers err as an application/problem+json, a missing row names the table
+func errorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = apierrors.NotFound("{{.TableName}}")
+	}
+	apierrors.Write(w, r, err)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+{{- define "idParam"}}{{if eq .Framework "chi"}}chi.URLParam(r, "id"){{else}}r.PathValue("id"){{end}}{{end}}
 `
 
 func createControllerFile(apiInputs models.APIInputs) error {
 	fileName := fmt.Sprintf(APIFilePath, apiInputs.WrkDir,apiInputs.APIGroup) + "controller.go"
+	if common.NetHTTP(apiInputs.Framework) {
+		return common.CreateFileAndItsContent(fileName, apiInputs, httpControllerContent)
+	}
 	return common.CreateFileAndItsContent(fileName, apiInputs, controllerContent)
 }
 
+const bindContent = `// Generated By API Service Generator
+package bind
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the binding tags of the requests, the tags Gin validates
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+}
+
+// JSON decodes the body of a request into v and validates it
+func JSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// Validate checks the binding tags of a request struct, the gRPC and GraphQL inputs are checked with it too
+func Validate(v any) error {
+	return validate.Struct(v)
+}
+`
+
+// createBindFile writes the request validation shared by the controllers, the gRPC server and the GraphQL resolvers
+func createBindFile(wrkDir string) error {
+	fileName := fmt.Sprintf(BindPath, wrkDir) + "bind.go"
+	return common.CreateFileAndItsContent(fileName, nil, bindContent)
+}
+
 const serviceContent = `// Generated By API Service Generator
 package {{.APIGroup}}
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+{{- range .Table.Imports}}
+	"{{.}}"
+{{- end}}
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
 	"{{.GoModule}}/{{.WrkDir}}/pkg/db"
+{{- if eq .DBMS "mysql"}}
+	"github.com/go-sql-driver/mysql"
+{{- else}}
+	"github.com/lib/pq"
+{{- end}}
 )
 
 type Service interface {
 	Get{{.APIGroupTitle}}(ctx context.Context) ([]db.{{.TableNameTitle}}, error)
+	Get{{.APIGroupTitle}}ByID(ctx context.Context, id {{.Table.Key.GoType}}) (db.{{.TableNameTitle}}, error)
+	Create{{.APIGroupTitle}}(ctx context.Context, req {{.APIGroupTitle}}Request) (db.{{.TableNameTitle}}, error)
+	Update{{.APIGroupTitle}}(ctx context.Context, id {{.Table.Key.GoType}}, req {{template "updateRequest" .}}) (db.{{.TableNameTitle}}, error)
+	Delete{{.APIGroupTitle}}(ctx context.Context, id {{.Table.Key.GoType}}) error
+{{- if .GraphQL}}
+	// the batch methods of the GraphQL loaders
+	Get{{.APIGroupTitle}}ByIDs(ctx context.Context, ids []{{.Table.Key.GoType}}) ([]db.{{.TableNameTitle}}, error)
+{{- range .Table.Columns}}{{if .References}}
+	List{{$.APIGroupTitle}}By{{.FieldName}}(ctx context.Context, values []{{template "baseType" .}}) ([]db.{{$.TableNameTitle}}, error)
+{{- end}}{{end}}
+{{- end}}
 }
 
+// ErrConflict is returned when a write clashes with the stored {{.TableName}}
+var ErrConflict = apierrors.Conflict("{{.TableName}} was changed by another request")
+
+// ErrDuplicate is returned when a write breaks a unique constraint
+var ErrDuplicate = apierrors.Duplicate("{{.TableName}} already exists")
+
+// {{.APIGroupTitle}}Request is the body of the create and update requests
+type {{.APIGroupTitle}}Request struct {
+{{- range .Table.Columns}}
+	{{.FieldName}} {{.GoType}} ` + "`" + `json:"{{.Name}}"{{if .Binding}} binding:"{{.Binding}}"{{end}}` + "`" + `
+{{- end}}
+}
+{{- if .Table.Versioned}}
+
+// {{.APIGroupTitle}}UpdateRequest carries the version the client last read
+type {{.APIGroupTitle}}UpdateRequest struct {
+	{{.APIGroupTitle}}Request
+	Version int32 ` + "`" + `json:"version" binding:"required"` + "`" + `
+}
+{{- end}}
+
 type {{.APIGroupTitle}}Service struct {
 	DBConn *db.Queries
 }
@@ -105,6 +514,139 @@ func (s *{{.APIGroupTitle}}Service) Get{{.APIGroupTitle}}(ctx context.Context) (
 	return s.DBConn.List{{.TableName}}(ctx)
 }
 
+func (s *{{.APIGroupTitle}}Service) Get{{.APIGroupTitle}}ByID(ctx context.Context, id {{.Table.Key.GoType}}) (db.{{.TableNameTitle}}, error) {
+	return s.DBConn.Get{{.TableName}}(ctx, id)
+}
+
+func (s *{{.APIGroupTitle}}Service) Create{{.APIGroupTitle}}(ctx context.Context, req {{.APIGroupTitle}}Request) (db.{{.TableNameTitle}}, error) {
+{{- if .Table.Key.AppGenerated}}
+	id := newID()
+{{- end}}
+{{- if eq .DBMS "mysql"}}
+	{{if .Table.Key.AppGenerated}}_{{else}}result{{end}}, err := s.DBConn.Create{{.TableName}}(ctx, {{template "createArgs" .}})
+	if err != nil {
+		return db.{{.TableNameTitle}}{}, checkDuplicate(err)
+	}
+{{- if not .Table.Key.AppGenerated}}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return db.{{.TableNameTitle}}{}, err
+	}
+	id := {{.Table.Key.GoType}}(lastID)
+{{- end}}
+	return s.DBConn.Get{{.TableName}}(ctx, id)
+{{- else}}
+	{{.TableNameTitle}}, err := s.DBConn.Create{{.TableName}}(ctx, {{template "createArgs" .}})
+	return {{.TableNameTitle}}, checkDuplicate(err)
+{{- end}}
+}
+
+func (s *{{.APIGroupTitle}}Service) Update{{.APIGroupTitle}}(ctx context.Context, id {{.Table.Key.GoType}}, req {{template "updateRequest" .}}) (db.{{.TableNameTitle}}, error) {
+	params := db.Update{{.TableName}}Params{
+		ID: id,
+{{- range .Table.Columns}}
+		{{.FieldName}}: req.{{.FieldName}},
+{{- end}}
+{{- if .Table.Versioned}}
+		Version: req.Version,
+{{- end}}
+	}
+{{- if and (eq .DBMS "mysql") .Table.Versioned}}
+	rows, err := s.DBConn.Update{{.TableName}}(ctx, params)
+	if err != nil {
+		return db.{{.TableNameTitle}}{}, checkDuplicate(err)
+	}
+	{{.TableNameTitle}}, err := s.DBConn.Get{{.TableName}}(ctx, id)
+	if err == nil && rows == 0 {
+		return {{.TableNameTitle}}, ErrConflict
+	}
+	return {{.TableNameTitle}}, err
+{{- else if eq .DBMS "mysql"}}
+	if err := s.DBConn.Update{{.TableName}}(ctx, params); err != nil {
+		return db.{{.TableNameTitle}}{}, checkDuplicate(err)
+	}
+	return s.DBConn.Get{{.TableName}}(ctx, id)
+{{- else if .Table.Versioned}}
+	{{.TableNameTitle}}, err := s.DBConn.Update{{.TableName}}(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the row exists, so the version did not match
+		if _, getErr := s.DBConn.Get{{.TableName}}(ctx, id); getErr == nil {
+			return {{.TableNameTitle}}, ErrConflict
+		}
+	}
+	return {{.TableNameTitle}}, checkDuplicate(err)
+{{- else}}
+	{{.TableNameTitle}}, err := s.DBConn.Update{{.TableName}}(ctx, params)
+	return {{.TableNameTitle}}, checkDuplicate(err)
+{{- end}}
+}
+
+func (s *{{.APIGroupTitle}}Service) Delete{{.APIGroupTitle}}(ctx context.Context, id {{.Table.Key.GoType}}) error {
+	rows, err := s.DBConn.Delete{{.TableName}}(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+{{- if .GraphQL}}
+
+func (s *{{.APIGroupTitle}}Service) Get{{.APIGroupTitle}}ByIDs(ctx context.Context, ids []{{.Table.Key.GoType}}) ([]db.{{.TableNameTitle}}, error) {
+	return s.DBConn.List{{.TableName}}ByIDs(ctx, ids)
+}
+{{- range .Table.Columns}}{{if .References}}
+
+func (s *{{$.APIGroupTitle}}Service) List{{$.APIGroupTitle}}By{{.FieldName}}(ctx context.Context, values []{{template "baseType" .}}) ([]db.{{$.TableNameTitle}}, error) {
+{{- if and (eq $.DBMS "mysql") .Nullable}}
+	// sqlc.slice takes the type of the nullable column
+	params := make([]{{.GoType}}, len(values))
+	for i := range values {
+		params[i] = &values[i]
+	}
+	return s.DBConn.List{{$.TableName}}By{{.FieldName}}(ctx, params)
+{{- else}}
+	return s.DBConn.List{{$.TableName}}By{{.FieldName}}(ctx, values)
+{{- end}}
+}
+{{- end}}{{end}}
+{{- end}}
+
+// checkDuplicate turns a unique violation from the driver into ErrDuplicate
+func checkDuplicate(err error) error {
+{{- if eq .DBMS "mysql"}}
+	var driverErr *mysql.MySQLError
+	if errors.As(err, &driverErr) && driverErr.Number == 1062 {
+{{- else}}
+	var driverErr *pq.Error
+	if errors.As(err, &driverErr) && driverErr.Code == "23505" {
+{{- end}}
+		return ErrDuplicate
+	}
+	return err
+}
+{{- if .Table.Key.AppGenerated}}
+
+func newID() {{.Table.Key.GoType}} {
+{{- if eq .Table.PrimaryKey "ulid"}}
+	return ulid.Make().String()
+{{- else}}
+	return uuid.New()
+{{- end}}
+}
+{{- end}}
+
+{{define "baseType"}}{{if .Nullable}}{{slice .GoType 1}}{{else}}{{.GoType}}{{end}}{{end}}
+{{define "updateRequest"}}{{.APIGroupTitle}}{{if .Table.Versioned}}UpdateRequest{{else}}Request{{end}}{{end}}
+{{define "createArgs"}}
+{{- if and (eq (len .Table.Columns) 1) (not .Table.Key.AppGenerated)}}req.{{(index .Table.Columns 0).FieldName}}
+{{- else}}db.Create{{.TableName}}Params{
+{{- if .Table.Key.AppGenerated}}ID: id, {{end}}
+{{- range $i, $c := .Table.Columns}}{{if $i}}, {{end}}{{$c.FieldName}}: req.{{$c.FieldName}}{{end}}}
+{{- end}}
+{{- end}}
 `
 
 func createServiceFile(apiInputs models.APIInputs) error {
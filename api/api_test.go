@@ -3,6 +3,8 @@ package api
 import (
 	"errors"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"testing"
 
@@ -10,9 +12,12 @@ import (
 	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/abhijithk1/api-service-generator/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain (m *testing.M) {
+	// the Swagger UI assets are not written by the mocked executor
+	WriteFile = func(string, []byte, os.FileMode) error { return nil }
 	os.Exit(m.Run())
 }
 
@@ -94,6 +99,11 @@ func TestSetup_Success(t *testing.T) {
 	mockCmdsExecutor.On("CreateDirectory",filePath).Return(nil)
 	mockCmdsExecutor.On("CreateFileAndItsContent", fileControllerName, apiInputs, controllerContent).Return(nil)
 	mockCmdsExecutor.On("CreateFileAndItsContent", fileServiceName, apiInputs, serviceContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/bind/bind.go", nil, bindContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/errors/errors.go", "", errorsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", fmt.Sprintf(OpenAPIPath, apiInputs.WrkDir), openAPIData(apiInputs.WrkDir, apiInputs), openAPIContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/docs.go", nil, docsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/docs/index.html", "dir", docsIndex).Return(nil)
 
 	Setup(apiInputs)
 
@@ -165,3 +175,64 @@ func TestSetup_CreateAPIGroupError(t *testing.T) {
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
+
+func TestCreateControllerFile_NetHTTP(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	apiInputs := models.APIInputs{WrkDir: "dir", APIGroup: "dummy", Framework: "chi"}
+
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/dummy/controller.go", apiInputs, httpControllerContent).Return(nil)
+
+	assert.NoError(t, createControllerFile(apiInputs))
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestHTTPControllerContent(t *testing.T) {
+	for _, primaryKey := range []string{"serial", "uuid", "ulid"} {
+		apiInputs := orderInputs(t, "orders", models.TableSpec{PrimaryKey: primaryKey, Versioned: true, Columns: []models.Column{{Name: "item", Type: "TEXT"}}})
+
+		apiInputs.Framework = "chi"
+		chi := render(t, httpControllerContent, apiInputs)
+		_, err := parser.ParseFile(token.NewFileSet(), "controller.go", chi, 0)
+		require.NoError(t, err, chi)
+		assert.Contains(t, chi, "func RegisterHandler(r chi.Router, service Service) {")
+		assert.Contains(t, chi, "\tr.Put(\"/orders/{id}\", resource.UpdateOrders)\n")
+		assert.Contains(t, chi, "id, err := parseID(chi.URLParam(r, \"id\"))")
+		assert.Contains(t, chi, "\t\"example/api-service/services/orders/api/v1/bind\"\n")
+
+		apiInputs.Framework = "stdlib"
+		stdlib := render(t, httpControllerContent, apiInputs)
+		_, err = parser.ParseFile(token.NewFileSet(), "controller.go", stdlib, 0)
+		require.NoError(t, err, stdlib)
+		assert.Contains(t, stdlib, "func RegisterHandler(mux *http.ServeMux, service Service) {")
+		assert.Contains(t, stdlib, "\tmux.HandleFunc(\"PUT /orders/{id}\", resource.UpdateOrders)\n")
+		assert.Contains(t, stdlib, "id, err := parseID(r.PathValue(\"id\"))")
+		assert.Contains(t, stdlib, "var req OrdersUpdateRequest\n\tif err := bind.JSON(r, &req); err != nil {")
+		assert.NotContains(t, stdlib, "chi")
+		assert.NotContains(t, stdlib, "gin-gonic")
+	}
+
+	_, err := parser.ParseFile(token.NewFileSet(), "bind.go", bindContent, 0)
+	assert.NoError(t, err)
+}
+
+func TestCreateDocsFiles_NetHTTP(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/docs.go", "stdlib", httpDocsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/docs/index.html", "dir", docsIndex).Return(nil)
+
+	assert.NoError(t, createDocsFiles("dir", "stdlib"))
+	mockCmdsExecutor.AssertExpectations(t)
+
+	for framework, route := range map[string]string{
+		"chi":    "router.Handle(\"/docs/ui/*\", http.StripPrefix(\"/docs/ui\", http.FileServer(http.FS(ui))))",
+		"stdlib": "router.Handle(\"GET /docs/ui/\", http.StripPrefix(\"/docs/ui\", http.FileServer(http.FS(ui))))",
+	} {
+		docs := render(t, httpDocsContent, framework)
+		_, err := parser.ParseFile(token.NewFileSet(), "docs.go", docs, parser.ParseComments)
+		require.NoError(t, err, docs)
+		assert.Contains(t, docs, route)
+		assert.Contains(t, docs, "\n//go:embed openapi.yaml docs\nvar files embed.FS\n")
+	}
+}
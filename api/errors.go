@@ -0,0 +1,243 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/abhijithk1/api-service-generator/common"
+)
+
+var ErrorsPath = "%s/api/errors/"
+
+// errorsContent is the error model of the service, the controllers, the middleware and main.go answer
+// their errors with it, its data is the driver
+const errorsContent = `// Generated By API Service Generator
+package errors
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+	"github.com/go-playground/validator/v10"
+{{- if eq . "mysql"}}
+	"github.com/go-sql-driver/mysql"
+{{- else}}
+	"github.com/lib/pq"
+{{- end}}
+)
+
+// ContentType is the media type of the problem responses of RFC 7807
+const ContentType = "application/problem+json"
+
+// Code identifies a problem, clients match on it instead of the detail
+type Code string
+
+const (
+	CodeInvalidID           Code = "INVALID_ID"
+	CodeInvalidBody         Code = "INVALID_BODY"
+	CodeInvalidParameters   Code = "INVALID_PARAMETERS"
+	CodeValidationFailed    Code = "VALIDATION_FAILED"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeRouteNotFound       Code = "ROUTE_NOT_FOUND"
+	CodeConflict            Code = "CONFLICT"
+	CodeDuplicate           Code = "DUPLICATE"
+	CodeReferenceViolation  Code = "REFERENCE_VIOLATION"
+	CodeConstraintViolation Code = "CONSTRAINT_VIOLATION"
+	CodeInternal            Code = "INTERNAL"
+	CodeNotImplemented      Code = "NOT_IMPLEMENTED"
+)
+
+var ch = alog.UseChannel("HTTP")
+
+// Error is a domain error, answered with its status, code and detail. Its cause is logged
+// when the status is 5xx and never answered.
+type Error struct {
+	Status int
+	Code   Code
+	Detail string
+	// Fields are the checks a VALIDATION_FAILED request failed
+	Fields []FieldError
+	Err    error
+}
+
+// FieldError is a failed check of a field of the request body
+type FieldError struct {
+	Field string ` + "`" + `json:"field"` + "`" + `
+	Rule  string ` + "`" + `json:"rule"` + "`" + `
+}
+
+func New(status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Cause is the error logged for a 5xx, the wrapped one when there is one
+func (e *Error) Cause() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return e
+}
+
+func NotFound(resource string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, resource+" not found")
+}
+
+func RouteNotFound() *Error {
+	return New(http.StatusNotFound, CodeRouteNotFound, "no route matches the request")
+}
+
+// Conflict is the error of a write that clashes with the stored row, like a stale version
+func Conflict(detail string) *Error {
+	return New(http.StatusConflict, CodeConflict, detail)
+}
+
+// Duplicate is the error of a write that breaks a unique constraint
+func Duplicate(detail string) *Error {
+	return New(http.StatusConflict, CodeDuplicate, detail)
+}
+
+func Unauthorized(err error) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Detail: err.Error(), Err: err}
+}
+
+func InvalidID(err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidID, Detail: "invalid id", Err: err}
+}
+
+// Binding is the error of a request body that does not decode or breaks its binding tags
+func Binding(err error) *Error {
+	if fields := fieldErrors(err); fields != nil {
+		return &Error{Status: http.StatusBadRequest, Code: CodeValidationFailed, Detail: "the request body is invalid", Fields: fields, Err: err}
+	}
+	if errors.Is(err, io.EOF) {
+		return &Error{Status: http.StatusBadRequest, Code: CodeInvalidBody, Detail: "the request body is empty", Err: err}
+	}
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidBody, Detail: "the request body is not valid JSON", Err: err}
+}
+
+// Parameters is the error of path, query or header parameters that do not bind or break their binding tags
+func Parameters(err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidParameters, Detail: "the request parameters are invalid", Fields: fieldErrors(err), Err: err}
+}
+
+// fieldErrors are the binding tags err breaks, nil when it is not a validation error
+func fieldErrors(err error) []FieldError {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return nil
+	}
+	fields := []FieldError{}
+	for _, fieldErr := range fieldErrs {
+		fields = append(fields, FieldError{Field: fieldErr.Field(), Rule: fieldErr.Tag()})
+	}
+	return fields
+}
+
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "internal server error", Err: err}
+}
+
+// Panic is the error of a handler that panicked, with the stack of the panic
+func Panic(rec any) *Error {
+	return Internal(fmt.Errorf("panic: %v\n%s", rec, debug.Stack()))
+}
+
+// From maps err to the Error it is answered with: the domain errors as they are, sql.ErrNoRows
+// to NOT_FOUND, the constraint violations of the driver to 409 and 422 and the rest to INTERNAL
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Detail: "the resource was not found", Err: err}
+	}
+{{- if eq . "mysql"}}
+	var driverErr *mysql.MySQLError
+	if errors.As(err, &driverErr) {
+		switch driverErr.Number {
+		case 1062:
+			return &Error{Status: http.StatusConflict, Code: CodeDuplicate, Detail: "the resource already exists", Err: err}
+		case 1451, 1452:
+			return &Error{Status: http.StatusConflict, Code: CodeReferenceViolation, Detail: "a referenced resource is missing, or the resource is still referenced", Err: err}
+		case 1048, 1264, 1366, 1406, 3819:
+			// null, out of range, incorrect and too long values and check constraints
+			return &Error{Status: http.StatusUnprocessableEntity, Code: CodeConstraintViolation, Detail: "the request breaks a constraint of the database", Err: err}
+		}
+	}
+{{- else}}
+	var driverErr *pq.Error
+	if errors.As(err, &driverErr) {
+		switch {
+		case driverErr.Code == "23505":
+			return &Error{Status: http.StatusConflict, Code: CodeDuplicate, Detail: "the resource already exists", Err: err}
+		case driverErr.Code == "23503":
+			return &Error{Status: http.StatusConflict, Code: CodeReferenceViolation, Detail: "a referenced resource is missing, or the resource is still referenced", Err: err}
+		case driverErr.Code.Class() == "23" || driverErr.Code.Class() == "22":
+			// the integrity constraints and the invalid data
+			e := &Error{Status: http.StatusUnprocessableEntity, Code: CodeConstraintViolation, Detail: "the request breaks a constraint of the database", Err: err}
+			if driverErr.Constraint != "" {
+				e.Detail = "the request breaks the constraint " + driverErr.Constraint
+			}
+			return e
+		}
+	}
+{{- end}}
+	return Internal(err)
+}
+
+// Problem is the application/problem+json body of RFC 7807, with the code of the error
+type Problem struct {
+	Type     string       ` + "`" + `json:"type"` + "`" + `
+	Title    string       ` + "`" + `json:"title"` + "`" + `
+	Status   int          ` + "`" + `json:"status"` + "`" + `
+	Detail   string       ` + "`" + `json:"detail,omitempty"` + "`" + `
+	Instance string       ` + "`" + `json:"instance,omitempty"` + "`" + `
+	Code     Code         ` + "`" + `json:"code"` + "`" + `
+	Errors   []FieldError ` + "`" + `json:"errors,omitempty"` + "`" + `
+}
+
+// Problem is the body of the error for the request to instance
+func (e *Error) Problem(instance string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Detail,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
+
+// Write answers err as a problem, the 5xx are logged with their cause
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	if e.Status >= http.StatusInternalServerError {
+		ch.Log(alog.ERROR, "%s %s: %v", r.Method, r.URL.Path, e.Cause())
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(e.Problem(r.URL.Path))
+}
+`
+
+// CreateErrorsFile writes the error model shared by the controllers, the middleware and main.go
+func CreateErrorsFile(wrkDir, dbms string) error {
+	fileName := fmt.Sprintf(ErrorsPath, wrkDir) + "errors.go"
+	return common.CreateFileAndItsContent(fileName, dbms, errorsContent)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateErrorsFile(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/errors/errors.go", "mysql", errorsContent).Return(nil)
+
+	assert.NoError(t, CreateErrorsFile("dir", "mysql"))
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestErrorsContent(t *testing.T) {
+	postgres := render(t, errorsContent, "postgres")
+	_, err := parser.ParseFile(token.NewFileSet(), "errors.go", postgres, 0)
+	require.NoError(t, err, postgres)
+	assert.Contains(t, postgres, "\t\"github.com/lib/pq\"\n")
+	assert.Contains(t, postgres, "case driverErr.Code == \"23503\":")
+	assert.NotContains(t, postgres, "go-sql-driver")
+
+	mysql := render(t, errorsContent, "mysql")
+	_, err = parser.ParseFile(token.NewFileSet(), "errors.go", mysql, 0)
+	require.NoError(t, err, mysql)
+	assert.Contains(t, mysql, "\t\"github.com/go-sql-driver/mysql\"\n")
+	assert.Contains(t, mysql, "\t\tcase 1451, 1452:\n")
+	assert.NotContains(t, mysql, "lib/pq")
+}
+
+func TestControllerContent_Problems(t *testing.T) {
+	apiInputs := orderInputs(t, "orders", models.TableSpec{Columns: []models.Column{{Name: "item", Type: "TEXT"}}})
+
+	// the handlers answer every error through apierrors, none of them writes its own body
+	for _, framework := range []string{"gin", "chi", "stdlib"} {
+		apiInputs.Framework = framework
+		content := controllerContent
+		if common.NetHTTP(framework) {
+			content = httpControllerContent
+		}
+		controller := render(t, content, apiInputs)
+		_, err := parser.ParseFile(token.NewFileSet(), "controller.go", controller, 0)
+		require.NoError(t, err, controller)
+		assert.Contains(t, controller, "\tapierrors \"example/api-service/services/orders/api/errors\"\n", framework)
+		assert.Contains(t, controller, "\t\terr = apierrors.NotFound(\"orders\")\n", framework)
+		assert.Contains(t, controller, "apierrors.Binding(err)", framework)
+		assert.Contains(t, controller, "apierrors.InvalidID(err)", framework)
+		assert.NotContains(t, controller, "\"error\"", framework)
+		assert.NotContains(t, controller, "StatusBadRequest", framework)
+	}
+
+	service := render(t, serviceContent, apiInputs)
+	_, err := parser.ParseFile(token.NewFileSet(), "service.go", service, 0)
+	require.NoError(t, err, service)
+	assert.Contains(t, service, "var ErrConflict = apierrors.Conflict(\"orders was changed by another request\")")
+	assert.Contains(t, service, "var ErrDuplicate = apierrors.Duplicate(\"orders already exists\")")
+}
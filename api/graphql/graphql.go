@@ -0,0 +1,911 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	GraphQLPath = "%s/api/graphql/"
+	// Packages of the GraphQL server, added to the go.mod of the service
+	Packages = []string{"github.com/graph-gophers/graphql-go", "github.com/graph-gophers/dataloader/v7"}
+)
+
+// Setup writes the schema of the API groups and its resolvers to api/graphql, the groups of a table share its type
+func Setup(groups ...models.APIInputs) (err error) {
+	data, err := graphQLData(groups...)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf(GraphQLPath, data.WrkDir)
+	err = common.CreateDirectory(path)
+	if err != nil {
+		return err
+	}
+
+	files := []struct{ name, content string }{
+		{"schema.graphql", schemaContent},
+		{"graphql.go", handlerContent},
+		{"playground.html", playgroundContent},
+		{"resolvers.go", resolversContent},
+		{"loaders.go", loadersContent},
+		{"scalars.go", scalarsContent},
+	}
+	for _, file := range files {
+		err = common.CreateFileAndItsContent(path+file.name, data, file.content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conversion of a Go type of the db model to a GraphQL type, goType is the type of the resolver
+type conversion struct {
+	graphQL string
+	goType  string
+	to      string
+	from    string
+}
+
+func (c conversion) identity() bool {
+	return c.to == "%s" && c.from == "%s"
+}
+
+var conversions = map[string]conversion{
+	"string":          {"String", "string", "%s", "%s"},
+	"int8":            {"Int", "int32", "int32(%s)", "int8(%s)"},
+	"int16":           {"Int", "int32", "int32(%s)", "int16(%s)"},
+	"int32":           {"Int", "int32", "%s", "%s"},
+	"int64":           {"Int64", "Int64", "Int64(%s)", "int64(%s)"},
+	"bool":            {"Boolean", "bool", "%s", "%s"},
+	"float32":         {"Float", "float64", "float64(%s)", "float32(%s)"},
+	"float64":         {"Float", "float64", "%s", "%s"},
+	"[]byte":          {"String", "string", "base64.StdEncoding.EncodeToString(%s)", "parse(&err, %s, base64.StdEncoding.DecodeString)"},
+	"time.Time":       {"Time", "gql.Time", "gql.Time{Time: %s}", "%s.Time"},
+	"uuid.UUID":       {"String", "string", "%s.String()", "parse(&err, %s, uuid.Parse)"},
+	"json.RawMessage": {"JSON", "JSON", "JSON(%s)", "json.RawMessage(%s)"},
+}
+
+// field of a column, nullable columns are nullable and arrays are lists
+func field(name, fieldName, goType string) (models.GraphQLField, error) {
+	f := models.GraphQLField{Name: lowerCamel(name), FieldName: fieldName}
+	base, kind := goType, ""
+	switch {
+	case strings.HasPrefix(goType, "*"):
+		base, kind = goType[1:], "*"
+	case strings.HasPrefix(goType, "[]") && goType != "[]byte":
+		base, kind = goType[2:], "[]"
+	}
+	c, found := conversions[base]
+	if !found {
+		return f, fmt.Errorf("column %s: Go type %s has no GraphQL type", name, goType)
+	}
+
+	f.Type, f.GoType, f.ToGraphQL, f.FromGraphQL = c.graphQL+"!", c.goType, c.to, c.from
+	switch kind {
+	case "*":
+		f.Type, f.GoType = c.graphQL, "*"+c.goType
+	case "[]":
+		f.Type, f.GoType = "["+c.graphQL+"!]!", "[]"+c.goType
+	}
+	if kind == "" || c.identity() {
+		return f, nil
+	}
+	convert := "mapPtr"
+	if kind == "[]" {
+		convert = "mapSlice"
+	}
+	f.ToGraphQL = fmt.Sprintf("%s(%%s, func(v %s) %s { return %s })", convert, base, c.goType, fmt.Sprintf(c.to, "v"))
+	f.FromGraphQL = fmt.Sprintf("%s(%%s, func(v %s) %s { return %s })", convert, c.goType, base, fmt.Sprintf(c.from, "v"))
+	return f, nil
+}
+
+// keyField is the id of a row, an ID in the schema whatever the primary key
+func keyField(primaryKey string) (models.GraphQLField, error) {
+	f := models.GraphQLField{Name: "id", FieldName: "ID", Type: "ID!", GoType: "gql.ID"}
+	switch primaryKey {
+	case "serial":
+		f.ToGraphQL = "gql.ID(strconv.FormatInt(int64(%s), 10))"
+	case "bigserial":
+		f.ToGraphQL = "gql.ID(strconv.FormatInt(%s, 10))"
+	case "uuid":
+		f.ToGraphQL = "gql.ID(%s.String())"
+	case "ulid":
+		f.ToGraphQL = "gql.ID(%s)"
+	default:
+		return f, fmt.Errorf("primary key %q has no GraphQL type", primaryKey)
+	}
+	return f, nil
+}
+
+// graphQLData is the schema of the groups: a type per table with the relationships of its foreign keys, and the fields of every group
+func graphQLData(groups ...models.APIInputs) (models.GraphQL, error) {
+	if len(groups) == 0 {
+		return models.GraphQL{}, fmt.Errorf("no API group to serve over GraphQL")
+	}
+	data := models.GraphQL{GoModule: groups[0].GoModule, WrkDir: groups[0].WrkDir, Framework: groups[0].Framework}
+
+	// the first group of a table loads its rows
+	loading := map[string]models.APIInputs{}
+	var expressions []string
+	for _, group := range groups {
+		group.APIGroupTitle = common.ToCamelCase(group.APIGroup)
+		group.TableNameTitle = common.ToCamelCase(group.TableName)
+		input := models.GraphQLGroup{APIInputs: group, Field: lowerCamel(group.APIGroup)}
+		for _, column := range group.Table.Columns {
+			f, err := field(column.Name, column.FieldName, column.GoType)
+			if err != nil {
+				return data, err
+			}
+			input.Input = append(input.Input, f)
+			expressions = append(expressions, f.GoType, f.FromGraphQL)
+		}
+		data.Groups = append(data.Groups, input)
+		expressions = append(expressions, keyParse[group.Table.PrimaryKey])
+
+		if _, found := loading[group.TableName]; found {
+			continue
+		}
+		loading[group.TableName] = group
+		table, err := tableType(group)
+		if err != nil {
+			return data, err
+		}
+		data.Tables = append(data.Tables, table)
+	}
+
+	// the foreign keys between the tables of the schema
+	loaders := map[string]bool{}
+	for i, table := range data.Tables {
+		group := loading[table.TableName]
+		for _, column := range group.Table.Columns {
+			referenced, found := loading[column.References]
+			if column.References == "" || !found {
+				continue
+			}
+			key := strings.TrimPrefix(column.GoType, "*")
+			if key != referenced.Table.Key.GoType {
+				return data, fmt.Errorf("column %s of %s is %s, the id of %s is %s", column.Name, table.TableName, key, referenced.TableName, referenced.Table.Key.GoType)
+			}
+
+			byID := models.GraphQLLoader{Name: referenced.TableNameTitle + "ByID", Key: key, Table: referenced.TableNameTitle, Group: referenced.APIGroup, Method: "Get" + referenced.APIGroupTitle + "ByIDs", FieldName: "ID"}
+			if !loaders[byID.Name] {
+				loaders[byID.Name] = true
+				data.Loaders = append(data.Loaders, byID)
+			}
+			byColumn := models.GraphQLLoader{Name: table.Name + "By" + column.FieldName, Key: key, Table: table.Name, List: true, Group: group.APIGroup, Method: "List" + group.APIGroupTitle + "By" + column.FieldName, FieldName: column.FieldName, Nullable: column.Nullable}
+			data.Loaders = append(data.Loaders, byColumn)
+
+			name := strings.TrimSuffix(column.Name, "_id")
+			if name == column.Name {
+				name += "_record"
+			}
+			data.Tables[i].Relations = append(data.Tables[i].Relations, models.GraphQLRelation{
+				Name: lowerCamel(name), Method: common.ToCamelCase(name), Table: referenced.TableNameTitle, Loader: byID.Name,
+				FieldName: column.FieldName, Nullable: column.Nullable,
+				Comment: fmt.Sprintf("is the row of %s that %s references", referenced.TableName, column.Name),
+			})
+			for j := range data.Tables {
+				if data.Tables[j].TableName != referenced.TableName {
+					continue
+				}
+				data.Tables[j].Relations = append(data.Tables[j].Relations, models.GraphQLRelation{
+					Name: lowerCamel(table.TableName) + "By" + common.ToCamelCase(column.Name), Method: table.Name + "By" + column.FieldName, Table: table.Name, List: true, Loader: byColumn.Name,
+					Comment: fmt.Sprintf("are the rows of %s whose %s references this row", table.TableName, column.Name),
+				})
+			}
+		}
+	}
+
+	for _, table := range data.Tables {
+		names := map[string]bool{}
+		for _, f := range table.Fields {
+			names[f.Name] = true
+			expressions = append(expressions, f.Type, f.ToGraphQL)
+		}
+		for _, relation := range table.Relations {
+			if names[relation.Name] {
+				return data, fmt.Errorf("field %s of %s is both a column and a relationship, rename the column", relation.Name, table.Name)
+			}
+			names[relation.Name] = true
+		}
+	}
+	scalars := map[string]bool{}
+	for _, f := range fields(data) {
+		scalars[strings.Trim(f.Type, "[]!")] = true
+	}
+	for _, scalar := range []string{"Int64", "JSON", "Time"} {
+		if scalars[scalar] {
+			data.Scalars = append(data.Scalars, scalar)
+		}
+	}
+	all := strings.Join(expressions, " ")
+	data.Imports = resolverImports(data, all)
+	data.LoaderImports = loaderImports(data)
+	return data, nil
+}
+
+// fields are the fields of the object and input types
+func fields(data models.GraphQL) []models.GraphQLField {
+	var all []models.GraphQLField
+	for _, table := range data.Tables {
+		all = append(all, table.Fields...)
+	}
+	for _, group := range data.Groups {
+		all = append(all, group.Input...)
+	}
+	return all
+}
+
+// keyParse is how the resolvers parse an id, for the imports
+var keyParse = map[string]string{
+	"serial":    "strconv.",
+	"bigserial": "strconv.",
+	"uuid":      "uuid.",
+	"ulid":      "ulid.",
+}
+
+// tableType is the object type of the rows of the table of the group, with the columns the generator adds
+func tableType(group models.APIInputs) (models.GraphQLTable, error) {
+	table := models.GraphQLTable{Name: group.TableNameTitle, TableName: group.TableName}
+	key, err := keyField(group.Table.PrimaryKey)
+	if err != nil {
+		return table, err
+	}
+	table.Fields = append(table.Fields, key)
+
+	type added struct{ name, fieldName, goType string }
+	columns := []added{}
+	for _, column := range group.Table.Columns {
+		columns = append(columns, added{column.Name, column.FieldName, column.GoType})
+	}
+	if group.Table.Timestamps {
+		columns = append(columns, added{"created_at", "CreatedAt", "time.Time"}, added{"updated_at", "UpdatedAt", "time.Time"})
+	}
+	if group.Table.SoftDelete {
+		columns = append(columns, added{"deleted_at", "DeletedAt", "*time.Time"})
+	}
+	if group.Table.Versioned {
+		columns = append(columns, added{"version", "Version", "int32"})
+	}
+	for _, column := range columns {
+		f, err := field(column.name, column.fieldName, column.goType)
+		if err != nil {
+			return table, err
+		}
+		table.Fields = append(table.Fields, f)
+	}
+	return table, nil
+}
+
+// resolverImports are the imports of resolvers.go, the standard library, the module and the others
+func resolverImports(data models.GraphQL, expressions string) []string {
+	std := []string{"context", "database/sql", "errors"}
+	others := []string{`gql "github.com/graph-gophers/graphql-go"`}
+	for pkg, used := range map[string]bool{
+		"encoding/base64": strings.Contains(expressions, "base64."),
+		"encoding/json":   strings.Contains(expressions, "json.RawMessage"),
+		"strconv":         strings.Contains(expressions, "strconv."),
+		"time":            strings.Contains(strings.ReplaceAll(expressions, "gql.Time", ""), "time.Time"),
+	} {
+		if used {
+			std = append(std, pkg)
+		}
+	}
+	if strings.Contains(expressions, "uuid.") {
+		others = append(others, "github.com/google/uuid")
+	}
+	if strings.Contains(expressions, "ulid.") {
+		others = append(others, "github.com/oklog/ulid/v2")
+	}
+
+	module := data.GoModule + "/" + data.WrkDir
+	local := []string{module + "/api/v1/bind", module + "/pkg/db"}
+	for _, group := range data.Groups {
+		local = append(local, module+"/api/v1/"+group.APIGroup)
+	}
+	return join(std, local, others)
+}
+
+// loaderImports are the imports of loaders.go, which has no loader without foreign keys
+func loaderImports(data models.GraphQL) []string {
+	std := []string{"context"}
+	if len(data.Loaders) == 0 {
+		return join(std)
+	}
+	others := []string{"github.com/graph-gophers/dataloader/v7"}
+	for _, loader := range data.Loaders {
+		if strings.HasPrefix(loader.Key, "uuid.") {
+			others = append(others, "github.com/google/uuid")
+			break
+		}
+	}
+	return join(std, []string{data.GoModule + "/" + data.WrkDir + "/pkg/db"}, others)
+}
+
+// join quotes the import paths of the groups and separates the groups with ""
+func join(groups ...[]string) []string {
+	var imports []string
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if len(imports) > 0 {
+			imports = append(imports, "")
+		}
+		sort.Strings(group)
+		for _, path := range group {
+			if strings.Contains(path, `"`) {
+				imports = append(imports, path)
+				continue
+			}
+			imports = append(imports, strconv.Quote(path))
+		}
+	}
+	return imports
+}
+
+// lowerCamel is the name of a field in the schema, e.g. owner_id -> ownerId
+func lowerCamel(name string) string {
+	runes := []rune(common.ToCamelCase(name))
+	if len(runes) == 0 {
+		return ""
+	}
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+const schemaContent = `# Generated By API Service Generator
+schema {
+  query: Query
+  mutation: Mutation
+}
+{{- range .Scalars}}
+
+scalar {{.}}
+{{- end}}
+
+type Query {
+{{- range .Groups}}
+  "the rows of {{.TableName}}"
+  {{.Field}}: [{{.TableNameTitle}}!]!
+  "a row of {{.TableName}}, null when it does not exist"
+  {{.Field}}ById(id: ID!): {{.TableNameTitle}}
+{{- end}}
+}
+
+type Mutation {
+{{- range .Groups}}
+  create{{.APIGroupTitle}}(input: {{.APIGroupTitle}}Input!): {{.TableNameTitle}}!
+{{- if .Table.Versioned}}
+  "version is the version the client last read"
+  update{{.APIGroupTitle}}(id: ID!, input: {{.APIGroupTitle}}Input!, version: Int!): {{.TableNameTitle}}!
+{{- else}}
+  update{{.APIGroupTitle}}(id: ID!, input: {{.APIGroupTitle}}Input!): {{.TableNameTitle}}!
+{{- end}}
+  delete{{.APIGroupTitle}}(id: ID!): Boolean!
+{{- end}}
+}
+{{- range .Tables}}
+
+"a row of {{.TableName}}"
+type {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+{{- range .Relations}}
+  "{{.Comment}}"
+  {{.Name}}: {{if .List}}[{{.Table}}!]!{{else}}{{.Table}}{{end}}
+{{- end}}
+}
+{{- end}}
+{{- range .Groups}}
+
+"the columns of create{{.APIGroupTitle}} and update{{.APIGroupTitle}}"
+input {{.APIGroupTitle}}Input {
+{{- range .Input}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+}
+{{- end}}
+`
+
+const handlerContent = `// Generated By API Service Generator
+package graphql
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"net/http"
+
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+{{- range .Groups}}
+	"{{$.GoModule}}/{{$.WrkDir}}/api/v1/{{.APIGroup}}"
+{{- end}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/auth"
+	util "{{.GoModule}}/{{.WrkDir}}/utils"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+{{- if eq .Framework "chi"}}
+	"github.com/go-chi/chi/v5"
+{{- else if ne .Framework "stdlib"}}
+	"github.com/gin-gonic/gin"
+{{- end}}
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+//go:embed playground.html
+var playground []byte
+
+var ch = alog.UseChannel("GRAPHQL")
+
+var errInvalidID = errors.New("invalid id")
+
+// Resolver is the root of the schema, its methods resolve the fields of Query and Mutation
+type Resolver struct {
+{{- range .Groups}}
+	{{.APIGroup}}Svc {{.APIGroup}}.Service
+{{- end}}
+}
+
+// RegisterHandler serves the schema at POST /graphql behind the auth middleware,
+// and the GraphiQL playground at GET /graphql when GRAPHQL_PLAYGROUND=true
+func RegisterHandler(router {{template "router" .}}{{range .Groups}}, {{.APIGroup}}Svc {{.APIGroup}}.Service{{end}}) {
+	resolver := &Resolver{ {{- range $i, $group := .Groups}}{{if $i}}, {{end}}{{$group.APIGroup}}Svc: {{$group.APIGroup}}Svc{{end}}}
+	handler := &relay.Handler{Schema: gql.MustParseSchema(schema, resolver)}
+{{- if eq .Framework "chi" "stdlib"}}
+
+	serve := func(w http.ResponseWriter, r *http.Request) {
+		// the loaders batch and cache the lookups of one request
+		ctx := withLoaders(r.Context(), resolver)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+	servePlayground := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(playground)
+	}
+{{- end}}
+{{- if eq .Framework "chi"}}
+
+	router.With(auth.AuthMiddleware()).Post("/graphql", serve)
+	if util.GetAppConfig().GraphQLPlayground == "true" {
+		router.Get("/graphql", servePlayground)
+	}
+{{- else if eq .Framework "stdlib"}}
+
+	router.Handle("POST /graphql", auth.AuthMiddleware()(http.HandlerFunc(serve)))
+	if util.GetAppConfig().GraphQLPlayground == "true" {
+		router.HandleFunc("GET /graphql", servePlayground)
+	}
+{{- else}}
+
+	router.POST("/graphql", auth.AuthMiddleware(), func(c *gin.Context) {
+		// the loaders batch and cache the lookups of one request
+		ctx := withLoaders(c.Request.Context(), resolver)
+		handler.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	})
+	if util.GetAppConfig().GraphQLPlayground == "true" {
+		router.GET("/graphql", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", playground)
+		})
+	}
+{{- end}}
+}
+
+// resolverError answers an error of the Service with the detail of apierrors.From, the table instead
+// of sql: no rows in result set. The cause of a 5xx is logged, not sent.
+func resolverError(err error, table string) error {
+	e := apierrors.From(err)
+	if errors.Is(err, sql.ErrNoRows) {
+		e = apierrors.NotFound(table)
+	}
+	if e.Status >= http.StatusInternalServerError {
+		ch.Log(alog.ERROR, "%v", e.Cause())
+	}
+	return problem{e}
+}
+
+// problem is the error of a field, the code and the failed checks of the error model are its extensions
+type problem struct {
+	*apierrors.Error
+}
+
+func (p problem) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": p.Code}
+	if len(p.Fields) > 0 {
+		extensions["errors"] = p.Fields
+	}
+	return extensions
+}
+{{- define "router"}}{{if eq .Framework "chi"}}chi.Router{{else if eq .Framework "stdlib"}}*http.ServeMux{{else}}*gin.Engine{{end}}{{end}}
+`
+
+const playgroundContent = `<!DOCTYPE html>
+<!-- Generated By API Service Generator -->
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphiql@3/graphiql.min.css" />
+</head>
+<body style="margin: 0;">
+  <div id="graphiql" style="height: 100vh;"></div>
+  <script crossorigin src="https://cdn.jsdelivr.net/npm/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://cdn.jsdelivr.net/npm/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://cdn.jsdelivr.net/npm/graphiql@3/graphiql.min.js"></script>
+  <script>
+    // the Authorization header of the auth middleware goes in the Headers tab
+    const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, { fetcher, defaultEditorToolsVisibility: true }),
+    );
+  </script>
+</body>
+</html>
+`
+
+const resolversContent = `// Generated By API Service Generator
+package graphql
+
+import (
+{{- range .Imports}}
+{{- if .}}
+	{{.}}
+{{- else}}
+{{end}}
+{{- end}}
+)
+{{- range .Tables}}
+{{- $table := .}}
+
+// {{.Name}}Resolver resolves a row of {{.TableName}}
+type {{.Name}}Resolver struct {
+	row db.{{.Name}}
+}
+
+func new{{.Name}}Resolvers(rows []db.{{.Name}}) []*{{.Name}}Resolver {
+	resolvers := make([]*{{.Name}}Resolver, len(rows))
+	for i, row := range rows {
+		resolvers[i] = &{{.Name}}Resolver{row: row}
+	}
+	return resolvers
+}
+{{- range .Fields}}
+
+func (r *{{$table.Name}}Resolver) {{.FieldName}}() {{.GoType}} {
+	return {{printf .ToGraphQL (print "r.row." .FieldName)}}
+}
+{{- end}}
+{{- range .Relations}}
+
+// {{.Method}} {{.Comment}}, loaded in batches
+{{- if .List}}
+func (r *{{$table.Name}}Resolver) {{.Method}}(ctx context.Context) ([]*{{.Table}}Resolver, error) {
+	rows, err := loadersFrom(ctx).{{.Loader}}.Load(ctx, r.row.ID)()
+	if err != nil {
+		return nil, resolverError(err, "{{.Name}}")
+	}
+	return new{{.Table}}Resolvers(rows), nil
+}
+{{- else}}
+func (r *{{$table.Name}}Resolver) {{.Method}}(ctx context.Context) (*{{.Table}}Resolver, error) {
+{{- if .Nullable}}
+	if r.row.{{.FieldName}} == nil {
+		return nil, nil
+	}
+{{- end}}
+	row, err := loadersFrom(ctx).{{.Loader}}.Load(ctx, {{if .Nullable}}*{{end}}r.row.{{.FieldName}})()
+	if err != nil {
+		return nil, resolverError(err, "{{.Name}}")
+	}
+	if row == nil {
+		return nil, nil
+	}
+	return &{{.Table}}Resolver{row: *row}, nil
+}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- range .Groups}}
+
+// {{.APIGroupTitle}} lists the rows of {{.TableName}}
+func (r *Resolver) {{.APIGroupTitle}}(ctx context.Context) ([]*{{.TableNameTitle}}Resolver, error) {
+	rows, err := r.{{.APIGroup}}Svc.Get{{.APIGroupTitle}}(ctx)
+	if err != nil {
+		return nil, resolverError(err, "{{.TableName}}")
+	}
+	return new{{.TableNameTitle}}Resolvers(rows), nil
+}
+
+// {{.APIGroupTitle}}ByID is null when the row does not exist
+func (r *Resolver) {{.APIGroupTitle}}ByID(ctx context.Context, args struct{ ID gql.ID }) (*{{.TableNameTitle}}Resolver, error) {
+	id, err := parse{{.APIGroupTitle}}ID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.{{.APIGroup}}Svc.Get{{.APIGroupTitle}}ByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err, "{{.TableName}}")
+	}
+	return &{{.TableNameTitle}}Resolver{row: row}, nil
+}
+
+func (r *Resolver) Create{{.APIGroupTitle}}(ctx context.Context, args struct{ Input {{.APIGroupTitle}}Input }) (*{{.TableNameTitle}}Resolver, error) {
+	req, err := from{{.APIGroupTitle}}Input(args.Input)
+	if err == nil {
+		err = bind.Validate(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.{{.APIGroup}}Svc.Create{{.APIGroupTitle}}(ctx, req)
+	if err != nil {
+		return nil, resolverError(err, "{{.TableName}}")
+	}
+	return &{{.TableNameTitle}}Resolver{row: row}, nil
+}
+
+func (r *Resolver) Update{{.APIGroupTitle}}(ctx context.Context, args struct {
+	ID    gql.ID
+	Input {{.APIGroupTitle}}Input
+{{- if .Table.Versioned}}
+	Version int32
+{{- end}}
+}) (*{{.TableNameTitle}}Resolver, error) {
+	id, err := parse{{.APIGroupTitle}}ID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	input, err := from{{.APIGroupTitle}}Input(args.Input)
+{{- if .Table.Versioned}}
+	req := {{.APIGroup}}.{{.APIGroupTitle}}UpdateRequest{ {{- .APIGroupTitle}}Request: input, Version: args.Version}
+{{- else}}
+	req := input
+{{- end}}
+	if err == nil {
+		err = bind.Validate(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.{{.APIGroup}}Svc.Update{{.APIGroupTitle}}(ctx, id, req)
+	if err != nil {
+		return nil, resolverError(err, "{{.TableName}}")
+	}
+	return &{{.TableNameTitle}}Resolver{row: row}, nil
+}
+
+func (r *Resolver) Delete{{.APIGroupTitle}}(ctx context.Context, args struct{ ID gql.ID }) (bool, error) {
+	id, err := parse{{.APIGroupTitle}}ID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.{{.APIGroup}}Svc.Delete{{.APIGroupTitle}}(ctx, id); err != nil {
+		return false, resolverError(err, "{{.TableName}}")
+	}
+	return true, nil
+}
+
+// {{.APIGroupTitle}}Input is the input of create{{.APIGroupTitle}} and update{{.APIGroupTitle}}
+type {{.APIGroupTitle}}Input struct {
+{{- range .Input}}
+	{{.FieldName}} {{.GoType}}
+{{- end}}
+}
+
+// from{{.APIGroupTitle}}Input converts the input to the request of the Service, it is validated like the REST body
+func from{{.APIGroupTitle}}Input(in {{.APIGroupTitle}}Input) ({{.APIGroup}}.{{.APIGroupTitle}}Request, error) {
+	var err error
+	req := {{.APIGroup}}.{{.APIGroupTitle}}Request{
+{{- range .Input}}
+		{{.FieldName}}: {{printf .FromGraphQL (print "in." .FieldName)}},
+{{- end}}
+	}
+	return req, err
+}
+
+// parse{{.APIGroupTitle}}ID reads the {{.Table.PrimaryKey}} primary key from an ID
+func parse{{.APIGroupTitle}}ID(id gql.ID) ({{.Table.Key.GoType}}, error) {
+{{- if eq .Table.PrimaryKey "serial"}}
+	value, err := strconv.ParseInt(string(id), 10, 32)
+	if err != nil {
+		return 0, errInvalidID
+	}
+	return int32(value), nil
+{{- else if eq .Table.PrimaryKey "bigserial"}}
+	value, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, errInvalidID
+	}
+	return value, nil
+{{- else if eq .Table.PrimaryKey "uuid"}}
+	value, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, errInvalidID
+	}
+	return value, nil
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	value, err := ulid.ParseStrict(string(id))
+	if err != nil {
+		return "", errInvalidID
+	}
+	return value.String(), nil
+{{- end}}
+}
+{{- end}}
+`
+
+const loadersContent = `// Generated By API Service Generator
+package graphql
+
+import (
+{{- range .LoaderImports}}
+{{- if .}}
+	{{.}}
+{{- else}}
+{{end}}
+{{- end}}
+)
+
+type loadersKey struct{}
+
+// loaders batch the lookups of the relationships of a request, each key is loaded once per request
+type loaders struct {
+{{- range .Loaders}}
+	{{.Name}} *dataloader.Loader[{{.Key}}, {{if .List}}[]db.{{.Table}}{{else}}*db.{{.Table}}{{end}}]
+{{- end}}
+}
+
+func withLoaders(ctx context.Context, r *Resolver) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders(r))
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func newLoaders(r *Resolver) *loaders {
+	return &loaders{
+{{- range .Loaders}}
+{{- if .List}}
+		{{.Name}}: dataloader.NewBatchedLoader(func(ctx context.Context, values []{{.Key}}) []*dataloader.Result[[]db.{{.Table}}] {
+			rows, err := r.{{.Group}}Svc.{{.Method}}(ctx, values)
+			grouped := make(map[{{.Key}}][]db.{{.Table}}, len(values))
+			for _, row := range rows {
+{{- if .Nullable}}
+				if row.{{.FieldName}} != nil {
+					grouped[*row.{{.FieldName}}] = append(grouped[*row.{{.FieldName}}], row)
+				}
+{{- else}}
+				grouped[row.{{.FieldName}}] = append(grouped[row.{{.FieldName}}], row)
+{{- end}}
+			}
+			results := make([]*dataloader.Result[[]db.{{.Table}}], len(values))
+			for i, value := range values {
+				results[i] = &dataloader.Result[[]db.{{.Table}}]{Data: grouped[value], Error: err}
+			}
+			return results
+		}),
+{{- else}}
+		{{.Name}}: dataloader.NewBatchedLoader(func(ctx context.Context, ids []{{.Key}}) []*dataloader.Result[*db.{{.Table}}] {
+			rows, err := r.{{.Group}}Svc.{{.Method}}(ctx, ids)
+			byID := make(map[{{.Key}}]*db.{{.Table}}, len(rows))
+			for i := range rows {
+				byID[rows[i].ID] = &rows[i]
+			}
+			results := make([]*dataloader.Result[*db.{{.Table}}], len(ids))
+			for i, id := range ids {
+				results[i] = &dataloader.Result[*db.{{.Table}}]{Data: byID[id], Error: err}
+			}
+			return results
+		}),
+{{- end}}
+{{- end}}
+	}
+}
+`
+
+const scalarsContent = `// Generated By API Service Generator
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Int64 is the Int64 scalar of bigint columns, a GraphQL Int has 32 bits
+type Int64 int64
+
+func (Int64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+func (i *Int64) UnmarshalGraphQL(input interface{}) error {
+	switch value := input.(type) {
+	case int32:
+		*i = Int64(value)
+	case float64:
+		*i = Int64(value)
+	case string:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*i = Int64(parsed)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", input)
+	}
+	return nil
+}
+
+func (i Int64) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(i), 10), nil
+}
+
+// JSON is the JSON scalar of json columns, any value
+type JSON json.RawMessage
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	raw, err := json.Marshal(input)
+	*j = raw
+	return err
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// mapPtr converts a nullable value, nil stays nil
+func mapPtr[T, U any](value *T, convert func(T) U) *U {
+	if value == nil {
+		return nil
+	}
+	converted := convert(*value)
+	return &converted
+}
+
+// mapSlice converts the values of an array column
+func mapSlice[T, U any](values []T, convert func(T) U) []U {
+	if values == nil {
+		return nil
+	}
+	converted := make([]U, len(values))
+	for i, value := range values {
+		converted[i] = convert(value)
+	}
+	return converted
+}
+
+// parse converts a value that can be invalid, the first error is kept in err
+func parse[T any](err *error, value string, convert func(string) (T, error)) T {
+	parsed, parseErr := convert(value)
+	if parseErr != nil && *err == nil {
+		*err = parseErr
+	}
+	return parsed
+}
+`
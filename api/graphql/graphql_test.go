@@ -0,0 +1,197 @@
+package graphql
+
+import (
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+func apiInputs(t *testing.T, group, tableName, primaryKey string, columns ...models.Column) models.APIInputs {
+	table := models.TableSpec{PrimaryKey: primaryKey, Columns: columns, Timestamps: true, SoftDelete: true, Versioned: true}
+	// the referenced tables are tables of the spec
+	tables := []string{tableName}
+	for _, column := range columns {
+		tables = append(tables, column.References)
+	}
+	require.NoError(t, spec.Resolve(&table, "postgres", tables...))
+	return models.APIInputs{WrkDir: "dir", GoModule: "example.com/app", APIGroup: group, TableName: tableName, DBMS: "postgres", Table: table, GraphQL: true}
+}
+
+// capture returns the files Setup writes, rendered, by their name
+func capture(t *testing.T, setup func() error) (map[string]string, error) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	files := map[string]string{}
+	mockCmdsExecutor.On("CreateDirectory", "dir/api/graphql/").Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var rendered bytes.Buffer
+		tmpl := template.Must(template.New("file").Parse(args.String(2)))
+		require.NoError(t, tmpl.Execute(&rendered, args.Get(1)))
+		files[strings.TrimPrefix(args.String(0), "dir/api/graphql/")] = rendered.String()
+	}).Return(nil)
+
+	err := setup()
+	return files, err
+}
+
+func parseGo(t *testing.T, files map[string]string) {
+	for name, content := range files {
+		if strings.HasSuffix(name, ".go") {
+			_, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ParseComments)
+			assert.NoError(t, err, name+"\n"+content)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	categories := apiInputs(t, "categories", "category", "serial",
+		models.Column{Name: "name", Type: "TEXT"},
+		models.Column{Name: "parent_id", Type: "INTEGER", Nullable: true, References: "category"},
+		models.Column{Name: "views", Type: "BIGINT"},
+		models.Column{Name: "attributes", Type: "JSONB", Nullable: true},
+		models.Column{Name: "tags", Type: "TEXT[]"},
+	)
+	files, err := capture(t, func() error { return Setup(categories) })
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"schema.graphql", "graphql.go", "playground.html", "resolvers.go", "loaders.go", "scalars.go"}, keys(files))
+	parseGo(t, files)
+
+	schema := files["schema.graphql"]
+	for _, line := range []string{
+		"scalar Int64\n\nscalar JSON\n\nscalar Time\n",
+		"  categories: [Category!]!\n",
+		"  categoriesById(id: ID!): Category\n",
+		"  updateCategories(id: ID!, input: CategoriesInput!, version: Int!): Category!\n",
+		"  deleteCategories(id: ID!): Boolean!\n",
+		"type Category {\n  id: ID!\n  name: String!\n  parentId: Int\n  views: Int64!\n  attributes: JSON\n  tags: [String!]!\n  createdAt: Time!\n",
+		"  deletedAt: Time\n  version: Int!\n",
+		"  parent: Category\n",
+		"  categoryByParentId: [Category!]!\n",
+		"input CategoriesInput {\n  name: String!\n  parentId: Int\n  views: Int64!\n",
+	} {
+		assert.Contains(t, schema, line)
+	}
+
+	resolvers := files["resolvers.go"]
+	for _, line := range []string{
+		`"strconv"`,
+		`"example.com/app/dir/api/v1/categories"`,
+		"return gql.ID(strconv.FormatInt(int64(r.row.ID), 10))",
+		"return Int64(r.row.Views)",
+		"return mapPtr(r.row.Attributes, func(v json.RawMessage) JSON { return JSON(v) })",
+		"return mapPtr(r.row.DeletedAt, func(v time.Time) gql.Time { return gql.Time{Time: v} })",
+		"row, err := loadersFrom(ctx).CategoryByID.Load(ctx, *r.row.ParentID)()",
+		"rows, err := loadersFrom(ctx).CategoryByParentID.Load(ctx, r.row.ID)()",
+		"Views: int64(in.Views),",
+		"req := categories.CategoriesUpdateRequest{CategoriesRequest: input, Version: args.Version}",
+		// the errors of the Service go through the error model, the causes of a 5xx are not sent
+		"return nil, resolverError(err, \"parent\")",
+		"return nil, resolverError(err, \"category\")",
+		"return false, resolverError(err, \"category\")",
+	} {
+		assert.Contains(t, resolvers, line)
+	}
+
+	loaders := files["loaders.go"]
+	assert.Contains(t, loaders, "CategoryByID *dataloader.Loader[int32, *db.Category]")
+	assert.Contains(t, loaders, "CategoryByParentID *dataloader.Loader[int32, []db.Category]")
+	assert.Contains(t, loaders, "rows, err := r.categoriesSvc.GetCategoriesByIDs(ctx, ids)")
+	assert.Contains(t, loaders, "rows, err := r.categoriesSvc.ListCategoriesByParentID(ctx, values)")
+	assert.Contains(t, loaders, "grouped[*row.ParentID] = append(grouped[*row.ParentID], row)")
+
+	assert.Contains(t, files["graphql.go"], "func RegisterHandler(router *gin.Engine, categoriesSvc categories.Service) {")
+	assert.Contains(t, files["graphql.go"], "resolver := &Resolver{categoriesSvc: categoriesSvc}")
+	assert.Contains(t, files["graphql.go"], `apierrors "example.com/app/dir/api/errors"`)
+	assert.Contains(t, files["graphql.go"], "\te := apierrors.From(err)\n")
+	assert.Contains(t, files["graphql.go"], "\t\tch.Log(alog.ERROR, \"%v\", e.Cause())\n")
+}
+
+func TestSetup_WithoutRelations(t *testing.T) {
+	items := apiInputs(t, "items", "item", "uuid", models.Column{Name: "name", Type: "TEXT"}, models.Column{Name: "owner_id", Type: "UUID", References: "users"})
+	items.Table.Timestamps, items.Table.SoftDelete, items.Table.Versioned = false, false, false
+	files, err := capture(t, func() error { return Setup(items) })
+	require.NoError(t, err)
+	parseGo(t, files)
+
+	// users is not served, so owner_id is a plain column
+	assert.NotContains(t, files["schema.graphql"], "scalar")
+	assert.Contains(t, files["schema.graphql"], "  ownerId: String!\n}")
+	assert.Contains(t, files["schema.graphql"], "  updateItems(id: ID!, input: ItemsInput!): Item!\n")
+	assert.Contains(t, files["loaders.go"], "import (\n\t\"context\"\n)")
+	assert.Contains(t, files["resolvers.go"], "OwnerID: parse(&err, in.OwnerID, uuid.Parse),")
+	assert.Contains(t, files["resolvers.go"], "return uuid.Nil, errInvalidID")
+	assert.NotContains(t, files["resolvers.go"], `"strconv"`)
+}
+
+func TestSetup_Frameworks(t *testing.T) {
+	for framework, lines := range map[string][]string{
+		"chi": {
+			"func RegisterHandler(router chi.Router, itemsSvc items.Service) {",
+			"\trouter.With(auth.AuthMiddleware()).Post(\"/graphql\", serve)\n",
+			"\t\trouter.Get(\"/graphql\", servePlayground)\n",
+		},
+		"stdlib": {
+			"func RegisterHandler(router *http.ServeMux, itemsSvc items.Service) {",
+			"\trouter.Handle(\"POST /graphql\", auth.AuthMiddleware()(http.HandlerFunc(serve)))\n",
+			"\t\trouter.HandleFunc(\"GET /graphql\", servePlayground)\n",
+		},
+	} {
+		group := apiInputs(t, "items", "item", "serial", models.Column{Name: "name", Type: "TEXT"})
+		group.Framework = framework
+		files, err := capture(t, func() error { return Setup(group) })
+		require.NoError(t, err)
+		parseGo(t, files)
+		for _, line := range lines {
+			assert.Contains(t, files["graphql.go"], line)
+		}
+		assert.NotContains(t, files["graphql.go"], "gin-gonic")
+		assert.NotContains(t, files["resolvers.go"], "gin-gonic")
+	}
+}
+
+func TestSetup_Errors(t *testing.T) {
+	mismatch := apiInputs(t, "categories", "category", "bigserial", models.Column{Name: "parent_id", Type: "INTEGER", Nullable: true, References: "category"})
+	_, err := graphQLData(mismatch)
+	assert.EqualError(t, err, "column parent_id of category is int32, the id of category is int64")
+
+	clash := apiInputs(t, "categories", "category", "serial",
+		models.Column{Name: "parent", Type: "TEXT"},
+		models.Column{Name: "parent_id", Type: "INTEGER", References: "category"},
+	)
+	_, err = graphQLData(clash)
+	assert.EqualError(t, err, "field parent of Category is both a column and a relationship, rename the column")
+
+	_, err = graphQLData()
+	assert.Error(t, err)
+
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateDirectory", "dir/api/graphql/").Return(errors.New("permission denied"))
+	assert.EqualError(t, Setup(apiInputs(t, "items", "item", "ulid", models.Column{Name: "name", Type: "TEXT"})), "permission denied")
+}
+
+func keys[T any](m map[string]T) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
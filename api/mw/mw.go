@@ -8,11 +8,25 @@ import (
 )
 
 var (
-	CorsPath = "%s/api/v1/mw/cors/"
-	AuthPath = "%s/api/v1/mw/auth/"
+	CorsPath   = "%s/api/v1/mw/cors/"
+	AuthPath   = "%s/api/v1/mw/auth/"
+	LoggerPath = "%s/api/v1/mw/logger/"
+	// the headers of the deprecated versions of a spec with groups
+	DeprecationPath = "%s/api/v1/mw/deprecation/"
 )
 
 func SetupMiddleWare(apiInputs models.APIInputs) (err error) {
+	if apiInputs.Routes != nil && apiInputs.Routes.Deprecated {
+		err = createDeprecationMiddleWare(apiInputs.WrkDir, apiInputs.Framework)
+		if err != nil {
+			return
+		}
+	}
+
+	if common.NetHTTP(apiInputs.Framework) {
+		return setupHTTPMiddleWare(apiInputs)
+	}
+
 	err = createCorsMiddleWare(apiInputs.WrkDir)
 	if err != nil {
 		return
@@ -41,10 +55,15 @@ func CORSMiddleware() gin.HandlerFunc {
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		AllowAllOrigins:  false,
-		AllowOriginFunc:  func(origin string) bool { return true },
+		AllowOriginFunc:  AllowOrigin,
 		MaxAge:           86400,
 	})
 }
+
+// AllowOrigin reports whether requests from the origin are served, the gRPC interceptors check it too
+func AllowOrigin(origin string) bool {
+	return true
+}
 `
 
 func createCorsMiddleWare(wrkDir string) error {
@@ -57,10 +76,10 @@ package auth
 
 import (
 	"errors"
-	"net/http"
 
 	"github.com/IBM/alchemy-logging/src/go/alog"
 	"github.com/gin-gonic/gin"
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
 	util "{{.GoModule}}/{{.WrkDir}}/utils"
 )
 
@@ -74,23 +93,27 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		token := ctx.GetHeader(AuthorizationKey)
-		// check authorization key
-		useAuth := util.GetAppConfig().AUTH
-		if len(token) < 30 {
-			if useAuth == "false" {
-				ch.Log(alog.ERROR, "Authorization False")
-				return
-			}
-			err := errors.New("no authorization key provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse(err))
+		if err := Authorize(token); err != nil {
+			apierrors.Write(ctx.Writer, ctx.Request, apierrors.Unauthorized(err))
+			ctx.Abort()
 			return
 		}
 		ctx.Next()
 	}
 }
 
-func ErrorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Authorize checks the authorization key of a request, the gRPC interceptors check it too
+func Authorize(token string) error {
+	// check authorization key
+	useAuth := util.GetAppConfig().AUTH
+	if len(token) < 30 {
+		if useAuth == "false" {
+			ch.Log(alog.ERROR, "Authorization False")
+			return nil
+		}
+		return errors.New("no authorization key provided")
+	}
+	return nil
 }
 `
 
@@ -98,3 +121,245 @@ func createAuthMiddleWare(apiInputs models.APIInputs) error {
 	fileName := fmt.Sprintf(AuthPath, apiInputs.WrkDir) + "auth.go"
 	return common.CreateFileAndItsContent(fileName, apiInputs, authMiddleWare)
 }
+
+// setupHTTPMiddleWare writes the middleware of the net/http routers, with the logger and the recovery Gin brings along
+func setupHTTPMiddleWare(apiInputs models.APIInputs) (err error) {
+	err = common.CreateFileAndItsContent(fmt.Sprintf(CorsPath, apiInputs.WrkDir)+"cors.go", nil, httpCorsMiddleWare)
+	if err != nil {
+		return
+	}
+
+	err = common.CreateFileAndItsContent(fmt.Sprintf(AuthPath, apiInputs.WrkDir)+"auth.go", apiInputs, httpAuthMiddleWare)
+	if err != nil {
+		return
+	}
+
+	err = common.CreateDirectory(fmt.Sprintf(LoggerPath, apiInputs.WrkDir))
+	if err != nil {
+		return
+	}
+
+	return common.CreateFileAndItsContent(fmt.Sprintf(LoggerPath, apiInputs.WrkDir)+"logger.go", apiInputs, loggerMiddleWare)
+}
+
+const httpCorsMiddleWare = `// Generated By API Service Generator
+package cors
+
+import (
+	"net/http"
+)
+
+const (
+	allowMethods  = "GET, POST, OPTIONS, PUT, DELETE"
+	allowHeaders  = "Origin, Content-Length, Content-Type, User-Agent, Referrer, Host, Token, Authorization"
+	exposeHeaders = "Content-Length"
+	maxAge        = "86400"
+)
+
+// CORSMiddleware sets the CORS headers for the allowed origins and answers their preflight requests
+func CORSMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !AllowOrigin(origin) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				header.Set("Access-Control-Allow-Methods", allowMethods)
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+				header.Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AllowOrigin reports whether requests from the origin are served, the gRPC interceptors check it too
+func AllowOrigin(origin string) bool {
+	return true
+}
+`
+
+const httpAuthMiddleWare = `// Generated By API Service Generator
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+	util "{{.GoModule}}/{{.WrkDir}}/utils"
+)
+
+const (
+	AuthorizationKey = "authorization"
+)
+
+var ch = alog.UseChannel("MAIN")
+
+func AuthMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(AuthorizationKey)
+			if err := Authorize(token); err != nil {
+				apierrors.Write(w, r, apierrors.Unauthorized(err))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize checks the authorization key of a request, the gRPC interceptors check it too
+func Authorize(token string) error {
+	// check authorization key
+	useAuth := util.GetAppConfig().AUTH
+	if len(token) < 30 {
+		if useAuth == "false" {
+			ch.Log(alog.ERROR, "Authorization False")
+			return nil
+		}
+		return errors.New("no authorization key provided")
+	}
+	return nil
+}
+`
+
+const loggerMiddleWare = `// Generated By API Service Generator
+package logger
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+)
+
+var ch = alog.UseChannel("HTTP")
+
+// Logger logs the status, the latency, the method and the path of the requests, except the ones to skipPaths
+func Logger(skipPaths ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			ch.Log(alog.INFO, "%3d | %13v | %15s | %-7s %q", recorder.status, time.Since(start), r.RemoteAddr, r.Method, r.URL.Path)
+		})
+	}
+}
+
+// Recovery answers the panic of a handler with the problem of a 500, apierrors logs it with the stack
+func Recovery() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					// the server aborts the response without logging it
+					panic(rec)
+				}
+				apierrors.Write(w, r, apierrors.Panic(rec))
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// statusRecorder keeps the status a handler answered with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap gives http.ResponseController the writer of the server
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+`
+
+// deprecationMiddleWare sets the headers main.go gives it on the responses of a deprecated version,
+// its data is the framework
+const deprecationMiddleWare = `// Generated By API Service Generator
+package deprecation
+
+import (
+	"net/http"
+{{- if not (eq . "chi" "stdlib")}}
+
+	"github.com/gin-gonic/gin"
+{{- end}}
+)
+{{if eq . "chi" "stdlib"}}
+// Deprecation marks the responses of a version with the Deprecation header of RFC 9745, the Sunset
+// header of RFC 8594 and a Link to the documentation of the change, the empty ones are left out
+func Deprecation(deprecation, sunset, link string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			setHeaders(w.Header(), deprecation, sunset, link)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+{{- else}}
+// Deprecation marks the responses of a version with the Deprecation header of RFC 9745, the Sunset
+// header of RFC 8594 and a Link to the documentation of the change, the empty ones are left out
+func Deprecation(deprecation, sunset, link string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setHeaders(c.Writer.Header(), deprecation, sunset, link)
+		c.Next()
+	}
+}
+{{- end}}
+
+func setHeaders(header http.Header, deprecation, sunset, link string) {
+	if deprecation != "" {
+		header.Set("Deprecation", deprecation)
+	}
+	if sunset != "" {
+		header.Set("Sunset", sunset)
+	}
+	if link != "" {
+		header.Add("Link", link)
+	}
+}
+`
+
+func createDeprecationMiddleWare(wrkDir, framework string) error {
+	err := common.CreateDirectory(fmt.Sprintf(DeprecationPath, wrkDir))
+	if err != nil {
+		return err
+	}
+	return common.CreateFileAndItsContent(fmt.Sprintf(DeprecationPath, wrkDir)+"deprecation.go", framework, deprecationMiddleWare)
+}
@@ -1,15 +1,20 @@
 package mw
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"testing"
+	"text/template"
 
 	"github.com/abhijithk1/api-service-generator/common"
 	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/abhijithk1/api-service-generator/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestMain (m *testing.M) {
@@ -111,3 +116,76 @@ func TestSetupMiddleware_CorsMiddleWareError(t *testing.T) {
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
+
+func TestSetupMiddleware_NetHTTP(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	apiInputs := models.APIInputs{
+		WrkDir:    "dir",
+		APIGroup:  "dummy",
+		GoModule:  "example",
+		Framework: "stdlib",
+	}
+
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/mw/cors/cors.go", nil, httpCorsMiddleWare).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/mw/auth/auth.go", apiInputs, httpAuthMiddleWare).Return(nil)
+	mockCmdsExecutor.On("CreateDirectory", "dir/api/v1/mw/logger/").Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/mw/logger/logger.go", apiInputs, loggerMiddleWare).Return(nil)
+
+	err := SetupMiddleWare(apiInputs)
+	assert.NoError(t, err)
+	mockCmdsExecutor.AssertExpectations(t)
+
+	// the gRPC interceptors call AllowOrigin and Authorize whatever the router
+	for name, content := range map[string]string{"cors.go": httpCorsMiddleWare, "auth.go": httpAuthMiddleWare, "logger.go": loggerMiddleWare} {
+		var rendered bytes.Buffer
+		assert.NoError(t, template.Must(template.New(name).Parse(content)).Execute(&rendered, apiInputs))
+		_, err := parser.ParseFile(token.NewFileSet(), name, rendered.String(), 0)
+		assert.NoError(t, err, name)
+		assert.NotContains(t, rendered.String(), "gin-gonic")
+		assert.NotContains(t, rendered.String(), "json.NewEncoder", name)
+	}
+	assert.Contains(t, httpAuthMiddleWare, "\t\t\t\tapierrors.Write(w, r, apierrors.Unauthorized(err))\n")
+	assert.Contains(t, loggerMiddleWare, "\t\t\t\tapierrors.Write(w, r, apierrors.Panic(rec))\n")
+	assert.Contains(t, httpCorsMiddleWare, "func AllowOrigin(origin string) bool {")
+	assert.Contains(t, httpAuthMiddleWare, "func Authorize(token string) error {")
+}
+
+func TestSetupMiddleware_Deprecation(t *testing.T) {
+	for _, framework := range []string{"gin", "chi", "stdlib"} {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		apiInputs := models.APIInputs{WrkDir: "dir", Framework: framework, Routes: &models.APIRoutes{Deprecated: true}}
+
+		mockCmdsExecutor.On("CreateDirectory", "dir/api/v1/mw/deprecation/").Return(nil)
+		mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/v1/mw/deprecation/deprecation.go", framework, deprecationMiddleWare).Return(nil)
+		mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+
+		assert.NoError(t, SetupMiddleWare(apiInputs))
+		mockCmdsExecutor.AssertCalled(t, "CreateFileAndItsContent", "dir/api/v1/mw/deprecation/deprecation.go", framework, deprecationMiddleWare)
+
+		var rendered bytes.Buffer
+		assert.NoError(t, template.Must(template.New("deprecation.go").Parse(deprecationMiddleWare)).Execute(&rendered, framework))
+		_, err := parser.ParseFile(token.NewFileSet(), "deprecation.go", rendered.String(), parser.ParseComments)
+		assert.NoError(t, err, rendered.String())
+		if framework == "gin" {
+			assert.Contains(t, rendered.String(), "func Deprecation(deprecation, sunset, link string) gin.HandlerFunc {")
+		} else {
+			assert.Contains(t, rendered.String(), "func Deprecation(deprecation, sunset, link string) func(http.Handler) http.Handler {")
+			assert.NotContains(t, rendered.String(), "gin")
+		}
+	}
+}
+
+func TestAuthMiddleWare_Problem(t *testing.T) {
+	var rendered bytes.Buffer
+	apiInputs := models.APIInputs{WrkDir: "dir", GoModule: "example"}
+	assert.NoError(t, template.Must(template.New("auth.go").Parse(authMiddleWare)).Execute(&rendered, apiInputs))
+	_, err := parser.ParseFile(token.NewFileSet(), "auth.go", rendered.String(), 0)
+	assert.NoError(t, err, rendered.String())
+	assert.Contains(t, rendered.String(), "\tapierrors \"example/dir/api/errors\"\n")
+	assert.Contains(t, rendered.String(), "\t\t\tapierrors.Write(ctx.Writer, ctx.Request, apierrors.Unauthorized(err))\n\t\t\tctx.Abort()\n")
+	assert.NotContains(t, rendered.String(), "ErrorResponse")
+}
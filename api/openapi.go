@@ -0,0 +1,473 @@
+package api
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	OpenAPIPath = "%s/api/openapi.yaml"
+	DocsPath    = "%s/api/"
+	WriteFile   = os.WriteFile
+)
+
+// swaggerUI is the dist of Swagger UI 5.17.14 with its license, the assets of the /docs page.
+// It is vendored, so the page is built into the service without downloading anything.
+//
+//go:embed swaggerui
+var swaggerUI embed.FS
+
+// openAPIData is the document of the groups, each group is mounted under /v1 like in main.go,
+// createOpenAPIFile replaces the routes with the ones of the versions of a spec with groups
+func openAPIData(wrkDir string, groups ...models.APIInputs) models.OpenAPI {
+	data := models.OpenAPI{Title: filepath.Base(wrkDir)}
+	tables := map[string]bool{}
+	for _, group := range groups {
+		group.APIGroupTitle = common.ToCamelCase(group.APIGroup)
+		group.TableNameTitle = common.ToCamelCase(group.TableName)
+		data.Groups = append(data.Groups, group)
+		data.Routes = append(data.Routes, models.APIRoute{APIInputs: group})
+		if !tables[group.TableName] {
+			tables[group.TableName] = true
+			data.Tables = append(data.Tables, group)
+		}
+	}
+	return data
+}
+
+const openAPIContent = `# Generated By API Service Generator
+# The routes of main.go and the controllers of api/v1, regenerate it with them.
+openapi: 3.0.3
+info:
+  title: {{.Title}}
+  version: 0.1.0
+servers:
+  - url: /
+    description: The service that serves this document at /openapi.yaml
+tags:
+{{- range .Groups}}
+  - name: {{.APIGroup}}
+    description: Rows of the {{.TableName}} table
+{{- end}}
+security:
+  - Authorization: []
+paths:
+  /health:
+    get:
+      operationId: Health
+      summary: Reports that the service is up
+      security: []
+      responses:
+        "200":
+          description: The service is up
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Health"
+{{- range .Routes}}
+  /{{or .Version "v1"}}/{{.APIGroup}}:
+    get:
+      tags: [{{.APIGroup}}]
+      operationId: Get{{.APIGroupTitle}}{{template "version" .}}
+      summary: Lists the {{.TableName}} rows
+      responses:
+        "200":
+          description: The {{.TableName}} rows
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/{{.TableNameTitle}}"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "500":
+          $ref: "#/components/responses/InternalError"
+    post:
+      tags: [{{.APIGroup}}]
+      operationId: Create{{.APIGroupTitle}}{{template "version" .}}
+      summary: Creates a {{.TableName}} row
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/{{.APIGroupTitle}}Request"
+      responses:
+        "201":
+          description: The created {{.TableName}} row
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.TableNameTitle}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "422":
+          $ref: "#/components/responses/UnprocessableEntity"
+        "500":
+          $ref: "#/components/responses/InternalError"
+  /{{or .Version "v1"}}/{{.APIGroup}}/{id}:
+    parameters:
+      - $ref: "#/components/parameters/{{.APIGroupTitle}}ID"
+    get:
+      tags: [{{.APIGroup}}]
+      operationId: Get{{.APIGroupTitle}}ByID{{template "version" .}}
+      summary: Reads a {{.TableName}} row
+      responses:
+        "200":
+          description: The {{.TableName}} row
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.TableNameTitle}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "500":
+          $ref: "#/components/responses/InternalError"
+    put:
+      tags: [{{.APIGroup}}]
+      operationId: Update{{.APIGroupTitle}}{{template "version" .}}
+      summary: Updates a {{.TableName}} row
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/{{.APIGroupTitle}}{{if .Table.Versioned}}UpdateRequest{{else}}Request{{end}}"
+      responses:
+        "200":
+          description: The updated {{.TableName}} row
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.TableNameTitle}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "422":
+          $ref: "#/components/responses/UnprocessableEntity"
+        "500":
+          $ref: "#/components/responses/InternalError"
+    delete:
+      tags: [{{.APIGroup}}]
+      operationId: Delete{{.APIGroupTitle}}{{template "version" .}}
+      summary: Deletes a {{.TableName}} row
+      responses:
+        "204":
+          description: The {{.TableName}} row is deleted
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "401":
+          $ref: "#/components/responses/Unauthorized"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "500":
+          $ref: "#/components/responses/InternalError"
+{{- end}}
+components:
+  securitySchemes:
+    Authorization:
+      type: apiKey
+      in: header
+      name: Authorization
+      description: Token of at least 30 characters, checked by the auth middleware unless AUTH is false in app.env
+  parameters:
+{{- range .Groups}}
+    {{.APIGroupTitle}}ID:
+      name: id
+      in: path
+      required: true
+      description: Primary key of the {{.TableName}} row
+      schema: {{template "keySchema" .}}
+{{- end}}
+  responses:
+    BadRequest:
+      description: The id or the body is invalid, INVALID_ID, INVALID_BODY or VALIDATION_FAILED
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+    Unauthorized:
+      description: No authorization key provided, UNAUTHORIZED
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+    NotFound:
+      description: No row with the id, NOT_FOUND
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+    Conflict:
+      description: The row already exists, it was changed by another request, or a referenced row is missing, DUPLICATE, CONFLICT or REFERENCE_VIOLATION
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+    UnprocessableEntity:
+      description: The row breaks a constraint of the database, CONSTRAINT_VIOLATION
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+    InternalError:
+      description: The database failed, INTERNAL, the cause is logged and not answered
+      content:
+        application/problem+json:
+          schema:
+            $ref: "#/components/schemas/Problem"
+  schemas:
+    Health:
+      type: object
+      required: [health]
+      properties:
+        health: {type: string, example: ok}
+    Problem:
+      description: The RFC 7807 body of the errors of the handlers, the middleware and of the URLs without a route
+      type: object
+      required: [type, title, status, code]
+      properties:
+        type: {type: string, example: about:blank}
+        title: {type: string, example: Not Found}
+        status: {type: integer, example: 404}
+        detail: {type: string, example: books not found}
+        instance: {type: string, example: /v1/books/42}
+        code:
+          type: string
+          description: Stable code of the error, clients match on it instead of the detail
+          enum: [INVALID_ID, INVALID_BODY, VALIDATION_FAILED, UNAUTHORIZED, NOT_FOUND, ROUTE_NOT_FOUND, CONFLICT, DUPLICATE, REFERENCE_VIOLATION, CONSTRAINT_VIOLATION, INTERNAL]
+        errors:
+          description: The failed checks of a VALIDATION_FAILED
+          type: array
+          items:
+            type: object
+            required: [field, rule]
+            properties:
+              field: {type: string, example: Title}
+              rule: {type: string, example: required}
+{{- range .Tables}}
+    {{.TableNameTitle}}:
+      description: Row of the {{.TableName}} table, its fields are named like the Go fields of the sqlc model
+      type: object
+      required: [ID{{range .Table.Columns}}, {{.FieldName}}{{end}}{{if .Table.Timestamps}}, CreatedAt, UpdatedAt{{end}}{{if .Table.SoftDelete}}, DeletedAt{{end}}{{if .Table.Versioned}}, Version{{end}}]
+      properties:
+        ID: {{template "keySchema" .}}
+{{- range .Table.Columns}}
+        {{.FieldName}}: {{.Schema}}
+{{- end}}
+{{- if .Table.Timestamps}}
+        CreatedAt: {type: string, format: date-time}
+        UpdatedAt: {type: string, format: date-time}
+{{- end}}
+{{- if .Table.SoftDelete}}
+        DeletedAt: {type: string, format: date-time, nullable: true}
+{{- end}}
+{{- if .Table.Versioned}}
+        Version: {type: integer, format: int32, example: 1}
+{{- end}}
+{{- end}}
+{{- range .Groups}}
+    {{.APIGroupTitle}}Request:
+      description: Body of the create{{if not .Table.Versioned}} and update{{end}} requests of {{.APIGroup}}
+      type: object
+{{- if .Table.RequiredList}}
+      required: [{{.Table.RequiredList}}]
+{{- end}}
+      properties:
+{{- range .Table.Columns}}
+        {{.Name}}: {{.Schema}}
+{{- end}}
+{{- if .Table.Versioned}}
+    {{.APIGroupTitle}}UpdateRequest:
+      description: Body of the update requests of {{.APIGroup}}, with the version the client last read
+      allOf:
+        - $ref: "#/components/schemas/{{.APIGroupTitle}}Request"
+        - type: object
+          required: [version]
+          properties:
+            version: {type: integer, format: int32, example: 1}
+{{- end}}
+{{- end}}
+
+{{define "version"}}
+{{- if and .Version (ne .Version "v1")}}_{{.Version}}{{end}}
+{{- if .Deprecated}}
+      deprecated: true
+{{- end}}
+{{- end}}
+{{- define "keySchema"}}
+{{- if eq .Table.PrimaryKey "serial"}}{type: integer, format: int32, example: 1}
+{{- else if eq .Table.PrimaryKey "bigserial"}}{type: integer, format: int64, example: 1}
+{{- else if eq .Table.PrimaryKey "uuid"}}{type: string, format: uuid, example: "00000000-0000-4000-8000-000000000000"}
+{{- else if eq .Table.PrimaryKey "ulid"}}{type: string, minLength: 26, maxLength: 26, example: "01HZX3Q5W8K2M4N6P8R0T2V4X6"}
+{{- end}}
+{{- end}}
+`
+
+func createOpenAPIFile(apiInputs models.APIInputs) error {
+	fileName := fmt.Sprintf(OpenAPIPath, apiInputs.WrkDir)
+	data := openAPIData(apiInputs.WrkDir, apiInputs)
+	if apiInputs.Routes != nil {
+		data = openAPIData(apiInputs.WrkDir, apiInputs.Routes.Groups...)
+		data.Routes = nil
+		for _, version := range apiInputs.Routes.Versions {
+			data.Routes = append(data.Routes, version.Groups...)
+		}
+	}
+	return common.CreateFileAndItsContent(fileName, data, openAPIContent)
+}
+
+const docsContent = `// Generated By API Service Generator
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// files are the OpenAPI document and the Swagger UI page with its assets in docs/ui,
+// built into the binary.
+//
+//go:embed openapi.yaml docs
+var files embed.FS
+
+// RegisterDocs serves the OpenAPI document at /openapi.yaml and Swagger UI at /docs
+func RegisterDocs(router *gin.Engine) {
+	spec, _ := files.ReadFile("openapi.yaml")
+	page, _ := files.ReadFile("docs/index.html")
+	ui, _ := fs.Sub(files, "docs/ui")
+
+	router.GET("/openapi.yaml", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "application/yaml", spec)
+	})
+	router.GET("/docs", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+	router.StaticFS("/docs/ui", http.FS(ui))
+}
+`
+
+// httpDocsContent serves the documents on the net/http routers, the framework is the data of the template
+const httpDocsContent = `// Generated By API Service Generator
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+{{- if eq . "chi"}}
+
+	"github.com/go-chi/chi/v5"
+{{- end}}
+)
+
+// files are the OpenAPI document and the Swagger UI page with its assets in docs/ui,
+// built into the binary.
+//
+//go:embed openapi.yaml docs
+var files embed.FS
+
+// RegisterDocs serves the OpenAPI document at /openapi.yaml and Swagger UI at /docs
+func RegisterDocs(router {{if eq . "chi"}}chi.Router{{else}}*http.ServeMux{{end}}) {
+	spec, _ := files.ReadFile("openapi.yaml")
+	page, _ := files.ReadFile("docs/index.html")
+	ui, _ := fs.Sub(files, "docs/ui")
+{{- if eq . "chi"}}
+
+	router.Get("/openapi.yaml", serve("application/yaml", spec))
+	router.Get("/docs", serve("text/html; charset=utf-8", page))
+	router.Handle("/docs/ui/*", http.StripPrefix("/docs/ui", http.FileServer(http.FS(ui))))
+{{- else}}
+
+	router.HandleFunc("GET /openapi.yaml", serve("application/yaml", spec))
+	router.HandleFunc("GET /docs", serve("text/html; charset=utf-8", page))
+	router.Handle("GET /docs/ui/", http.StripPrefix("/docs/ui", http.FileServer(http.FS(ui))))
+{{- end}}
+}
+
+func serve(contentType string, body []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}
+}
+`
+
+const docsIndex = `<!DOCTYPE html>
+<!-- Generated By API Service Generator -->
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.}} API</title>
+  <link rel="stylesheet" href="/docs/ui/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/ui/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: "/openapi.yaml", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// createDocsFiles writes the handlers that serve api/openapi.yaml and the Swagger UI page with its assets
+func createDocsFiles(wrkDir, framework string) error {
+	filePath := fmt.Sprintf(DocsPath, wrkDir)
+	var err error
+	if common.NetHTTP(framework) {
+		err = common.CreateFileAndItsContent(filePath+"docs.go", framework, httpDocsContent)
+	} else {
+		err = common.CreateFileAndItsContent(filePath+"docs.go", nil, docsContent)
+	}
+	if err != nil {
+		return err
+	}
+	err = common.CreateFileAndItsContent(filePath+"docs/index.html", filepath.Base(wrkDir), docsIndex)
+	if err != nil {
+		return err
+	}
+	return createSwaggerUIFiles(filePath + "docs/ui/")
+}
+
+// createSwaggerUIFiles copies the vendored Swagger UI to dir as it is, the bundle is not a template
+func createSwaggerUIFiles(dir string) error {
+	files, err := swaggerUI.ReadDir("swaggerui")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		content, err := swaggerUI.ReadFile("swaggerui/" + file.Name())
+		if err != nil {
+			return err
+		}
+		err = WriteFile(dir+file.Name(), content, 0644)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %w", dir+file.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var route = regexp.MustCompile(`r\.(GET|POST|PUT|DELETE)\("([^"]+)"`)
+
+func render(t *testing.T, content string, data interface{}) string {
+	var rendered bytes.Buffer
+	require.NoError(t, template.Must(template.New("file").Parse(content)).Execute(&rendered, data))
+	return rendered.String()
+}
+
+func orderInputs(t *testing.T, group string, table models.TableSpec) models.APIInputs {
+	require.NoError(t, spec.Resolve(&table, "postgres"))
+	return models.APIInputs{
+		WrkDir:         "services/orders",
+		GoModule:       "example/api-service",
+		APIGroup:       group,
+		APIGroupTitle:  common.ToCamelCase(group),
+		TableName:      "orders",
+		TableNameTitle: "Orders",
+		DBMS:           "postgres",
+		Table:          table,
+	}
+}
+
+// document parses the rendered api/openapi.yaml and checks that its references resolve
+func document(t *testing.T, groups ...models.APIInputs) map[string]interface{} {
+	content := render(t, openAPIContent, openAPIData(groups[0].WrkDir, groups...))
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), &doc), content)
+
+	for _, ref := range regexp.MustCompile(`\$ref: "#/([^"]+)"`).FindAllStringSubmatch(content, -1) {
+		var node interface{} = doc
+		for _, key := range strings.Split(ref[1], "/") {
+			node = node.(map[string]interface{})[key]
+		}
+		assert.NotNil(t, node, "%s does not resolve", ref[1])
+	}
+	return doc
+}
+
+func lookup(node interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		node = node.(map[string]interface{})[key]
+	}
+	return node
+}
+
+func TestCreateOpenAPIFile(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	apiInputs := models.APIInputs{WrkDir: "dir", APIGroup: "dummy", TableName: "table_name"}
+
+	data := openAPIData(apiInputs.WrkDir, apiInputs)
+	assert.Equal(t, "Dummy", data.Groups[0].APIGroupTitle)
+	assert.Equal(t, "TableName", data.Tables[0].TableNameTitle)
+
+	mockCmdsExecutor.On("CreateFileAndItsContent", "dir/api/openapi.yaml", data, openAPIContent).Return(nil)
+	assert.NoError(t, createOpenAPIFile(apiInputs))
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	for _, primaryKey := range []string{"serial", "bigserial", "uuid", "ulid"} {
+		t.Run(primaryKey, func(t *testing.T) {
+			apiInputs := orderInputs(t, "orders", models.TableSpec{PrimaryKey: primaryKey, Columns: spec.DefaultSpec.Columns})
+			doc := document(t, apiInputs)
+			paths := doc["paths"].(map[string]interface{})
+
+			// the routes the controller registers under /v1 are the documented operations
+			routes := route.FindAllStringSubmatch(render(t, controllerContent, apiInputs), -1)
+			registered := []string{"GET /health"}
+			for _, r := range routes {
+				registered = append(registered, r[1]+" /v1"+strings.ReplaceAll(r[2], ":id", "{id}"))
+			}
+			documented := []string{}
+			for path, operations := range paths {
+				for method := range operations.(map[string]interface{}) {
+					if method != "parameters" {
+						documented = append(documented, strings.ToUpper(method)+" "+path)
+					}
+				}
+			}
+			assert.Len(t, routes, 5)
+			assert.ElementsMatch(t, registered, documented)
+
+			health := lookup(paths, "/health", "get").(map[string]interface{})
+			assert.Equal(t, []interface{}{}, health["security"])
+		})
+	}
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	table := models.TableSpec{
+		PrimaryKey: "uuid",
+		Timestamps: true,
+		SoftDelete: true,
+		Versioned:  true,
+		Columns: []models.Column{
+			{Name: "customer_id", Type: "BIGINT"},
+			{Name: "status", Type: "ENUM", Values: []string{"open", "true"}},
+			{Name: "note", Type: "TEXT", Nullable: true},
+			{Name: "tags", Type: "TEXT[]"},
+			{Name: "total", Type: "NUMERIC(10,2)"},
+			{Name: "details", Type: "JSONB"},
+		},
+	}
+	orders := orderInputs(t, "orders", table)
+	archive := orderInputs(t, "archive", table)
+	doc := document(t, orders, archive)
+
+	schemas := lookup(doc, "components", "schemas").(map[string]interface{})
+	assert.Contains(t, schemas, "OrdersRequest")
+	assert.Contains(t, schemas, "ArchiveUpdateRequest")
+
+	// the row is the sqlc model, marshalled without json tags
+	row := schemas["Orders"].(map[string]interface{})
+	fields := []interface{}{"ID", "CustomerID", "Status", "Note", "Tags", "Total", "Details", "CreatedAt", "UpdatedAt", "DeletedAt", "Version"}
+	assert.Equal(t, fields, row["required"])
+	assert.Len(t, row["properties"], len(fields))
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "uuid", "example": "00000000-0000-4000-8000-000000000000"}, lookup(row, "properties", "ID"))
+
+	request := schemas["OrdersRequest"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"status", "total"}, request["required"])
+	assert.Equal(t, []interface{}{"open", "true"}, lookup(request, "properties", "status", "enum"))
+	assert.Equal(t, true, lookup(request, "properties", "note", "nullable"))
+	assert.Equal(t, map[string]interface{}{"type": "string"}, lookup(request, "properties", "tags", "items"))
+	assert.Equal(t, map[string]interface{}{}, lookup(request, "properties", "details", "example"))
+
+	put := lookup(doc, "paths", "/v1/archive/{id}", "put", "requestBody", "content", "application/json", "schema", "$ref")
+	assert.Equal(t, "#/components/schemas/ArchiveUpdateRequest", put)
+	assert.Equal(t, map[string]interface{}{"type": "apiKey", "in": "header", "name": "Authorization"},
+		without(lookup(doc, "components", "securitySchemes", "Authorization"), "description"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"Authorization": []interface{}{}}}, doc["security"])
+	assert.Equal(t, "orders", lookup(doc, "info", "title"))
+}
+
+func without(node interface{}, key string) map[string]interface{} {
+	copied := map[string]interface{}{}
+	for k, v := range node.(map[string]interface{}) {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+func TestCreateDocsFiles(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/orders/api/docs.go", nil, docsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/orders/api/docs/index.html", "orders", docsIndex).Return(nil)
+
+	assert.NoError(t, createDocsFiles("services/orders", "gin"))
+	mockCmdsExecutor.AssertExpectations(t)
+
+	// the document and the page are embedded next to docs.go, the assets of the page are served from docs/ui
+	_, err := parser.ParseFile(token.NewFileSet(), "docs.go", docsContent, parser.ParseComments)
+	require.NoError(t, err)
+	assert.Contains(t, docsContent, "\n//go:embed openapi.yaml docs\nvar files embed.FS\n")
+	page := render(t, docsIndex, "orders")
+	assert.Contains(t, page, `<title>orders API</title>`)
+	assert.Contains(t, page, `<script src="/docs/ui/swagger-ui-bundle.js"></script>`)
+	assert.Contains(t, page, `<link rel="stylesheet" href="/docs/ui/swagger-ui.css">`)
+}
+
+func TestCreateSwaggerUIFiles(t *testing.T) {
+	defer func(writeFile func(string, []byte, os.FileMode) error) { WriteFile = writeFile }(WriteFile)
+	written := map[string][]byte{}
+	WriteFile = func(name string, content []byte, _ os.FileMode) error {
+		written[name] = content
+		return nil
+	}
+
+	// the vendored dist is copied byte for byte, the bundle is not run through text/template
+	require.NoError(t, createSwaggerUIFiles("dir/api/docs/ui/"))
+	assert.Len(t, written, 4)
+	assert.Contains(t, string(written["dir/api/docs/ui/swagger-ui-bundle.js"]), `"5.17.14"`)
+	assert.Contains(t, string(written["dir/api/docs/ui/swagger-ui.css"]), ".swagger-ui")
+	assert.Contains(t, string(written["dir/api/docs/ui/LICENSE"]), "Apache License")
+	assert.Contains(t, string(written["dir/api/docs/ui/NOTICE"]), "SmartBear")
+
+	WriteFile = func(string, []byte, os.FileMode) error { return errors.New("disk full") }
+	assert.ErrorContains(t, createSwaggerUIFiles("dir/api/docs/ui/"), "error writing dir/api/docs/ui/")
+}
+
+func TestOpenAPI_Versions(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	var data models.OpenAPI
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/openapi.yaml", mock.Anything, openAPIContent).Run(func(args mock.Arguments) {
+		data = args.Get(1).(models.OpenAPI)
+	}).Return(nil)
+	require.NoError(t, createOpenAPIFile(library(t, "gin")))
+
+	content := render(t, openAPIContent, data)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), &doc), content)
+	paths := doc["paths"].(map[string]interface{})
+	assert.ElementsMatch(t, []string{"/health", "/v1/authors", "/v1/authors/{id}", "/v1/books", "/v1/books/{id}", "/v2/books", "/v2/books/{id}", "/v3/books", "/v3/books/{id}"}, keys(paths))
+
+	// v1 has a sunset date, the operation ids of the later versions end with the version
+	assert.Equal(t, true, lookup(paths, "/v1/authors", "get", "deprecated"))
+	assert.NotContains(t, lookup(paths, "/v2/books", "get"), "deprecated")
+	assert.Equal(t, "GetBooksByID_v2", lookup(paths, "/v2/books/{id}", "get", "operationId"))
+	assert.Equal(t, "DeleteBooks", lookup(paths, "/v1/books/{id}", "delete", "operationId"))
+	assert.Len(t, lookup(doc, "tags"), 2)
+}
+
+func keys(m map[string]interface{}) []string {
+	names := []string{}
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var VersionFilePath = "%s/api/%s/%s/"
+
+// Routes are the groups and versions of a spec with groups. Each group is apiInputs bound to its table,
+// a group served under a version after v1 embeds the handlers of the version it was served under before.
+func Routes(apiInputs models.APIInputs, apiSpec models.APISpec) *models.APIRoutes {
+	tables := map[string]models.TableSpec{}
+	for _, table := range apiSpec.Tables {
+		tables[table.Name] = table.TableSpec
+	}
+
+	routes := &models.APIRoutes{}
+	groups := map[string]models.APIInputs{}
+	for _, spec := range apiSpec.Groups {
+		group := apiInputs
+		group.Routes = nil
+		group.APIGroup = spec.Name
+		group.APIGroupTitle = common.ToCamelCase(spec.Name)
+		group.TableName = spec.Table
+		group.TableNameTitle = common.ToCamelCase(spec.Table)
+		group.Table = tables[spec.Table]
+		groups[spec.Name] = group
+		routes.Groups = append(routes.Groups, group)
+	}
+
+	for _, version := range apiSpec.Versions {
+		deprecated := version.DeprecationHeader != "" || version.SunsetHeader != ""
+		routes.Deprecated = routes.Deprecated || deprecated
+		for _, spec := range apiSpec.Groups {
+			previous := ""
+			for _, served := range spec.Versions {
+				if served == version.Name {
+					break
+				}
+				previous = served
+			}
+			if !contains(spec.Versions, version.Name) {
+				continue
+			}
+			if previous == "" && version.Name != "v1" {
+				previous = "v1"
+			}
+
+			route := models.APIRoute{APIInputs: groups[spec.Name], Previous: previous, Deprecated: deprecated}
+			route.Version = version.Name
+			for _, handler := range spec.Overrides[version.Name] {
+				route.Overrides = append(route.Overrides, handlerName(handler, route.APIGroupTitle))
+			}
+			version.Groups = append(version.Groups, route)
+		}
+		routes.Versions = append(routes.Versions, version)
+	}
+	return routes
+}
+
+// handlerName is the method of the Resource that serves a handler of the spec
+func handlerName(handler, title string) string {
+	switch handler {
+	case "list":
+		return "Get" + title
+	case "get":
+		return "Get" + title + "ByID"
+	case "create":
+		return "Create" + title
+	case "update":
+		return "Update" + title
+	default:
+		return "Delete" + title
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// versionControllerContent is the controller of a group in a version after v1, its Resource embeds
+// the one of the version before and registers the handlers of both under the route tree of the version
+const versionControllerContent = `// Generated By API Service Generator
+package {{.APIGroup}}
+
+import (
+{{- if or (eq .Framework "stdlib") (and (eq .Framework "chi") .Overrides)}}
+	"net/http"
+{{end}}
+	v1 "{{.GoModule}}/{{.WrkDir}}/api/v1/{{.APIGroup}}"
+{{- if ne .Previous "v1"}}
+	{{.Previous}} "{{.GoModule}}/{{.WrkDir}}/api/{{.Previous}}/{{.APIGroup}}"
+{{- end}}
+{{- if eq .Framework "chi"}}
+
+	"github.com/go-chi/chi/v5"
+{{- else if ne .Framework "stdlib"}}
+
+	"github.com/gin-gonic/gin"
+{{- end}}
+)
+
+// {{.APIGroupTitle}}Resource serves /{{.Version}}/{{.APIGroup}}, the handlers it does not override are the ones of {{.Previous}}
+type {{.APIGroupTitle}}Resource struct {
+	{{.Previous}}.{{.APIGroupTitle}}Resource
+	service v1.Service
+}
+{{if eq .Framework "chi"}}
+func RegisterHandler(r chi.Router, service v1.Service) {
+	resource := New{{.APIGroupTitle}}Resource(service)
+
+	r.Get("/{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	r.Get("/{{.APIGroup}}/{id}", resource.Get{{.APIGroupTitle}}ByID)
+	r.Post("/{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	r.Put("/{{.APIGroup}}/{id}", resource.Update{{.APIGroupTitle}})
+	r.Delete("/{{.APIGroup}}/{id}", resource.Delete{{.APIGroupTitle}})
+}
+{{- else if eq .Framework "stdlib"}}
+func RegisterHandler(mux *http.ServeMux, service v1.Service) {
+	resource := New{{.APIGroupTitle}}Resource(service)
+
+	mux.HandleFunc("GET /{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	mux.HandleFunc("GET /{{.APIGroup}}/{id}", resource.Get{{.APIGroupTitle}}ByID)
+	mux.HandleFunc("POST /{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	mux.HandleFunc("PUT /{{.APIGroup}}/{id}", resource.Update{{.APIGroupTitle}})
+	mux.HandleFunc("DELETE /{{.APIGroup}}/{id}", resource.Delete{{.APIGroupTitle}})
+}
+{{- else}}
+func RegisterHandler(r *gin.RouterGroup, service v1.Service) {
+	resource := New{{.APIGroupTitle}}Resource(service)
+
+	r.GET("/{{.APIGroup}}", resource.Get{{.APIGroupTitle}})
+	r.GET("/{{.APIGroup}}/:id", resource.Get{{.APIGroupTitle}}ByID)
+	r.POST("/{{.APIGroup}}", resource.Create{{.APIGroupTitle}})
+	r.PUT("/{{.APIGroup}}/:id", resource.Update{{.APIGroupTitle}})
+	r.DELETE("/{{.APIGroup}}/:id", resource.Delete{{.APIGroupTitle}})
+}
+{{- end}}
+
+func New{{.APIGroupTitle}}Resource(service v1.Service) {{.APIGroupTitle}}Resource {
+	return {{.APIGroupTitle}}Resource{ {{- .Previous}}.New{{.APIGroupTitle}}Resource(service), service}
+}
+{{- range .Overrides}}
+
+// {{.}} overrides the handler of {{$.Previous}}, it answers like it until it is changed
+{{- if eq $.Framework "chi" "stdlib"}}
+func (h *{{$.APIGroupTitle}}Resource) {{.}}(w http.ResponseWriter, r *http.Request) {
+	h.{{$.APIGroupTitle}}Resource.{{.}}(w, r)
+}
+{{- else}}
+func (r *{{$.APIGroupTitle}}Resource) {{.}}(c *gin.Context) {
+	r.{{$.APIGroupTitle}}Resource.{{.}}(c)
+}
+{{- end}}
+{{- end}}
+`
+
+// createVersionFiles writes the packages of the groups served under the versions after v1
+func createVersionFiles(versions []models.APIVersion) error {
+	for _, version := range versions {
+		if version.Name == "v1" {
+			continue
+		}
+		for _, route := range version.Groups {
+			filePath := fmt.Sprintf(VersionFilePath, route.WrkDir, route.Version, route.APIGroup)
+			err := common.CreateDirectory(filePath)
+			if err != nil {
+				return err
+			}
+			err = common.CreateFileAndItsContent(filePath+"controller.go", route, versionControllerContent)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// library is a spec with authors under v1, and books under v1, v2 and v3 where v2 overrides get and update
+func library(t *testing.T, framework string) models.APIInputs {
+	apiSpec := models.APISpec{
+		Tables: []models.Table{
+			{Name: "authors", TableSpec: models.TableSpec{Columns: []models.Column{{Name: "name", Type: "TEXT"}}}},
+			{Name: "books", TableSpec: models.TableSpec{PrimaryKey: "uuid", Columns: []models.Column{{Name: "author_id", Type: "INTEGER", References: "authors"}}}},
+		},
+		Groups: []models.APIGroupSpec{
+			{Name: "authors", Table: "authors"},
+			{Name: "books", Table: "books", Versions: []string{"v1", "v2", "v3"}, Overrides: map[string][]string{"v2": {"get", "update"}}},
+		},
+		Versions: []models.APIVersion{{Name: "v1", Sunset: "2027-01-01"}},
+	}
+	require.NoError(t, spec.ResolveAPI(&apiSpec, "postgres"))
+	routes := Routes(models.APIInputs{WrkDir: "services/library", GoModule: "example/api-service", DBMS: "postgres", Framework: framework}, apiSpec)
+	apiInputs := routes.Groups[0]
+	apiInputs.Routes = routes
+	return apiInputs
+}
+
+func TestRoutes(t *testing.T) {
+	routes := library(t, "gin").Routes
+
+	require.Len(t, routes.Groups, 2)
+	assert.Equal(t, "Books", routes.Groups[1].APIGroupTitle)
+	assert.Equal(t, "uuid", routes.Groups[1].Table.PrimaryKey)
+	assert.Nil(t, routes.Groups[1].Routes)
+	assert.True(t, routes.Deprecated)
+
+	require.Len(t, routes.Versions, 3)
+	v1, v2, v3 := routes.Versions[0], routes.Versions[1], routes.Versions[2]
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", v1.SunsetHeader)
+	require.Len(t, v1.Groups, 2)
+	assert.Equal(t, models.APIRoute{APIInputs: v1.Groups[1].APIInputs, Deprecated: true}, v1.Groups[1])
+	assert.Equal(t, "v1", v1.Groups[1].Version)
+
+	require.Len(t, v2.Groups, 1)
+	assert.Equal(t, "v1", v2.Groups[0].Previous)
+	assert.Equal(t, []string{"GetBooksByID", "UpdateBooks"}, v2.Groups[0].Overrides)
+	assert.False(t, v2.Groups[0].Deprecated)
+	assert.Equal(t, "v2", v3.Groups[0].Previous)
+	assert.Empty(t, v3.Groups[0].Overrides)
+}
+
+func TestVersionControllerContent(t *testing.T) {
+	for _, framework := range []string{"gin", "chi", "stdlib"} {
+		routes := library(t, framework).Routes
+		v2 := render(t, versionControllerContent, routes.Versions[1].Groups[0])
+		_, err := parser.ParseFile(token.NewFileSet(), "controller.go", v2, parser.ParseComments)
+		require.NoError(t, err, v2)
+		assert.Contains(t, v2, "\tv1 \"example/api-service/services/library/api/v1/books\"\n")
+		assert.Contains(t, v2, "type BooksResource struct {\n\tv1.BooksResource\n\tservice v1.Service\n}\n")
+		assert.Contains(t, v2, "\treturn BooksResource{v1.NewBooksResource(service), service}\n")
+
+		v3 := render(t, versionControllerContent, routes.Versions[2].Groups[0])
+		_, err = parser.ParseFile(token.NewFileSet(), "controller.go", v3, parser.ParseComments)
+		require.NoError(t, err, v3)
+		assert.Contains(t, v3, "\tv1 \"example/api-service/services/library/api/v1/books\"\n\tv2 \"example/api-service/services/library/api/v2/books\"\n")
+		assert.Contains(t, v3, "type BooksResource struct {\n\tv2.BooksResource\n")
+		assert.NotContains(t, v3, "overrides")
+
+		switch framework {
+		case "gin":
+			assert.Contains(t, v2, "func (r *BooksResource) UpdateBooks(c *gin.Context) {\n\tr.BooksResource.UpdateBooks(c)\n}")
+			assert.Contains(t, v2, "\tr.GET(\"/books/:id\", resource.GetBooksByID)\n")
+			assert.NotContains(t, v2, "net/http")
+		case "chi":
+			assert.Contains(t, v2, "func (h *BooksResource) GetBooksByID(w http.ResponseWriter, r *http.Request) {\n\th.BooksResource.GetBooksByID(w, r)\n}")
+			assert.Contains(t, v2, "import (\n\t\"net/http\"\n\n\tv1 ")
+			assert.NotContains(t, v3, "net/http")
+		case "stdlib":
+			assert.Contains(t, v3, "func RegisterHandler(mux *http.ServeMux, service v1.Service) {")
+			assert.Contains(t, v3, "\tmux.HandleFunc(\"PUT /books/{id}\", resource.UpdateBooks)\n")
+		}
+	}
+}
+
+func TestSetup_Routes(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	apiInputs := library(t, "gin")
+	routes := apiInputs.Routes
+
+	for _, group := range routes.Groups {
+		mockCmdsExecutor.On("CreateDirectory", "services/library/api/v1/"+group.APIGroup+"/").Return(nil)
+		mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/v1/"+group.APIGroup+"/controller.go", group, controllerContent).Return(nil)
+		mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/v1/"+group.APIGroup+"/service.go", group, serviceContent).Return(nil)
+	}
+	for _, version := range routes.Versions[1:] {
+		mockCmdsExecutor.On("CreateDirectory", "services/library/api/"+version.Name+"/books/").Return(nil)
+		mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/"+version.Name+"/books/controller.go", version.Groups[0], versionControllerContent).Return(nil)
+	}
+	data := openAPIData(apiInputs.WrkDir, routes.Groups...)
+	data.Routes = append(append(append([]models.APIRoute{}, routes.Versions[0].Groups...), routes.Versions[1].Groups...), routes.Versions[2].Groups...)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/v1/bind/bind.go", nil, bindContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/errors/errors.go", apiInputs.DBMS, errorsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/openapi.yaml", data, openAPIContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/docs.go", nil, docsContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/library/api/docs/index.html", "library", docsIndex).Return(nil)
+
+	assert.NoError(t, Setup(apiInputs))
+	mockCmdsExecutor.AssertExpectations(t)
+}
@@ -0,0 +1,670 @@
+package rpc
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	ProtoPath  = "%s/proto/%s/v1/"
+	ServerPath = "%s/api/rpc/"
+	LookPath   = exec.LookPath
+	// Packages of the gRPC server, added to the go.mod of the service
+	Packages = []string{"google.golang.org/grpc", "google.golang.org/protobuf"}
+	// Tools make proto runs
+	Tools = []string{"make", "protoc", "protoc-gen-go", "protoc-gen-go-grpc"}
+)
+
+// CheckTools fails when a tool of make proto is not installed, so it is known before anything is generated
+func CheckTools() error {
+	var missing []string
+	for _, tool := range Tools {
+		if _, err := LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the gRPC transport needs %s on the PATH. Install protoc from https://grpc.io/docs/protoc-installation/ and the plugins with go install google.golang.org/protobuf/cmd/protoc-gen-go@latest google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Setup writes the proto of the API group and its gRPC server, then generates the Go code of the proto with make proto
+func Setup(apiInputs models.APIInputs) (err error) {
+	data, err := grpcData(apiInputs)
+	if err != nil {
+		return err
+	}
+
+	protoPath := fmt.Sprintf(ProtoPath, apiInputs.WrkDir, apiInputs.APIGroup)
+	serverPath := fmt.Sprintf(ServerPath, apiInputs.WrkDir)
+	for _, dir := range []string{protoPath, serverPath} {
+		err = common.CreateDirectory(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	files := []struct{ name, content string }{
+		{protoPath + apiInputs.APIGroup + ".proto", protoContent},
+		{serverPath + "server.go", serverContent},
+		{serverPath + "convert.go", convertContent},
+		{serverPath + apiInputs.APIGroup + ".go", groupServerContent},
+	}
+	for _, file := range files {
+		err = common.CreateFileAndItsContent(file.name, data, file.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\n*** Generating the Go code of the proto with protoc ***")
+	output, err := common.ExecuteCmds("make", []string{"proto"}, apiInputs.WrkDir)
+	if err != nil {
+		return fmt.Errorf("make proto failed: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// conversion of a Go type of the db model to a proto type, the value replaces %s in to and from
+type conversion struct {
+	proto   string
+	goProto string
+	to      string
+	from    string
+}
+
+func (c conversion) identity() bool {
+	return c.to == "%s" && c.from == "%s"
+}
+
+var conversions = map[string]conversion{
+	"string":          {"string", "string", "%s", "%s"},
+	"int8":            {"int32", "int32", "int32(%s)", "int8(%s)"},
+	"int16":           {"int32", "int32", "int32(%s)", "int16(%s)"},
+	"int32":           {"int32", "int32", "%s", "%s"},
+	"int64":           {"int64", "int64", "%s", "%s"},
+	"bool":            {"bool", "bool", "%s", "%s"},
+	"float32":         {"float", "float32", "%s", "%s"},
+	"float64":         {"double", "float64", "%s", "%s"},
+	"[]byte":          {"bytes", "[]byte", "%s", "%s"},
+	"time.Time":       {"google.protobuf.Timestamp", "*timestamppb.Timestamp", "timestamppb.New(%s)", "%s.AsTime()"},
+	"uuid.UUID":       {"string", "string", "%s.String()", "parse(&err, %s, uuid.Parse)"},
+	"json.RawMessage": {"string", "string", "string(%s)", "json.RawMessage(%s)"},
+}
+
+// protoField is the field of a column, nullable columns are optional and arrays repeated
+func protoField(name, fieldName, goType string, number int) (models.ProtoField, error) {
+	field := models.ProtoField{Name: name, GoName: GoCamelCase(name), FieldName: fieldName, Number: number}
+	base, label := goType, ""
+	switch {
+	case strings.HasPrefix(goType, "*"):
+		base, label = goType[1:], "optional "
+	case strings.HasPrefix(goType, "[]") && goType != "[]byte":
+		base, label = goType[2:], "repeated "
+	}
+	c, found := conversions[base]
+	if !found {
+		return field, fmt.Errorf("column %s: Go type %s has no proto type", name, goType)
+	}
+
+	field.Type, field.ToProto, field.FromProto = label+c.proto, c.to, c.from
+	switch {
+	case label == "":
+	case base == "time.Time" && label == "optional ":
+		// a message is nil without optional
+		field.Type, field.ToProto, field.FromProto = c.proto, "timestamp(%s)", "timePtr(%s)"
+	case c.identity():
+		field.ToProto, field.FromProto = "%s", "%s"
+	default:
+		convert := "mapPtr"
+		if label == "repeated " {
+			convert = "mapSlice"
+		}
+		field.ToProto = fmt.Sprintf("%s(%%s, func(v %s) %s { return %s })", convert, base, c.goProto, fmt.Sprintf(c.to, "v"))
+		field.FromProto = fmt.Sprintf("%s(%%s, func(v %s) %s { return %s })", convert, c.goProto, base, fmt.Sprintf(c.from, "v"))
+	}
+	return field, nil
+}
+
+// grpcData is the proto of the table of the group: its row with the columns the generator adds, and the input of the requests
+func grpcData(apiInputs models.APIInputs) (models.GRPC, error) {
+	apiInputs.APIGroupTitle = common.ToCamelCase(apiInputs.APIGroup)
+	apiInputs.TableNameTitle = common.ToCamelCase(apiInputs.TableName)
+	data := models.GRPC{APIInputs: apiInputs, Package: strings.ReplaceAll(apiInputs.APIGroup, "_", "") + "v1", InputName: GoCamelCase(apiInputs.APIGroup)}
+	table := apiInputs.Table
+
+	key := models.ProtoField{Name: "id", GoName: "Id", FieldName: "ID", Number: 1, ToProto: "%s"}
+	switch table.PrimaryKey {
+	case "serial":
+		key.Type = "int32"
+	case "bigserial":
+		key.Type = "int64"
+	case "uuid":
+		key.Type, key.ToProto = "string", "%s.String()"
+	case "ulid":
+		key.Type = "string"
+	default:
+		return data, fmt.Errorf("primary key %q has no proto type", table.PrimaryKey)
+	}
+	data.KeyType = key.Type
+	data.Row = append(data.Row, key)
+
+	for i, column := range table.Columns {
+		row, err := protoField(column.Name, column.FieldName, column.GoType, i+2)
+		if err != nil {
+			return data, err
+		}
+		input := row
+		input.Number = i + 1
+		data.Row = append(data.Row, row)
+		data.Input = append(data.Input, input)
+	}
+
+	// the columns the generator adds to the table
+	type added struct{ name, fieldName, goType string }
+	var columns []added
+	if table.Timestamps {
+		columns = append(columns, added{"created_at", "CreatedAt", "time.Time"}, added{"updated_at", "UpdatedAt", "time.Time"})
+	}
+	if table.SoftDelete {
+		columns = append(columns, added{"deleted_at", "DeletedAt", "*time.Time"})
+	}
+	if table.Versioned {
+		columns = append(columns, added{"version", "Version", "int32"})
+	}
+	for _, column := range columns {
+		field, err := protoField(column.name, column.fieldName, column.goType, len(data.Row)+1)
+		if err != nil {
+			return data, err
+		}
+		data.Row = append(data.Row, field)
+	}
+
+	var expressions []string
+	for _, field := range data.Row {
+		expressions = append(expressions, field.Type, field.ToProto)
+		data.Timestamp = data.Timestamp || strings.HasPrefix(strings.TrimPrefix(field.Type, "repeated "), "google.protobuf.Timestamp")
+	}
+	for _, field := range data.Input {
+		expressions = append(expressions, field.FromProto)
+	}
+	data.Imports = groupImports(apiInputs, strings.Join(expressions, " "))
+	return data, nil
+}
+
+// groupImports are the imports of the server of the group, the standard library, the service and the others
+func groupImports(apiInputs models.APIInputs, expressions string) []string {
+	std := []string{"context", "database/sql", "errors", "net/http"}
+	if strings.Contains(expressions, "json.RawMessage") {
+		std = append(std, "encoding/json")
+	}
+	if strings.Contains(expressions, "time.Time") {
+		std = append(std, "time")
+	}
+
+	module := apiInputs.GoModule + "/" + apiInputs.WrkDir
+	local := []string{
+		"apierrors " + module + "/api/errors",
+		module + "/api/v1/" + apiInputs.APIGroup,
+		module + "/api/v1/bind",
+		module + "/pkg/db",
+	}
+	others := []string{
+		"github.com/IBM/alchemy-logging/src/go/alog",
+		"google.golang.org/grpc/codes",
+		"google.golang.org/grpc/status",
+		"google.golang.org/protobuf/types/known/emptypb",
+	}
+	if strings.Contains(expressions, "timestamppb.") {
+		others = append(others, "google.golang.org/protobuf/types/known/timestamppb")
+	}
+	if strings.Contains(expressions, "uuid.") || apiInputs.Table.PrimaryKey == "uuid" {
+		others = append(others, "github.com/google/uuid")
+	}
+	if apiInputs.Table.PrimaryKey == "ulid" {
+		others = append(others, "github.com/oklog/ulid/v2")
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	// the Go package of the proto is named pb in the server
+	local = append(local, "pb "+module+"/pkg/pb/"+strings.ReplaceAll(apiInputs.APIGroup, "_", "")+"v1")
+
+	var imports []string
+	for i, group := range [][]string{std, local, others} {
+		if i > 0 {
+			imports = append(imports, "")
+		}
+		for _, path := range group {
+			if alias, path, found := strings.Cut(path, " "); found {
+				imports = append(imports, alias+" "+strconv.Quote(path))
+				continue
+			}
+			imports = append(imports, strconv.Quote(path))
+		}
+	}
+	return imports
+}
+
+// GoCamelCase is the Go name protoc-gen-go gives a proto field, e.g. user_id -> UserId
+func GoCamelCase(name string) string {
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(name) && isLower(name[i+1]):
+			// the next letter is upper cased
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(name) && isLower(name[i+1]); i++ {
+				b = append(b, name[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+const protoContent = `// Generated By API Service Generator
+syntax = "proto3";
+
+package {{.APIGroup}}.v1;
+
+option go_package = "{{.GoModule}}/{{.WrkDir}}/pkg/pb/{{.Package}};{{.Package}}";
+
+import "google/protobuf/empty.proto";
+{{- if .Timestamp}}
+import "google/protobuf/timestamp.proto";
+{{- end}}
+
+// {{.APIGroupTitle}}Service serves the rows of {{.TableName}} like the /v1/{{.APIGroup}} routes
+service {{.APIGroupTitle}}Service {
+  rpc List{{.APIGroupTitle}}(List{{.APIGroupTitle}}Request) returns (List{{.APIGroupTitle}}Response);
+  rpc Get{{.APIGroupTitle}}(Get{{.APIGroupTitle}}Request) returns ({{.TableNameTitle}});
+  rpc Create{{.APIGroupTitle}}(Create{{.APIGroupTitle}}Request) returns ({{.TableNameTitle}});
+  rpc Update{{.APIGroupTitle}}(Update{{.APIGroupTitle}}Request) returns ({{.TableNameTitle}});
+  rpc Delete{{.APIGroupTitle}}(Delete{{.APIGroupTitle}}Request) returns (google.protobuf.Empty);
+}
+
+// {{.TableNameTitle}} is a row of {{.TableName}}
+message {{.TableNameTitle}} {
+{{- range .Row}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
+}
+
+// {{.APIGroupTitle}}Input holds the columns of the create and update requests
+message {{.APIGroupTitle}}Input {
+{{- range .Input}}
+  {{.Type}} {{.Name}} = {{.Number}};
+{{- end}}
+}
+
+message List{{.APIGroupTitle}}Request {}
+
+message List{{.APIGroupTitle}}Response {
+  repeated {{.TableNameTitle}} items = 1;
+}
+
+message Get{{.APIGroupTitle}}Request {
+  {{.KeyType}} id = 1;
+}
+
+message Create{{.APIGroupTitle}}Request {
+  {{.APIGroupTitle}}Input {{.APIGroup}} = 1;
+}
+
+message Update{{.APIGroupTitle}}Request {
+  {{.KeyType}} id = 1;
+  {{.APIGroupTitle}}Input {{.APIGroup}} = 2;
+{{- if .Table.Versioned}}
+  // the version the client last read
+  int32 version = 3;
+{{- end}}
+}
+
+message Delete{{.APIGroupTitle}}Request {
+  {{.KeyType}} id = 1;
+}
+`
+
+const serverContent = `// Generated By API Service Generator
+package rpc
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/{{.APIGroup}}"
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/auth"
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/cors"
+	pb "{{.GoModule}}/{{.WrkDir}}/pkg/pb/{{.Package}}"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+var ch = alog.UseChannel("GRPC")
+
+// NewServer serves the {{.APIGroup}} Service over gRPC with the health and reflection services.
+// The interceptors recover from panics and check the origin and the authorization key like the Gin middleware.
+func NewServer({{.APIGroup}}Svc {{.APIGroup}}.Service) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoverUnary, authorizeUnary),
+		grpc.ChainStreamInterceptor(recoverStream, authorizeStream),
+	)
+	pb.Register{{.APIGroupTitle}}ServiceServer(server, New{{.APIGroupTitle}}Server({{.APIGroup}}Svc))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.{{.APIGroupTitle}}Service_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	return server
+}
+
+// public methods are called without the authorization key, like /health
+func public(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/") || strings.HasPrefix(method, "/grpc.reflection.")
+}
+
+// authorize checks the origin of a call like the CORS middleware and its authorization key like the auth middleware
+func authorize(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, origin := range md.Get("origin") {
+		if !cors.AllowOrigin(origin) {
+			return status.Errorf(codes.PermissionDenied, "origin %s is not allowed", origin)
+		}
+	}
+	if public(method) {
+		return nil
+	}
+
+	token := ""
+	if values := md.Get(auth.AuthorizationKey); len(values) > 0 {
+		token = values[0]
+	}
+	if err := auth.Authorize(token); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return nil
+}
+
+func authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authorizeStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// recoverUnary logs a panic of a handler and answers Internal, like gin.Recovery
+func recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ch.Log(alog.ERROR, "panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ch.Log(alog.ERROR, "panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(srv, stream)
+}
+`
+
+const groupServerContent = `// Generated By API Service Generator
+package rpc
+
+import (
+{{- range .Imports}}
+{{- if .}}
+	{{.}}
+{{- else}}
+{{end}}
+{{- end}}
+)
+
+// {{.APIGroupTitle}}Server serves the {{.APIGroup}} Service over gRPC, its methods mirror the /v1/{{.APIGroup}} routes
+type {{.APIGroupTitle}}Server struct {
+	pb.Unimplemented{{.APIGroupTitle}}ServiceServer
+	service {{.APIGroup}}.Service
+}
+
+func New{{.APIGroupTitle}}Server(service {{.APIGroup}}.Service) *{{.APIGroupTitle}}Server {
+	return &{{.APIGroupTitle}}Server{service: service}
+}
+
+func (s *{{.APIGroupTitle}}Server) List{{.APIGroupTitle}}(ctx context.Context, _ *pb.List{{.APIGroupTitle}}Request) (*pb.List{{.APIGroupTitle}}Response, error) {
+	rows, err := s.service.Get{{.APIGroupTitle}}(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &pb.List{{.APIGroupTitle}}Response{Items: make([]*pb.{{.TableNameTitle}}, len(rows))}
+	for i, row := range rows {
+		resp.Items[i] = to{{.TableNameTitle}}(row)
+	}
+	return resp, nil
+}
+
+func (s *{{.APIGroupTitle}}Server) Get{{.APIGroupTitle}}(ctx context.Context, req *pb.Get{{.APIGroupTitle}}Request) (*pb.{{.TableNameTitle}}, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	row, err := s.service.Get{{.APIGroupTitle}}ByID(ctx, id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return to{{.TableNameTitle}}(row), nil
+}
+
+func (s *{{.APIGroupTitle}}Server) Create{{.APIGroupTitle}}(ctx context.Context, req *pb.Create{{.APIGroupTitle}}Request) (*pb.{{.TableNameTitle}}, error) {
+	input, err := from{{.APIGroupTitle}}Input(req.Get{{.InputName}}())
+	if err == nil {
+		err = bind.Validate(input)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	row, err := s.service.Create{{.APIGroupTitle}}(ctx, input)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return to{{.TableNameTitle}}(row), nil
+}
+
+func (s *{{.APIGroupTitle}}Server) Update{{.APIGroupTitle}}(ctx context.Context, req *pb.Update{{.APIGroupTitle}}Request) (*pb.{{.TableNameTitle}}, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	input, err := from{{.APIGroupTitle}}Input(req.Get{{.InputName}}())
+{{- if .Table.Versioned}}
+	update := {{.APIGroup}}.{{.APIGroupTitle}}UpdateRequest{ {{- .APIGroupTitle}}Request: input, Version: req.GetVersion()}
+{{- else}}
+	update := input
+{{- end}}
+	if err == nil {
+		err = bind.Validate(update)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	row, err := s.service.Update{{.APIGroupTitle}}(ctx, id, update)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return to{{.TableNameTitle}}(row), nil
+}
+
+func (s *{{.APIGroupTitle}}Server) Delete{{.APIGroupTitle}}(ctx context.Context, req *pb.Delete{{.APIGroupTitle}}Request) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	if err := s.service.Delete{{.APIGroupTitle}}(ctx, id); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// to{{.TableNameTitle}} converts a row of the db model to its message
+func to{{.TableNameTitle}}(row db.{{.TableNameTitle}}) *pb.{{.TableNameTitle}} {
+	return &pb.{{.TableNameTitle}}{
+{{- range .Row}}
+		{{.GoName}}: {{printf .ToProto (print "row." .FieldName)}},
+{{- end}}
+	}
+}
+
+// from{{.APIGroupTitle}}Input converts the columns of a create or update request to the request of the Service
+func from{{.APIGroupTitle}}Input(in *pb.{{.APIGroupTitle}}Input) ({{.APIGroup}}.{{.APIGroupTitle}}Request, error) {
+	if in == nil {
+		return {{.APIGroup}}.{{.APIGroupTitle}}Request{}, errors.New("{{.APIGroup}} is required")
+	}
+	var err error
+	req := {{.APIGroup}}.{{.APIGroupTitle}}Request{
+{{- range .Input}}
+		{{.FieldName}}: {{printf .FromProto (print "in." .GoName)}},
+{{- end}}
+	}
+	return req, err
+}
+
+// parseID reads the {{.Table.PrimaryKey}} primary key of a request
+func parseID(id {{.KeyType}}) ({{.Table.Key.GoType}}, error) {
+{{- if eq .Table.PrimaryKey "serial" "bigserial"}}
+	return id, nil
+{{- else if eq .Table.PrimaryKey "uuid"}}
+	return uuid.Parse(id)
+{{- else if eq .Table.PrimaryKey "ulid"}}
+	parsed, err := ulid.ParseStrict(id)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+{{- end}}
+}
+
+// statusError answers the errors of the Service like errorResponse of the controller, with the
+// detail of apierrors.From and the gRPC code of its code. The cause of a 5xx is logged, not sent.
+func statusError(err error) error {
+	e := apierrors.From(err)
+	if errors.Is(err, sql.ErrNoRows) {
+		e = apierrors.NotFound("{{.TableName}}")
+	}
+	if e.Status >= http.StatusInternalServerError {
+		ch.Log(alog.ERROR, "%v", e.Cause())
+	}
+	return status.Error(grpcCode(e.Code), e.Detail)
+}
+
+func grpcCode(code apierrors.Code) codes.Code {
+	switch code {
+	case apierrors.CodeNotFound:
+		return codes.NotFound
+	case apierrors.CodeDuplicate:
+		return codes.AlreadyExists
+	case apierrors.CodeConflict:
+		return codes.Aborted
+	case apierrors.CodeReferenceViolation:
+		return codes.FailedPrecondition
+	case apierrors.CodeConstraintViolation:
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+`
+
+const convertContent = `// Generated By API Service Generator
+package rpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mapPtr converts a nullable value, nil stays nil
+func mapPtr[T, U any](value *T, convert func(T) U) *U {
+	if value == nil {
+		return nil
+	}
+	converted := convert(*value)
+	return &converted
+}
+
+// mapSlice converts the values of an array column
+func mapSlice[T, U any](values []T, convert func(T) U) []U {
+	if values == nil {
+		return nil
+	}
+	converted := make([]U, len(values))
+	for i, value := range values {
+		converted[i] = convert(value)
+	}
+	return converted
+}
+
+// parse converts a value that can be invalid, the first error is kept in err
+func parse[T any](err *error, value string, convert func(string) (T, error)) T {
+	parsed, parseErr := convert(value)
+	if parseErr != nil && *err == nil {
+		*err = parseErr
+	}
+	return parsed
+}
+
+// timestamp converts a nullable time, a nil message is NULL
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timePtr(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.AsTime()
+	return &converted
+}
+`
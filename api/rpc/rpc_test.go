@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+func apiInputs(t *testing.T, primaryKey string, columns ...models.Column) models.APIInputs {
+	table := models.TableSpec{PrimaryKey: primaryKey, Columns: columns, Timestamps: true, SoftDelete: true, Versioned: true}
+	require.NoError(t, spec.Resolve(&table, "postgres"))
+	return models.APIInputs{WrkDir: "dir", GoModule: "example.com/app", APIGroup: "line_items", TableName: "line_item", DBMS: "postgres", Table: table, GRPC: true}
+}
+
+// capture returns the files Setup writes, rendered, by their name
+func capture(t *testing.T, setup func() error) (map[string]string, error) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	files := map[string]string{}
+	mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var rendered bytes.Buffer
+		tmpl := template.Must(template.New("file").Parse(args.String(2)))
+		require.NoError(t, tmpl.Execute(&rendered, args.Get(1)))
+		files[args.String(0)] = rendered.String()
+	}).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "make", []string{"proto"}, "dir").Return([]byte{}, nil)
+
+	err := setup()
+	return files, err
+}
+
+func TestSetup(t *testing.T) {
+	inputs := apiInputs(t, "uuid",
+		models.Column{Name: "name", Type: "TEXT"},
+		models.Column{Name: "quantity", Type: "SMALLINT", Nullable: true},
+		models.Column{Name: "price", Type: "NUMERIC(10,2)"},
+		models.Column{Name: "owner_id", Type: "UUID", Nullable: true},
+		models.Column{Name: "shipped_at", Type: "TIMESTAMPTZ", Nullable: true},
+		models.Column{Name: "tags", Type: "TEXT[]"},
+		models.Column{Name: "attributes", Type: "JSONB"},
+		models.Column{Name: "blob", Type: "BYTEA"},
+	)
+	files, err := capture(t, func() error { return Setup(inputs) })
+	require.NoError(t, err)
+
+	proto := files["dir/proto/line_items/v1/line_items.proto"]
+	for _, line := range []string{
+		`package line_items.v1;`,
+		`option go_package = "example.com/app/dir/pkg/pb/lineitemsv1;lineitemsv1";`,
+		`import "google/protobuf/timestamp.proto";`,
+		`rpc DeleteLineItems(DeleteLineItemsRequest) returns (google.protobuf.Empty);`,
+		"message LineItem {\n  string id = 1;\n  string name = 2;\n  optional int32 quantity = 3;",
+		`optional string owner_id = 5;`,
+		`google.protobuf.Timestamp shipped_at = 6;`,
+		`repeated string tags = 7;`,
+		`bytes blob = 9;`,
+		`google.protobuf.Timestamp created_at = 10;`,
+		`int32 version = 13;`,
+		"message LineItemsInput {\n  string name = 1;",
+		"  LineItemsInput line_items = 2;\n  // the version the client last read\n  int32 version = 3;",
+	} {
+		assert.Contains(t, proto, line)
+	}
+
+	group := files["dir/api/rpc/line_items.go"]
+	for _, line := range []string{
+		`pb "example.com/app/dir/pkg/pb/lineitemsv1"`,
+		`"github.com/google/uuid"`,
+		"Id: row.ID.String(),",
+		"Quantity: mapPtr(row.Quantity, func(v int16) int32 { return int32(v) }),",
+		"ShippedAt: timestamp(row.ShippedAt),",
+		"Attributes: string(row.Attributes),",
+		"DeletedAt: timestamp(row.DeletedAt),",
+		"OwnerID: mapPtr(in.OwnerId, func(v string) uuid.UUID { return parse(&err, v, uuid.Parse) }),",
+		"input, err := fromLineItemsInput(req.GetLineItems())",
+		"update := line_items.LineItemsUpdateRequest{LineItemsRequest: input, Version: req.GetVersion()}",
+		// the errors are answered with the detail of the shared error model, the causes of a 5xx are logged
+		`apierrors "example.com/app/dir/api/errors"`,
+		"e := apierrors.From(err)",
+		"\t\te = apierrors.NotFound(\"line_item\")\n",
+		"\t\tch.Log(alog.ERROR, \"%v\", e.Cause())\n",
+		"return status.Error(grpcCode(e.Code), e.Detail)",
+	} {
+		assert.Contains(t, group, line)
+	}
+	assert.NotContains(t, group, "status.Error(codes.Internal, err.Error())")
+
+	for name, content := range files {
+		if strings.HasSuffix(name, ".go") {
+			_, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ParseComments)
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
+func TestSetup_Serial(t *testing.T) {
+	inputs := apiInputs(t, "serial", models.Column{Name: "name", Type: "TEXT"})
+	inputs.Table.Timestamps, inputs.Table.SoftDelete, inputs.Table.Versioned = false, false, false
+	files, err := capture(t, func() error { return Setup(inputs) })
+	require.NoError(t, err)
+
+	proto := files["dir/proto/line_items/v1/line_items.proto"]
+	assert.NotContains(t, proto, "timestamp.proto")
+	assert.Contains(t, proto, "message GetLineItemsRequest {\n  int32 id = 1;\n}")
+	assert.NotContains(t, proto, "version")
+
+	group := files["dir/api/rpc/line_items.go"]
+	assert.Contains(t, group, "func parseID(id int32) (int32, error) {\n\treturn id, nil\n}")
+	assert.Contains(t, group, "update := input")
+	assert.NotContains(t, group, "uuid")
+	_, err = parser.ParseFile(token.NewFileSet(), "line_items.go", group, 0)
+	assert.NoError(t, err)
+}
+
+func TestSetup_MakeProtoError(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "make", []string{"proto"}, "dir").Return([]byte("protoc-gen-go: program not found"), errors.New("exit status 2"))
+
+	err := Setup(apiInputs(t, "ulid", models.Column{Name: "name", Type: "TEXT"}))
+	assert.ErrorContains(t, err, "make proto failed: exit status 2\nprotoc-gen-go: program not found")
+}
+
+func TestSetup_CreateDirectoryError(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateDirectory", "dir/proto/line_items/v1/").Return(errors.New("permission denied"))
+
+	err := Setup(apiInputs(t, "bigserial", models.Column{Name: "name", Type: "TEXT"}))
+	assert.EqualError(t, err, "permission denied")
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestCheckTools(t *testing.T) {
+	defer func() { LookPath = exec.LookPath }()
+	LookPath = func(file string) (string, error) {
+		if file == "protoc" || file == "protoc-gen-go-grpc" {
+			return "", errors.New("not found")
+		}
+		return "/usr/bin/" + file, nil
+	}
+	assert.ErrorContains(t, CheckTools(), "the gRPC transport needs protoc, protoc-gen-go-grpc on the PATH")
+
+	LookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	assert.NoError(t, CheckTools())
+}
+
+func TestProtoField(t *testing.T) {
+	field, err := protoField("scores", "Scores", "[]int16", 4)
+	require.NoError(t, err)
+	assert.Equal(t, models.ProtoField{
+		Name: "scores", GoName: "Scores", FieldName: "Scores", Type: "repeated int32", Number: 4,
+		ToProto:   "mapSlice(%s, func(v int16) int32 { return int32(v) })",
+		FromProto: "mapSlice(%s, func(v int32) int16 { return int16(v) })",
+	}, field)
+
+	field, err = protoField("note", "Note", "*string", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "optional string", field.Type)
+	assert.Equal(t, "%s", field.ToProto)
+
+	_, err = protoField("amount", "Amount", "decimal.Decimal", 2)
+	assert.EqualError(t, err, "column amount: Go type decimal.Decimal has no proto type")
+}
+
+func TestGoCamelCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"id":         "Id",
+		"owner_id":   "OwnerId",
+		"line_items": "LineItems",
+		"address2":   "Address2",
+		"ip_v4_addr": "IpV4Addr",
+		"_hidden":    "XHidden",
+	} {
+		assert.Equal(t, expected, GoCamelCase(name), name)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"fmt"
 
 	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
 )
 
-func CleanUp(wrkDir, containerName, driver string) {
+// CleanUp removes the service directory and the containers and volumes of the service. A reused
+// container, even one labelled with this service by an earlier run, and its data are kept.
+func CleanUp(wrkDir, containerName string, reused bool) {
 	err := removeDirectory(wrkDir)
 	if err != nil {
 		fmt.Println("Error : ", err)
 	}
-	if containerName != "" {
-		err = removeDockerContainer(containerName, driver)
+	if containerName != "" && !reused {
+		err = removeDockerResources(wrkDir)
 		if err != nil {
 			fmt.Println("Error : ", err)
 		}
@@ -32,33 +35,8 @@ func removeDirectory(wrkDir string) error {
 	return nil
 }
 
-func removeDockerContainer(containerName, driver string) error {
-	var volume string
-	cmdStr := "docker"
-	switch driver {
-	case "postgres":
-		volume = "pgdata"
-	case "mysql":
-		volume = "mysql_data"
-	}
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
-
-	output, err := common.ExecuteCmds(cmdStr, cmdArgs1, ".")
-	if err != nil {
-		fmt.Printf("\nError running command: %s\nOutput: %s\n", err, output)
-		return err
-	}
-
-	fmt.Printf("\n\nSuccessfully removed the container: %s\n", containerName)
-
-	output, err = common.ExecuteCmds(cmdStr, cmdArgs2, ".")
-	if err != nil {
-		fmt.Printf("\nError running command: %s\nOutput: %s\n", err, output)
-		return err
-	}
-
-	fmt.Printf("\n\nSuccessfully removed the container volume: %s\n", containerName)
-
-	return nil
+// removeDockerResources removes the containers and volumes labelled with this service only,
+// so the data of other generated services is kept.
+func removeDockerResources(wrkDir string) error {
+	return docker.DefaultRuntime.RemoveService(docker.ProjectName(wrkDir))
 }
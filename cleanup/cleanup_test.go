@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
 	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -45,92 +46,96 @@ func TestRemoveDirectory_Error(t *testing.T) {
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
-func TestRemoveDockerContainer_SuccessPostgres(t *testing.T) {
+var (
+	// the project of dir, its name and the hash of its absolute path
+	labelFilter    = "label=api-service-generator.service=" + docker.ProjectName("dir")
+	listContainers = []string{"ps", "-aq", "--filter", labelFilter}
+	listVolumes    = []string{"volume", "ls", "-q", "--filter", labelFilter}
+)
+
+func TestRemoveDockerResources_Success(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	containerName := "container"
-	volume := "pgdata"
-	driver := "postgres"
 	cmdStr := "docker"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs1, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs2, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr, listContainers, ".").Return([]byte("a1b2c3\n"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr, []string{"rm", "-f", "a1b2c3"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr, listVolumes, ".").Return([]byte("dir_pgdata\n"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr, []string{"volume", "rm", "dir_pgdata"}, ".").Return([]byte(""), nil)
 
-	err := removeDockerContainer(containerName, driver)
+	err := removeDockerResources("dir")
 	assert.NoError(t, err)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
-func TestRemoveDockerContainer_SuccessMySQL(t *testing.T) {
+
+func TestRemoveDockerResources_NothingLabelled(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	containerName := "container"
-	volume := "mysql_data"
-	driver := "mysql"
-	cmdStr := "docker"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs1, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs2, ".").Return([]byte(""), nil)
+	// other services' containers and volumes are never listed, so nothing is removed
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listContainers, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listVolumes, ".").Return([]byte(""), nil)
 
-	err := removeDockerContainer(containerName, driver)
+	err := removeDockerResources("dir")
 	assert.NoError(t, err)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
-func TestRemoveDockerContainer_ContainerVolumeError(t *testing.T) {
+func TestRemoveDockerResources_ListError(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	containerName := "container"
-	volume := "pgdata"
-	driver := "postgres"
-	cmdStr := "docker"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs1, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs2, ".").Return([]byte(""), errors.New("error in removing the container volume"))
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listContainers, ".").Return([]byte(""), errors.New("error in listing the containers"))
 
-	err := removeDockerContainer(containerName, driver)
+	err := removeDockerResources("dir")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error in removing the container volume")
+	assert.Contains(t, err.Error(), "error in listing the containers")
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
-func TestRemoveDockerContainer_ContainerError(t *testing.T) {
+func TestRemoveDockerResources_ContainerError(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	containerName := "container"
-	driver := "postgres"
-	cmdStr := "docker"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs1, ".").Return([]byte(""), errors.New("error in stopping the container"))
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listContainers, ".").Return([]byte("a1b2c3"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"rm", "-f", "a1b2c3"}, ".").Return([]byte(""), errors.New("error in stopping the container"))
 
-	err := removeDockerContainer(containerName, driver)
+	err := removeDockerResources("dir")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error in stopping the container")
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
+func TestRemoveDockerResources_VolumeError(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listContainers, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", listVolumes, ".").Return([]byte("dir_mysql_data"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"volume", "rm", "dir_mysql_data"}, ".").Return([]byte(""), errors.New("error in removing the container volume"))
+
+	err := removeDockerResources("dir")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error in removing the container volume")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
 func TestCleanUp_WithoutContainerName(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
 	wrkDir := "dir"
 	containerName := ""
-	driver := "postgres"
 	cmdStr := "rm"
 	cmdArgs := []string{"-rf", wrkDir}
 	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), nil)
 
-	CleanUp(wrkDir, containerName, driver)
+	CleanUp(wrkDir, containerName, false)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
@@ -141,12 +146,11 @@ func TestCleanUp_WithoutContainerName_Error(t *testing.T) {
 
 	wrkDir := "dir"
 	containerName := ""
-	driver := "postgres"
 	cmdStr := "rm"
 	cmdArgs := []string{"-rf", wrkDir}
 	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), errors.New("error in removing the directory"))
 
-	CleanUp(wrkDir, containerName, driver)
+	CleanUp(wrkDir, containerName, false)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
@@ -159,17 +163,15 @@ func TestCleanUp_WithContainerName(t *testing.T) {
 	containerName := "containerName"
 	cmdStr := "rm"
 	cmdArgs := []string{"-rf", wrkDir}
-	volume := "pgdata"
-	driver := "postgres"
 	cmdStr2 := "docker"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
 
 	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, cmdArgs1, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, cmdArgs2, ".").Return([]byte(""), nil)
-	
-	CleanUp(wrkDir, containerName, driver)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, listContainers, ".").Return([]byte("a1b2c3"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, []string{"rm", "-f", "a1b2c3"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, listVolumes, ".").Return([]byte("dir_pgdata"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, []string{"volume", "rm", "dir_pgdata"}, ".").Return([]byte(""), nil)
+
+	CleanUp(wrkDir, containerName, false)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
@@ -183,16 +185,24 @@ func TestCleanUp_WithContainerName_Error(t *testing.T) {
 	cmdStr := "rm"
 	cmdArgs := []string{"-rf", wrkDir}
 	cmdStr2 := "docker"
-	volume := "pgdata"
-	driver := "postgres"
-	cmdArgs1 := []string{"rm", "-f", containerName}
-	cmdArgs2 := []string{"volume", "rm", volume}
 
 	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, cmdArgs1, ".").Return([]byte(""), nil)
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, cmdArgs2, ".").Return([]byte(""), errors.New("error in removing docker container"))
-	
-	CleanUp(wrkDir, containerName, driver)
+	mockCmdsExecutor.On("ExecuteCmds", cmdStr2, listContainers, ".").Return([]byte(""), errors.New("error in listing the containers"))
+
+	CleanUp(wrkDir, containerName, false)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestCleanUp_ReusedContainer(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	// the reused container carries the label of this service from an earlier run, it is not listed or removed
+	mockCmdsExecutor.On("ExecuteCmds", "rm", []string{"-rf", "dir"}, ".").Return([]byte(""), nil)
+
+	CleanUp("dir", "containerName", true)
 
 	mockCmdsExecutor.AssertExpectations(t)
+	mockCmdsExecutor.AssertNotCalled(t, "ExecuteCmds", "docker", listContainers, ".")
 }
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/db/snapshot"
+	"github.com/abhijithk1/api-service-generator/manifest"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/spf13/cobra"
+)
+
+// dbCmd groups the commands that manage the database container of a generated service
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database container of a generated service",
+	Long:  `Commands that manage the database container of a generated service, with the settings read from its api-service-generator.yaml`,
+}
+
+var dbStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the database container, creating it from docker-compose.yml when it is missing",
+	Run:   runDB(startDB),
+}
+
+var dbStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the database container, the data is kept",
+	Run:   runDB(stopDB),
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the database container",
+	Run:   runDB(statusDB),
+}
+
+var dbLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Print the last lines of the database container logs",
+	Run: runDB(func(cmd *cobra.Command, dbInputs models.DBInputs) error {
+		tail, _ := cmd.Flags().GetInt("tail")
+		return logsDB(dbInputs, tail)
+	}),
+}
+
+var dbShellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Open psql or mysql inside the database container",
+	Run:   runDB(shellDB),
+}
+
+var dbResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Recreate the database container with an empty data volume",
+	Run: runDB(func(cmd *cobra.Command, dbInputs models.DBInputs) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+		return resetDB(bufio.NewReader(os.Stdin), dbInputs, yes)
+	}),
+}
+
+var dbSnapshotCmd = &cobra.Command{
+	Use:   "snapshot <name>",
+	Short: "Dump the database into a snapshot under .apigen/snapshots",
+	Args:  cobra.ExactArgs(1),
+	Run: runDB(func(cmd *cobra.Command, dbInputs models.DBInputs) error {
+		return snapshotDB(dbInputs, cmd.Flags().Arg(0))
+	}),
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Replace the database with a snapshot taken at the same migration version",
+	Args:  cobra.ExactArgs(1),
+	Run: runDB(func(cmd *cobra.Command, dbInputs models.DBInputs) error {
+		force, _ := cmd.Flags().GetBool("force")
+		return restoreDB(dbInputs, cmd.Flags().Arg(0), force)
+	}),
+}
+
+func init() {
+	dbCmd.PersistentFlags().String("dir", ".", "Directory of the generated service.")
+	dbCmd.PersistentFlags().String("runtime", "", "Container runtime, the one the service was generated with by default.")
+	dbLogsCmd.Flags().Int("tail", 100, "Number of lines to print.")
+	dbResetCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation.")
+	dbRestoreCmd.Flags().Bool("force", false, "Restore a snapshot taken at another migration version.")
+	dbCmd.AddCommand(dbStartCmd, dbStopCmd, dbStatusCmd, dbLogsCmd, dbShellCmd, dbResetCmd, dbSnapshotCmd, dbRestoreCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// runDB loads the service of the --dir flag and runs the db command on it
+func runDB(run func(cmd *cobra.Command, dbInputs models.DBInputs) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		runtime, _ := cmd.Flags().GetString("runtime")
+		dbInputs, err := loadService(dir, runtime)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+		if dbInputs.External && cmd.Name() != "status" {
+			fmt.Println("Error : ", fmt.Errorf("the %s database of this service is external, manage it where it runs", dbInputs.DBMS))
+			return
+		}
+
+		err = run(cmd, dbInputs)
+		if err != nil {
+			fmt.Println("Error : ", err)
+		}
+	}
+}
+
+// loadService reads the manifest of the service in dir and selects its runtime
+func loadService(dir, runtime string) (models.DBInputs, error) {
+	m, err := manifest.Read(dir)
+	if err != nil {
+		return models.DBInputs{}, err
+	}
+	if runtime == "" {
+		runtime = m.Runtime
+	}
+	docker.DefaultRuntime, err = docker.NewRuntime(runtime)
+	if err != nil {
+		return models.DBInputs{}, err
+	}
+	dbInputs := manifest.DBInputs(m, dir)
+	if project := docker.ProjectName(dbInputs.WrkDir); project != m.Project {
+		fmt.Printf("\nWarning: the service was generated as %s, resources are labelled with that name and not %s\n", m.Project, project)
+	}
+	return dbInputs, nil
+}
+
+func startDB(_ *cobra.Command, dbInputs models.DBInputs) error {
+	container, found, err := docker.InspectContainer(dbInputs.ContainerName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case found && container.Running:
+		fmt.Printf("\nContainer %s is already running\n", dbInputs.ContainerName)
+	case found:
+		fmt.Printf("\nStarting the container %s\n", dbInputs.ContainerName)
+		err = docker.DefaultRuntime.Start(dbInputs.ContainerName)
+	default:
+		fmt.Printf("\nContainer %s does not exist, creating it\n", dbInputs.ContainerName)
+		err = docker.DefaultRuntime.Up(dbInputs)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nWaiting for the database to accept connections")
+	err = docker.WaitForDB(dbInputs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nThe database is listening on port %d\n", dbInputs.ContainerPort)
+	return nil
+}
+
+func stopDB(_ *cobra.Command, dbInputs models.DBInputs) error {
+	err := docker.DefaultRuntime.Stop(dbInputs.ContainerName)
+	if errors.Is(err, docker.ErrNotFound) {
+		fmt.Printf("\nContainer %s does not exist\n", dbInputs.ContainerName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nStopped the container %s\n", dbInputs.ContainerName)
+	return nil
+}
+
+func statusDB(_ *cobra.Command, dbInputs models.DBInputs) error {
+	if dbInputs.External {
+		fmt.Printf("\nThe %s database is external, its DSN is in app.env\n", dbInputs.DBMS)
+		return nil
+	}
+
+	container, found, err := docker.InspectContainer(dbInputs.ContainerName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Printf("\nContainer %s does not exist, create it with db start\n", dbInputs.ContainerName)
+		return nil
+	}
+
+	state := "stopped"
+	if container.Running {
+		state = "running, not accepting connections yet"
+		if docker.Ready(dbInputs) {
+			state = "running, accepting connections"
+		}
+	}
+	fmt.Printf("\nContainer: %s\nState:     %s\nImage:     %s\nPort:      %d\nDatabase:  %s (%s)\n",
+		dbInputs.ContainerName, state, container.Image, container.Port, dbInputs.DBName, dbInputs.DBMS)
+	return nil
+}
+
+func logsDB(dbInputs models.DBInputs, tail int) error {
+	logs, err := docker.DefaultRuntime.Logs(dbInputs.ContainerName, tail)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s", logs)
+	return nil
+}
+
+func shellDB(_ *cobra.Command, dbInputs models.DBInputs) error {
+	container, found, err := docker.InspectContainer(dbInputs.ContainerName)
+	if err != nil {
+		return err
+	}
+	if !found || !container.Running {
+		return fmt.Errorf("container %s is not running, start it with db start", dbInputs.ContainerName)
+	}
+
+	env, shellCmd := docker.ShellCommand(dbInputs)
+	return docker.DefaultRuntime.Shell(dbInputs.ContainerName, env, shellCmd)
+}
+
+// resetDB removes the containers and volumes of the service and starts the database again,
+// empty. A reused container was not created for the service, so it is never reset.
+func resetDB(reader *bufio.Reader, dbInputs models.DBInputs, yes bool) error {
+	if dbInputs.ReuseContainer {
+		return fmt.Errorf("container %s was not created for this service, reset it with docker", dbInputs.ContainerName)
+	}
+	if !yes {
+		prompt := fmt.Sprintf("Reset removes the container %s and all of its data, continue? (y/n): ", dbInputs.ContainerName)
+		choice := promptForInput(reader, prompt, "n", func(s string) bool { return s == "y" || s == "n" })
+		if choice != "y" {
+			return fmt.Errorf("reset aborted")
+		}
+	}
+
+	err := docker.DefaultRuntime.RemoveService(docker.ProjectName(dbInputs.WrkDir))
+	if err != nil {
+		return err
+	}
+	err = docker.DefaultRuntime.Up(dbInputs)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nWaiting for the database to accept connections")
+	err = docker.WaitForDB(dbInputs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nThe database is empty, make run migrates it and make seed loads the fixtures")
+	return nil
+}
+
+func snapshotDB(dbInputs models.DBInputs, name string) error {
+	taken, err := snapshot.Create(dbInputs, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nSaved the snapshot %s at migration version %d in %s\n", name, taken.Version, filepath.Join(snapshot.Dir, taken.File))
+	if taken.Dirty {
+		fmt.Println("\nWarning: the last migration of the database failed, the snapshot is marked dirty")
+	}
+	return nil
+}
+
+func restoreDB(dbInputs models.DBInputs, name string, force bool) error {
+	restored, err := snapshot.Restore(dbInputs, name, force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nRestored the snapshot %s taken %s, the database is at migration version %d\n",
+		name, restored.Created.Local().Format(time.DateTime), restored.Version)
+	if restored.Dirty {
+		fmt.Println("\nWarning: the snapshot is marked dirty, force the migration version before migrating again")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/manifest"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var serviceInputs = models.DBInputs{
+	WrkDir:        "/srv/myservice",
+	DBMS:          "postgres",
+	ContainerName: "dummy_db",
+	ContainerPort: 6432,
+	DBName:        "dummy_db",
+	Postgres:      models.PostgresDriver{PsqlUser: "postgres", PsqlPassword: "password"},
+}
+
+var (
+	inspectDummy = []string{"inspect", "--type", "container", "dummy_db"}
+	readyDummy   = []string{"exec", "dummy_db", "pg_isready", "-h", "127.0.0.1", "-U", "postgres", "-d", "dummy_db"}
+	runningDummy = []byte(`[{"Config": {"Image": "postgres:16"}, "State": {"Running": true}, "HostConfig": {"PortBindings": {"5432/tcp": [{"HostPort": "6432"}]}}}]`)
+	stoppedDummy = []byte(`[{"Config": {"Image": "postgres:16"}, "State": {"Running": false}}]`)
+)
+
+func TestLoadService(t *testing.T) {
+	runtime := docker.DefaultRuntime
+	defer func() { docker.DefaultRuntime = runtime }()
+
+	dir := filepath.Join(t.TempDir(), "myservice")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	require.NoError(t, os.WriteFile(dir+manifest.FileName, []byte(`project: myservice
+runtime: podman
+database:
+  driver: postgres
+  container: dummy_db
+  image: postgres:16
+  port: 6432
+  name: dummy_db
+  user: postgres
+  password: password
+`), 0644))
+
+	dbInputs, err := loadService(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, dir, dbInputs.WrkDir)
+	assert.Equal(t, serviceInputs.Postgres, dbInputs.Postgres)
+	assert.Equal(t, "dummy_db", dbInputs.ContainerName)
+	podman, _ := docker.NewRuntime("podman")
+	assert.Equal(t, podman, docker.DefaultRuntime)
+
+	_, err = loadService(dir, "docker")
+	require.NoError(t, err)
+	assert.Equal(t, runtime, docker.DefaultRuntime)
+
+	_, err = loadService(t.TempDir(), "")
+	assert.ErrorContains(t, err, "no manifest in")
+}
+
+func TestStartDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		inspect []byte
+		err     error
+		start   []string
+		dir     string
+	}{
+		{"running", runningDummy, nil, nil, ""},
+		{"stopped", stoppedDummy, nil, []string{"start", "dummy_db"}, "."},
+		{"missing", []byte("Error: No such container: dummy_db"), assert.AnError, docker.ComposeUp, "/srv/myservice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+			common.DefaultExecutor = mockCmdsExecutor
+			mockCmdsExecutor.On("ExecuteCmds", "docker", inspectDummy, ".").Return(tt.inspect, tt.err)
+			if tt.start != nil {
+				mockCmdsExecutor.On("ExecuteCmds", "docker", tt.start, tt.dir).Return([]byte(""), nil)
+			}
+			mockCmdsExecutor.On("ExecuteCmds", "docker", readyDummy, ".").Return([]byte("accepting connections"), nil)
+
+			err := startDB(dbStartCmd, serviceInputs)
+			assert.NoError(t, err)
+
+			mockCmdsExecutor.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStopDB(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"stop", "dummy_db"}, ".").Return([]byte("dummy_db"), nil).Once()
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"stop", "dummy_db"}, ".").Return([]byte("Error: No such container: dummy_db"), assert.AnError).Once()
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"stop", "dummy_db"}, ".").Return([]byte("permission denied"), assert.AnError).Once()
+
+	assert.NoError(t, stopDB(dbStopCmd, serviceInputs))
+	// a missing container is already stopped
+	assert.NoError(t, stopDB(dbStopCmd, serviceInputs))
+	assert.ErrorContains(t, stopDB(dbStopCmd, serviceInputs), "permission denied")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestStatusDB(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspectDummy, ".").Return(runningDummy, nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyDummy, ".").Return([]byte("no response"), assert.AnError)
+
+	assert.NoError(t, statusDB(dbStatusCmd, serviceInputs))
+	// the status of an external database needs no container
+	assert.NoError(t, statusDB(dbStatusCmd, models.DBInputs{DBMS: "postgres", External: true}))
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestShellDB(t *testing.T) {
+	interactive := docker.Interactive
+	defer func() { docker.Interactive = interactive }()
+	var shell []string
+	docker.Interactive = func(cmdStr string, cmdArgs, env []string) error {
+		shell = append([]string{cmdStr}, cmdArgs...)
+		return nil
+	}
+
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspectDummy, ".").Return(runningDummy, nil).Once()
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspectDummy, ".").Return(stoppedDummy, nil).Once()
+
+	require.NoError(t, shellDB(dbShellCmd, serviceInputs))
+	assert.Equal(t, []string{"docker", "exec", "-it", "dummy_db", "psql", "-U", "postgres", "-d", "dummy_db"}, shell)
+
+	err := shellDB(dbShellCmd, serviceInputs)
+	assert.EqualError(t, err, "container dummy_db is not running, start it with db start")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestResetDB(t *testing.T) {
+	t.Run("confirmed", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		project := docker.ProjectName("/srv/myservice")
+		filter := "label=api-service-generator.service=" + project
+		mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"ps", "-aq", "--filter", filter}, ".").Return([]byte("a1b2"), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"rm", "-f", "a1b2"}, ".").Return([]byte(""), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"volume", "ls", "-q", "--filter", filter}, ".").Return([]byte(project+"_pgdata"), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"volume", "rm", project + "_pgdata"}, ".").Return([]byte(""), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", docker.ComposeUp, "/srv/myservice").Return([]byte(""), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", readyDummy, ".").Return([]byte("accepting connections"), nil)
+
+		err := resetDB(bufio.NewReader(strings.NewReader("y\n")), serviceInputs, false)
+		assert.NoError(t, err)
+
+		mockCmdsExecutor.AssertExpectations(t)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+
+		// nothing is removed, the mock has no expectations
+		err := resetDB(bufio.NewReader(strings.NewReader("\n")), serviceInputs, false)
+		assert.EqualError(t, err, "reset aborted")
+	})
+
+	t.Run("reused container", func(t *testing.T) {
+		dbInputs := serviceInputs
+		dbInputs.ReuseContainer = true
+		err := resetDB(bufio.NewReader(strings.NewReader("")), dbInputs, true)
+		assert.ErrorContains(t, err, "was not created for this service")
+	})
+}
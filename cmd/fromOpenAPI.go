@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/abhijithk1/api-service-generator/openapi"
+	"github.com/spf13/cobra"
+)
+
+// fromOpenAPICmd generates the API layer of a service from an OpenAPI document
+var fromOpenAPICmd = &cobra.Command{
+	Use:   "from-openapi <spec.yaml>",
+	Short: "Generate the Gin routes, request and response structs and services of an OpenAPI 3 document",
+	Long: `Generates a package under api/v1 per tag of an OpenAPI 3 document, with the Gin handlers of its operations,
+the structs of their requests and responses with validation tags, and a Service interface.
+The component schemas go to api/v1/schemas and api/v1/openapi.go registers every handler.
+
+service_impl.go is only written when it is missing, run the command again after changing the document:
+the generated files are replaced and the new operations get stubs in service_stubs.go.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		module, _ := cmd.Flags().GetString("module")
+		err := openapi.Setup(args[0], dir, module)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+		fmt.Println("\nGenerated the API of the document, register it with v1.RegisterOpenAPIHandlers in main.go")
+	},
+}
+
+func init() {
+	fromOpenAPICmd.Flags().String("dir", ".", "Directory of the service to generate the API in.")
+	fromOpenAPICmd.Flags().String("module", "", "Go module of the service, the one of the go.mod of --dir by default.")
+	rootCmd.AddCommand(fromOpenAPICmd)
+}
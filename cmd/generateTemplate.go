@@ -9,14 +9,21 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/abhijithk1/api-service-generator/api"
+	"github.com/abhijithk1/api-service-generator/api/graphql"
 	"github.com/abhijithk1/api-service-generator/api/mw"
+	"github.com/abhijithk1/api-service-generator/api/rpc"
 	"github.com/abhijithk1/api-service-generator/cleanup"
 	"github.com/abhijithk1/api-service-generator/common"
 	finalsetup "github.com/abhijithk1/api-service-generator/common/finalSetup"
 	"github.com/abhijithk1/api-service-generator/db"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/db/external"
+	"github.com/abhijithk1/api-service-generator/deploy"
 	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
 	"github.com/abhijithk1/api-service-generator/util"
 	"github.com/spf13/cobra"
 )
@@ -31,6 +38,18 @@ var generateTemplateCmd = &cobra.Command{
 
 func init() {
 	generateTemplateCmd.Flags().StringP("name", "n", "", "Name of the API Service that needs to be generated.")
+	generateTemplateCmd.Flags().Int("seed-rows", 0, "Number of fake rows to synthesise into the seed fixtures.")
+	generateTemplateCmd.Flags().String("spec", "", "Path of a YAML table spec with the primary key and columns of the table.")
+	generateTemplateCmd.Flags().String("db-dsn", "", "DSN of an existing database to use instead of starting a container.")
+	generateTemplateCmd.Flags().Bool("no-container", false, "Use an existing database, its DSN is prompted for when --db-dsn is not set.")
+	generateTemplateCmd.Flags().Bool("k8s", false, "Write Kubernetes manifests and Kustomize overlays for dev, staging and prod to deploy/k8s.")
+	generateTemplateCmd.Flags().Bool("helm", false, "Write a Helm chart of the service to deploy/helm/<name>.")
+	generateTemplateCmd.Flags().String("transport", "rest", "Transport of the Service: rest, grpc or both. gRPC needs protoc, protoc-gen-go and protoc-gen-go-grpc.")
+	generateTemplateCmd.Flags().String("framework", "gin", "Router of the API layer: gin, chi or stdlib, the ServeMux of the standard library.")
+	generateTemplateCmd.Flags().Bool("graphql", false, "Serve the table over GraphQL at /graphql, with the relationships of its foreign keys.")
+	generateTemplateCmd.Flags().String("runtime", "docker", "Container runtime for the database: "+strings.Join(docker.Runtimes, ", ")+".")
+	generateTemplateCmd.Flags().Duration("db-wait-timeout", 60*time.Second, "How long to wait for the database container to accept connections.")
+	generateTemplateCmd.Flags().Duration("db-wait-interval", 500*time.Millisecond, "First pause between readiness checks, doubled after every failed check.")
 	rootCmd.AddCommand(generateTemplateCmd)
 }
 
@@ -46,21 +65,117 @@ func runGenerateTemplate(cmd *cobra.Command, args []string) {
 	}
 	apiInputs.WrkDir = dbInputs.WrkDir
 
+	runtime, _ := cmd.Flags().GetString("runtime")
+	var err error
+	docker.DefaultRuntime, err = docker.NewRuntime(runtime)
+	if err != nil {
+		fmt.Println("Error : ", err)
+		return
+	}
+	dbInputs.Runtime = runtime
+
+	transport, _ := cmd.Flags().GetString("transport")
+	switch transport {
+	case "rest":
+	case "grpc", "both":
+		apiInputs.GRPC, apiInputs.GRPCOnly = true, transport == "grpc"
+		dbInputs.GRPC = true
+		// fail before the database container is started
+		err = rpc.CheckTools()
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+	default:
+		fmt.Println("Error : ", fmt.Errorf("transport %q is not supported, use rest, grpc or both", transport))
+		return
+	}
+	apiInputs.Framework, _ = cmd.Flags().GetString("framework")
+	if _, ok := api.Frameworks[apiInputs.Framework]; !ok {
+		fmt.Println("Error : ", fmt.Errorf("framework %q is not supported, use gin, chi or stdlib", apiInputs.Framework))
+		return
+	}
+	apiInputs.GraphQL, _ = cmd.Flags().GetBool("graphql")
+	if apiInputs.GraphQL && apiInputs.GRPCOnly {
+		fmt.Println("Error : ", fmt.Errorf("--graphql is served next to the REST routes, use --transport rest or both"))
+		return
+	}
+	dbInputs.GraphQL = apiInputs.GraphQL
+	dbInputs.SeedRows, _ = cmd.Flags().GetInt("seed-rows")
+	dbInputs.WaitTimeout, _ = cmd.Flags().GetDuration("db-wait-timeout")
+	dbInputs.WaitInterval, _ = cmd.Flags().GetDuration("db-wait-interval")
+
+	dsn, _ := cmd.Flags().GetString("db-dsn")
+	noContainer, _ := cmd.Flags().GetBool("no-container")
+
 	reader := bufio.NewReader(os.Stdin)
-	dbInputs.DBMS = promptForInput(reader, "Enter the Database Driver: ", "postgres", common.IsValidString)
-	dbInputs.ContainerName = promptForInput(reader, "Enter the name for the Docker container: ", "dummy_db", common.IsValidString)
-	dbInputs.ContainerPort = promptForInt(reader, "Enter the name for the Docker container port: ", 6432)
-	err := driverInputs(reader, &dbInputs)
+	if dsn != "" || noContainer {
+		err = externalInputs(reader, dsn, &dbInputs)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+	} else {
+		dbInputs.DBMS = promptForInput(reader, "Enter the Database Driver: ", "postgres", common.IsValidString)
+		dbInputs.ContainerName = promptForInput(reader, "Enter the name for the Docker container: ", "dummy_db", common.IsValidString)
+		dbInputs.ContainerPort = promptForInt(reader, "Enter the name for the Docker container port: ", 6432)
+		err = driverInputs(reader, &dbInputs)
+		if err != nil {
+			fmt.Println(err.Error())
+		}
+	}
+	specPath, _ := cmd.Flags().GetString("spec")
+	apiSpec, err := spec.LoadAPI(specPath, dbInputs.DBMS)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Println("Error : ", err)
+		return
+	}
+	if apiSpec.Groups != nil {
+		if apiInputs.GRPC {
+			fmt.Println("Error : ", fmt.Errorf("--transport grpc serves a single group, the groups of a spec are served with --transport rest"))
+			return
+		}
+		dbInputs.Tables = apiSpec.Tables
+		dbInputs.Table = apiSpec.Tables[0].TableSpec
+	} else {
+		// the table name comes first, a column of the spec can reference the table
+		dbInputs.TableName = promptForInput(reader, "Enter a Table Name: ", "api_table", common.IsValidString)
+		dbInputs.Table, err = spec.Load(specPath, dbInputs.DBMS, dbInputs.TableName)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+	}
+	if !dbInputs.External {
+		dbInputs.DBName = promptForInput(reader, "Enter the Name of the Database: ", "dummy_db", common.IsValidString)
+		err = checkContainer(reader, &dbInputs)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+	}
+	// the tables and groups of a spec with groups are not prompted for
+	if apiSpec.Groups != nil {
+		dbInputs.TableName = apiSpec.Tables[0].Name
 	}
-	dbInputs.DBName = promptForInput(reader, "Enter the Name of the Database: ", "dummy_db", common.IsValidString)
-	dbInputs.TableName = promptForInput(reader, "Enter a Table Name: ", "api_table", common.IsValidString)
 	apiInputs.TableName = dbInputs.TableName
-	apiInputs.APIGroup = promptForInput(reader, "Enter an API Group: ", "dummy", common.IsValidString)
+	apiInputs.DBMS = dbInputs.DBMS
+	apiInputs.Table = dbInputs.Table
+	if apiSpec.Groups == nil {
+		apiInputs.APIGroup = promptForInput(reader, "Enter an API Group: ", "dummy", common.IsValidString)
+	}
 	apiInputs.GoModule = promptForInput(reader, "Enter a Go Module Base Path: ", "example/api-service", func(s string) bool {return true})
 	dbInputs.GoModule = apiInputs.GoModule
+	groups := []models.APIInputs{apiInputs}
+	if apiSpec.Groups != nil {
+		routes := api.Routes(apiInputs, apiSpec)
+		groups = routes.Groups
+		// the first group stands for the service where a single group is expected
+		apiInputs = routes.Groups[0]
+		apiInputs.Routes = routes
+	}
 
+	common.DependentPackages = append(common.DependentPackages, api.Frameworks[apiInputs.Framework]...)
 	steps := []func() error{
 		func() error { return common.Initialise(apiInputs.GoModule, &dbInputs) },
 		func() error { return db.Setup(dbInputs) },
@@ -69,11 +184,28 @@ func runGenerateTemplate(cmd *cobra.Command, args []string) {
 		func() error { return util.SetUtils(apiInputs.WrkDir) },
 		func() error { return finalsetup.FinalSetup(apiInputs, dbInputs) },
 	}
+	if apiInputs.GRPC {
+		common.DependentPackages = append(common.DependentPackages, rpc.Packages...)
+		// make proto is a target of the Makefile FinalSetup writes
+		steps = append(steps, func() error { return rpc.Setup(apiInputs) })
+	}
+	if apiInputs.GraphQL {
+		common.DependentPackages = append(common.DependentPackages, graphql.Packages...)
+		steps = append(steps, func() error { return graphql.Setup(groups...) })
+	}
+	if k8s, _ := cmd.Flags().GetBool("k8s"); k8s {
+		steps = append(steps, func() error { return deploy.Setup(dbInputs) })
+	}
+	if helm, _ := cmd.Flags().GetBool("helm"); helm {
+		steps = append(steps, func() error { return deploy.SetupHelm(dbInputs) })
+	}
 
 	for _, step := range steps {
 		if err := step(); err != nil {
 			fmt.Printf("Error: Setup step failed: %v", err)
-			cleanup.CleanUp(dbInputs.WrkDir, dbInputs.ContainerName, dbInputs.DBMS)
+			// a reused container may carry the label of this service from an earlier run, so
+			// cleanup keeps it and its volume, and an external database has no container name
+			cleanup.CleanUp(dbInputs.WrkDir, dbInputs.ContainerName, dbInputs.ReuseContainer)
 			return
 		}
 	}
@@ -128,4 +260,73 @@ func driverInputs(reader *bufio.Reader, dbInputs *models.DBInputs) error {
 	default:
 		return fmt.Errorf("driver not supported")
 	}
-}
\ No newline at end of file
+}
+
+// externalInputs takes the DSN of a database that runs elsewhere and checks that it answers
+func externalInputs(reader *bufio.Reader, dsn string, dbInputs *models.DBInputs) error {
+	for dsn == "" {
+		// not promptForInput, the DSN is case sensitive
+		fmt.Print("\nEnter the DSN of the database: ")
+		input, err := reader.ReadString('\n')
+		dsn = strings.TrimSpace(input)
+		if err != nil && dsn == "" {
+			return fmt.Errorf("DSN should be provided")
+		}
+	}
+
+	dbms, err := external.Driver(dsn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nPinging the %s database\n", dbms)
+	err = external.Ping(dbms, dsn)
+	if err != nil {
+		return err
+	}
+
+	dbInputs.DBMS = dbms
+	dbInputs.DSN = dsn
+	dbInputs.External = true
+	return nil
+}
+
+// checkContainer resolves name and port clashes with what already runs on the machine
+func checkContainer(reader *bufio.Reader, dbInputs *models.DBInputs) error {
+	for {
+		container, found, err := docker.InspectContainer(dbInputs.ContainerName)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+
+		reusable := docker.Reusable(container, *dbInputs)
+		prompt := fmt.Sprintf("Container %s already exists, pick a new name (n) or abort (a): ", dbInputs.ContainerName)
+		if reusable {
+			prompt = fmt.Sprintf("Container %s already exists with the same image and credentials, reuse it (r), pick a new name (n) or abort (a): ", dbInputs.ContainerName)
+		}
+		choice := promptForInput(reader, prompt, "a", func(s string) bool {
+			return s == "n" || s == "a" || (reusable && s == "r")
+		})
+		switch choice {
+		case "r":
+			dbInputs.ReuseContainer = true
+			dbInputs.ContainerPort = container.Port
+			return nil
+		case "n":
+			dbInputs.ContainerName = promptForInput(reader, "Enter the name for the Docker container: ", dbInputs.ContainerName+"_2", common.IsValidString)
+		default:
+			return fmt.Errorf("container %s already exists", dbInputs.ContainerName)
+		}
+	}
+
+	for !docker.PortFree(dbInputs.ContainerPort) {
+		next := docker.NextFreePort(dbInputs.ContainerPort)
+		if next == 0 {
+			return fmt.Errorf("port %d is already in use and no free port was found after it", dbInputs.ContainerPort)
+		}
+		dbInputs.ContainerPort = promptForInt(reader, fmt.Sprintf("Port %d is already in use, enter another port: ", dbInputs.ContainerPort), next)
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/db/external"
+	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/abhijithk1/api-service-generator/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock validation function that always returns true
@@ -170,3 +176,105 @@ func TestDriverInputs(t *testing.T) {
 		})
 	}
 }
+
+const inspectPostgres = `[{"Config": {"Image": "postgres:16", "Env": ["POSTGRES_USER=postgres", "POSTGRES_PASSWORD=password", "POSTGRES_DB=dummy_db"]},
+	"State": {"Running": true}, "HostConfig": {"PortBindings": {"5432/tcp": [{"HostPort": "7432"}]}}}]`
+
+func TestCheckContainer(t *testing.T) {
+	portFree := docker.PortFree
+	defer func() { docker.PortFree = portFree }()
+	inspect := func(name string) []string { return []string{"inspect", "--type", "container", name} }
+	notFound := []byte("Error: No such container")
+	newInputs := func() *models.DBInputs {
+		return &models.DBInputs{
+			DBMS:          "postgres",
+			ContainerName: "dummy_db",
+			ContainerPort: 6432,
+			DBName:        "dummy_db",
+			Postgres:      models.PostgresDriver{PsqlUser: "postgres", PsqlPassword: "password"},
+		}
+	}
+
+	t.Run("reuse", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("dummy_db"), ".").Return([]byte(inspectPostgres), nil)
+
+		dbInputs := newInputs()
+		err := checkContainer(bufio.NewReader(strings.NewReader("r\n")), dbInputs)
+		require.NoError(t, err)
+		assert.True(t, dbInputs.ReuseContainer)
+		assert.Equal(t, 7432, dbInputs.ContainerPort)
+	})
+
+	t.Run("new name and next free port", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("dummy_db"), ".").Return([]byte(inspectPostgres), nil)
+		mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("dummy_db_2"), ".").Return(notFound, assert.AnError)
+		docker.PortFree = func(port int) bool { return port > 6433 }
+
+		dbInputs := newInputs()
+		err := checkContainer(bufio.NewReader(strings.NewReader("n\n\n\n")), dbInputs)
+		require.NoError(t, err)
+		assert.False(t, dbInputs.ReuseContainer)
+		assert.Equal(t, "dummy_db_2", dbInputs.ContainerName)
+		assert.Equal(t, 6434, dbInputs.ContainerPort)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("dummy_db"), ".").Return([]byte(inspectPostgres), nil)
+
+		dbInputs := newInputs()
+		dbInputs.Postgres.PsqlPassword = "other"
+		// reuse is not offered when the credentials differ
+		err := checkContainer(bufio.NewReader(strings.NewReader("r\na\n")), dbInputs)
+		assert.ErrorContains(t, err, "container dummy_db already exists")
+	})
+
+	t.Run("no free port", func(t *testing.T) {
+		mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+		common.DefaultExecutor = mockCmdsExecutor
+		mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("dummy_db"), ".").Return(notFound, assert.AnError)
+		docker.PortFree = func(int) bool { return false }
+
+		err := checkContainer(bufio.NewReader(strings.NewReader("")), newInputs())
+		assert.ErrorContains(t, err, "port 6432 is already in use")
+	})
+}
+
+func TestExternalInputs(t *testing.T) {
+	ping := external.Ping
+	defer func() { external.Ping = ping }()
+	var pinged []string
+	external.Ping = func(dbms, dsn string) error {
+		pinged = append(pinged, dbms+" "+dsn)
+		if strings.Contains(dsn, "down") {
+			return errors.New("error pinging postgres: connection refused")
+		}
+		return nil
+	}
+
+	dbInputs := &models.DBInputs{}
+	err := externalInputs(bufio.NewReader(strings.NewReader("")), "App:S3cret@tcp(db.internal:3306)/Shared", dbInputs)
+	require.NoError(t, err)
+	assert.Equal(t, models.DBInputs{DBMS: "mysql", DSN: "App:S3cret@tcp(db.internal:3306)/Shared", External: true}, *dbInputs)
+
+	// --no-container prompts for the DSN and keeps its case
+	dbInputs = &models.DBInputs{}
+	err = externalInputs(bufio.NewReader(strings.NewReader("\n postgres://App@db.internal/Shared \n")), "", dbInputs)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://App@db.internal/Shared", dbInputs.DSN)
+	assert.Equal(t, []string{"mysql App:S3cret@tcp(db.internal:3306)/Shared", "postgres postgres://App@db.internal/Shared"}, pinged)
+
+	err = externalInputs(bufio.NewReader(strings.NewReader("")), "postgres://app@down/shared", &models.DBInputs{})
+	assert.ErrorContains(t, err, "connection refused")
+
+	err = externalInputs(bufio.NewReader(strings.NewReader("")), "", &models.DBInputs{})
+	assert.EqualError(t, err, "DSN should be provided")
+
+	err = externalInputs(bufio.NewReader(strings.NewReader("")), "shared.db", &models.DBInputs{})
+	assert.ErrorContains(t, err, "cannot tell the driver")
+}
@@ -14,8 +14,8 @@ import (
 )
 
 var (
-	InitialDirectories = []string{"/api", "/api/v1", "/api/v1/mw", "/api/v1/mw/cors", "/api/v1/mw/auth", "/pkg", "/pkg/db", "/pkg/db/migrations", "/pkg/db/query", "/utils"}
-	DependentPackages  = []string{"github.com/gin-gonic/gin", "github.com/IBM/alchemy-logging/src/go/alog", "github.com/golang-migrate/migrate/v4", "github.com/gin-contrib/cors", "github.com/spf13/viper", "github.com/stretchr/testify/mock"}
+	InitialDirectories = []string{"/api", "/api/docs", "/api/docs/ui", "/api/errors", "/api/v1", "/api/v1/bind", "/api/v1/mw", "/api/v1/mw/cors", "/api/v1/mw/auth", "/pkg", "/pkg/db", "/pkg/db/migrations", "/pkg/db/query", "/pkg/db/seeds", "/cmd", "/cmd/seed", "/cmd/healthcheck", "/utils"}
+	DependentPackages  = []string{"github.com/IBM/alchemy-logging/src/go/alog", "github.com/golang-migrate/migrate/v4", "github.com/go-playground/validator/v10", "github.com/spf13/viper", "github.com/stretchr/testify/mock", "gopkg.in/yaml.v3"}
 	MarshalYAML        = yaml.Marshal
 )
 
@@ -123,6 +123,7 @@ func Initialise(path string, dbInputs *models.DBInputs) (err error){
 	fmt.Println("\n*** Successfully created go.mod ***")
 
 	appendDriverPackage(dbInputs)
+	appendTablePackages(dbInputs)
 	fmt.Println("\n*** Updating go packages ***")
 	err = ExecuteGoGets(dbInputs.WrkDir)
 	if err != nil {
@@ -164,6 +165,11 @@ func capitalize(s string) string {
 	return string(runes)
 }
 
+// NetHTTP reports whether the handlers of the framework are net/http handlers, Gin is the default
+func NetHTTP(framework string) bool {
+	return framework == "chi" || framework == "stdlib"
+}
+
 func IsValidString(s string) bool {
 	// Define the regular expression pattern to match only alphanumeric characters and underscores.
 	var validStringPattern = `^[a-zA-Z0-9_]*$`
@@ -175,6 +181,24 @@ func IsValidString(s string) bool {
 	return re.MatchString(s)
 }
 
+// appendTablePackages adds the packages the column types of every table need, each once
+func appendTablePackages(dbInputs *models.DBInputs) {
+	packages := dbInputs.Table.Packages
+	for _, table := range dbInputs.Tables {
+		packages = append(packages, table.Packages...)
+	}
+	seen := map[string]bool{}
+	for _, pkg := range DependentPackages {
+		seen[pkg] = true
+	}
+	for _, pkg := range packages {
+		if !seen[pkg] {
+			seen[pkg] = true
+			DependentPackages = append(DependentPackages, pkg)
+		}
+	}
+}
+
 func appendDriverPackage(dbInputs *models.DBInputs) {
 	switch dbInputs.DBMS {
 	case "postgres":
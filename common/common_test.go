@@ -330,6 +330,36 @@ func TestInitialise_SuccessPostgres(t *testing.T) {
 
 }
 
+func TestInitialise_TablePackages(t *testing.T) {
+	mockExec := mocks.NewMockCmdsExecutor()
+	DefaultExecutor = mockExec
+
+	// the second table needs a package the first does not, the shared one is fetched once
+	dbInputs := models.DBInputs{
+		DBMS:   "postgres",
+		WrkDir: "example",
+		Table:  models.TableSpec{Packages: []string{"github.com/google/uuid"}},
+		Tables: []models.Table{
+			{Name: "authors", TableSpec: models.TableSpec{Packages: []string{"github.com/google/uuid"}}},
+			{Name: "books", TableSpec: models.TableSpec{Packages: []string{"github.com/google/uuid", "github.com/oklog/ulid/v2"}}},
+		},
+	}
+	InitialDirectories = []string{}
+	DependentPackages = []string{"github.com/some/package"}
+
+	mockExec.On("CreateDirectory", dbInputs.WrkDir).Return(nil)
+	mockExec.On("ExecuteCmds", "go", []string{"mod", "init", "example/path/example"}, dbInputs.WrkDir).Return([]byte(""), nil)
+	for _, pkg := range []string{"github.com/some/package", "github.com/lib/pq", "github.com/google/uuid", "github.com/oklog/ulid/v2"} {
+		mockExec.On("ExecuteCmds", "go", []string{"get", pkg}, dbInputs.WrkDir).Return([]byte(""), nil).Once()
+	}
+
+	err := Initialise("example/path", &dbInputs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github.com/some/package", "github.com/lib/pq", "github.com/google/uuid", "github.com/oklog/ulid/v2"}, DependentPackages)
+
+	mockExec.AssertExpectations(t)
+}
+
 func TestInitialise_SuccessMySql(t *testing.T) {
 	// Create a mock executor
 	mockExec := mocks.NewMockCmdsExecutor()
@@ -1,15 +1,21 @@
 package finalsetup
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
+	"text/template"
 
 	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/manifest"
 	"github.com/abhijithk1/api-service-generator/models"
 )
 
 var (
 	PostgresDBSource = `{{.DBMS}}://{{.Postgres.PsqlUser}}:{{.Postgres.PsqlPassword}}@localhost:{{.ContainerPort}}/{{.DBName}}?sslmode=disable`
-	MysqlDBSource = `{{.MySQL.MysqlUser}}:{{.MySQL.MysqlPassword}}@tcp(localhost:{{.ContainerPort}})/{{.DBName}}?charset=utf8&parseTime=True&loc=Local`
+	MysqlDBSource = `{{.MySQL.MysqlUser}}:{{.MySQL.MysqlPassword}}@tcp(localhost:{{.ContainerPort}})/{{.DBName}}?charset=utf8&parseTime=True&loc=Local&multiStatements=true`
+	// the DSN given with --db-dsn, written as is
+	ExternalDBSource = `{{.DSN}}`
 )
 
 func FinalSetup(apiInputs models.APIInputs, dbInputs models.DBInputs) (err error) {
@@ -25,7 +31,13 @@ func FinalSetup(apiInputs models.APIInputs, dbInputs models.DBInputs) (err error
 		return
 	}
 
-	err = createMakeFile(apiInputs.WrkDir)
+	err = manifest.Write(dbInputs)
+	if err != nil {
+		fmt.Println("Error : ", err)
+		return
+	}
+
+	err = createMakeFile(dbInputs)
 	if err != nil {
 		fmt.Println("Error : ", err)
 		return
@@ -37,18 +49,39 @@ func FinalSetup(apiInputs models.APIInputs, dbInputs models.DBInputs) (err error
 		return
 	}
 
+	err = createDockerFiles(dbInputs.WrkDir)
+	if err != nil {
+		fmt.Println("Error : ", err)
+		return
+	}
+
 	return nil
 }
 
 const mainContent = `// Generated By API Service Generator
 package main
 
-import (
-	"{{.GoModule}}/{{.WrkDir}}/api/v1/{{.APIGroup}}"
+import ({{template "groupImports" .}}
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+{{- if not .GRPCOnly}}
 	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/auth"
+{{- end}}
 	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/cors"
 	"{{.GoModule}}/{{.WrkDir}}/pkg/db"
 	util "{{.GoModule}}/{{.WrkDir}}/utils"
+{{- if not .GRPCOnly}}
+	"{{.GoModule}}/{{.WrkDir}}/api"
+{{- end}}
+{{- if .GraphQL}}
+	"{{.GoModule}}/{{.WrkDir}}/api/graphql"
+{{- end}}
+{{- if .GRPC}}
+	"{{.GoModule}}/{{.WrkDir}}/api/rpc"
+	"net"
+	"os"
+{{- end}}
+	"flag"
+	"io"
 	"net/http"
 
 	"github.com/IBM/alchemy-logging/src/go/alog"
@@ -58,13 +91,19 @@ import (
 var ch = alog.UseChannel("MAIN")
 
 const DB_REVISION = 1
+{{- if .GRPCOnly}}
+
+// setupRouter serves /health only, the Service is served over gRPC
+func setupRouter() *gin.Engine {
+{{- else}}
 
-func setupRouter({{.APIGroup}}Svc {{.APIGroup}}.Service) *gin.Engine {
+func setupRouter({{template "serviceParams" .}}) *gin.Engine {
+{{- end}}
 	router := gin.New()
 
 	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{
 		SkipPaths: []string{"/health"},
-	}), gin.Recovery())
+	}), gin.CustomRecoveryWithWriter(io.Discard, recovery))
 
 	router.Use(cors.CORSMiddleware())
 
@@ -72,18 +111,50 @@ func setupRouter({{.APIGroup}}Svc {{.APIGroup}}.Service) *gin.Engine {
 		ctx.JSON(http.StatusOK, gin.H{"health": "ok"})
 	})
 
+{{- if not .GRPCOnly}}
+
+	// DOCS=true in app.env serves api/openapi.yaml and Swagger UI at /docs
+	if util.GetAppConfig().DOCS == "true" {
+		api.RegisterDocs(router)
+	}
+{{- if .Routes}}
+{{- range .Routes.Versions}}
+
+	{{.Name}} := router.Group("/{{.Name}}")
+	{{.Name}}.Use({{if or .DeprecationHeader .SunsetHeader}}{{template "deprecation" .}}, {{end}}auth.AuthMiddleware())
+{{range .Groups}}
+	{{template "package" .}}.RegisterHandler({{.Version}}, {{.APIGroup}}Svc)
+{{- end}}
+{{- end}}
+{{- else}}
+
 	v1 := router.Group("/v1")
 	v1.Use(auth.AuthMiddleware())
 
 	{{.APIGroup}}.RegisterHandler(v1, {{.APIGroup}}Svc)
+{{- end}}
+{{- if .GraphQL}}
+
+	// GRAPHQL_PLAYGROUND=true in app.env serves the playground at GET /graphql
+	graphql.RegisterHandler(router{{template "graphqlArgs" .}})
+{{- end}}
+{{- end}}
 
 	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"code": "404_NOT_FOUND", "message": "No URL found"})
+		apierrors.Write(c.Writer, c.Request, apierrors.RouteNotFound())
 	})
 
 	return router
 }
 
+// recovery answers the panic of a handler with the problem of a 500, apierrors logs it with the stack
+func recovery(c *gin.Context, rec any) {
+	apierrors.Write(c.Writer, c.Request, apierrors.Panic(rec))
+	c.Abort()
+}
+
+var migrateOnly = flag.Bool("migrate-only", false, "migrate the database and exit, the migration job of the deployment")
+
 func main() {
 	util.StartLogServer()
 	conn := db.GetConnection()
@@ -95,23 +166,290 @@ func main() {
 		ch.Log(alog.ERROR, "Failed to migrate: %v", err)
 		return
 	}
+	if *migrateOnly {
+		ch.Log(alog.INFO, "Database migrated to revision %d", DB_REVISION)
+		return
+	}
 
 	queries := db.New(conn)
 
-	{{.APIGroup}}Svc := {{.APIGroup}}.New{{.APIGroupTitle}}Service(queries)
+	{{template "newServices" .}}
+
+{{- if .GRPC}}
+
+	go serveGRPC(&{{.APIGroup}}Svc)
+{{- end}}
 
 	gin.SetMode(gin.ReleaseMode)
 
-	router := setupRouter(&{{.APIGroup}}Svc)
+	router := setupRouter({{if not .GRPCOnly}}{{template "serviceArgs" .}}{{end}})
 
 	ch.Log(alog.INFO, "Server listening on port 8080")
 	router.Run(":8080")
 }
+{{- if .GRPC}}
+
+// serveGRPC serves the Service over gRPC on GRPC_PORT, next to the HTTP server
+func serveGRPC({{.APIGroup}}Svc {{.APIGroup}}.Service) {
+	port := util.GetAppConfig().GRPCPort
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		ch.Log(alog.ERROR, "Failed to listen on gRPC port %s: %v", port, err)
+		os.Exit(1)
+	}
+	ch.Log(alog.INFO, "gRPC server listening on port %s", port)
+	if err := rpc.NewServer({{.APIGroup}}Svc).Serve(listener); err != nil {
+		ch.Log(alog.ERROR, "gRPC server stopped: %v", err)
+		os.Exit(1)
+	}
+}
+{{- end}}
+` + groupsTemplate
+
+// httpMainContent is main.go of the net/http routers, chi and the ServeMux of the standard library
+const httpMainContent = `// Generated By API Service Generator
+package main
+
+import (
+	"encoding/json"
+	"flag"
+{{- if .GRPC}}
+	"net"
+{{- end}}
+	"net/http"
+{{- if .GRPC}}
+	"os"
+{{- end}}
+{{/* a blank line before the packages of the service */}}
+{{- if not .GRPCOnly}}
+	"{{.GoModule}}/{{.WrkDir}}/api"
+{{- end}}
+{{- if .GraphQL}}
+	"{{.GoModule}}/{{.WrkDir}}/api/graphql"
+{{- end}}
+{{- if .GRPC}}
+	"{{.GoModule}}/{{.WrkDir}}/api/rpc"
+{{- end}}{{template "groupImports" .}}
+	apierrors "{{.GoModule}}/{{.WrkDir}}/api/errors"
+{{- if not .GRPCOnly}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/auth"
+{{- end}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/cors"
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/logger"
+	"{{.GoModule}}/{{.WrkDir}}/pkg/db"
+	util "{{.GoModule}}/{{.WrkDir}}/utils"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+{{- if eq .Framework "chi"}}
+	"github.com/go-chi/chi/v5"
+{{- end}}
+)
+
+var ch = alog.UseChannel("MAIN")
+
+const DB_REVISION = 1
+{{- if .GRPCOnly}}
+
+// setupRouter serves /health only, the Service is served over gRPC
+func setupRouter() http.Handler {
+{{- else}}
+
+func setupRouter({{template "serviceParams" .}}) http.Handler {
+{{- end}}
+{{- if eq .Framework "chi"}}
+	router := chi.NewRouter()
+
+	router.Use(logger.Logger("/health"), logger.Recovery())
+
+	router.Use(cors.CORSMiddleware())
+
+	router.Get("/health", health)
+{{- if not .GRPCOnly}}
+
+	// DOCS=true in app.env serves api/openapi.yaml and Swagger UI at /docs
+	if util.GetAppConfig().DOCS == "true" {
+		api.RegisterDocs(router)
+	}
+{{- if .Routes}}
+{{- range .Routes.Versions}}
+
+	router.Route("/{{.Name}}", func({{.Name}} chi.Router) {
+		{{.Name}}.Use({{if or .DeprecationHeader .SunsetHeader}}{{template "deprecation" .}}, {{end}}auth.AuthMiddleware())
+{{range .Groups}}
+		{{template "package" .}}.RegisterHandler({{.Version}}, {{.APIGroup}}Svc)
+{{- end}}
+	})
+{{- end}}
+{{- else}}
+
+	router.Route("/v1", func(v1 chi.Router) {
+		v1.Use(auth.AuthMiddleware())
+
+		{{.APIGroup}}.RegisterHandler(v1, {{.APIGroup}}Svc)
+	})
+{{- end}}
+{{- if .GraphQL}}
+
+	// GRAPHQL_PLAYGROUND=true in app.env serves the playground at GET /graphql
+	graphql.RegisterHandler(router{{template "graphqlArgs" .}})
+{{- end}}
+{{- end}}
+
+	router.NotFound(notFound)
+
+	return router
+{{- else}}
+	router := http.NewServeMux()
+
+	router.HandleFunc("GET /health", health)
+{{- if not .GRPCOnly}}
+
+	// DOCS=true in app.env serves api/openapi.yaml and Swagger UI at /docs
+	if util.GetAppConfig().DOCS == "true" {
+		api.RegisterDocs(router)
+	}
+{{- if .Routes}}
+{{- range .Routes.Versions}}
+
+	{{.Name}} := http.NewServeMux()
+{{- range .Groups}}
+	{{template "package" .}}.RegisterHandler({{.Version}}, {{.APIGroup}}Svc)
+{{- end}}
+	{{.Name}}.HandleFunc("/", notFound)
+	router.Handle("/{{.Name}}/", http.StripPrefix("/{{.Name}}", {{if or .DeprecationHeader .SunsetHeader}}{{template "deprecation" .}}(auth.AuthMiddleware()({{.Name}})){{else}}auth.AuthMiddleware()({{.Name}}){{end}}))
+{{- end}}
+{{- else}}
+
+	v1 := http.NewServeMux()
+	{{.APIGroup}}.RegisterHandler(v1, {{.APIGroup}}Svc)
+	v1.HandleFunc("/", notFound)
+	router.Handle("/v1/", http.StripPrefix("/v1", auth.AuthMiddleware()(v1)))
+{{- end}}
+{{- if .GraphQL}}
+
+	// GRAPHQL_PLAYGROUND=true in app.env serves the playground at GET /graphql
+	graphql.RegisterHandler(router{{template "graphqlArgs" .}})
+{{- end}}
+{{- end}}
+
+	router.HandleFunc("/", notFound)
+
+	// the middleware wraps the mux, so it runs for unmatched routes too
+	return logger.Logger("/health")(logger.Recovery()(cors.CORSMiddleware()(router)))
+{{- end}}
+}
+
+func health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"health": "ok"})
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	apierrors.Write(w, r, apierrors.RouteNotFound())
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+var migrateOnly = flag.Bool("migrate-only", false, "migrate the database and exit, the migration job of the deployment")
+
+func main() {
+	util.StartLogServer()
+	conn := db.GetConnection()
+
+	defer conn.Close()
+
+	err := db.RunMigration(conn, DB_REVISION)
+	if err != nil {
+		ch.Log(alog.ERROR, "Failed to migrate: %v", err)
+		return
+	}
+	if *migrateOnly {
+		ch.Log(alog.INFO, "Database migrated to revision %d", DB_REVISION)
+		return
+	}
+
+	queries := db.New(conn)
+
+	{{template "newServices" .}}
+
+{{- if .GRPC}}
+
+	go serveGRPC(&{{.APIGroup}}Svc)
+{{- end}}
+
+	router := setupRouter({{if not .GRPCOnly}}{{template "serviceArgs" .}}{{end}})
+
+	ch.Log(alog.INFO, "Server listening on port 8080")
+	if err := http.ListenAndServe(":8080", router); err != nil {
+		ch.Log(alog.ERROR, "Server stopped: %v", err)
+	}
+}
+{{- if .GRPC}}
+
+// serveGRPC serves the Service over gRPC on GRPC_PORT, next to the HTTP server
+func serveGRPC({{.APIGroup}}Svc {{.APIGroup}}.Service) {
+	port := util.GetAppConfig().GRPCPort
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		ch.Log(alog.ERROR, "Failed to listen on gRPC port %s: %v", port, err)
+		os.Exit(1)
+	}
+	ch.Log(alog.INFO, "gRPC server listening on port %s", port)
+	if err := rpc.NewServer({{.APIGroup}}Svc).Serve(listener); err != nil {
+		ch.Log(alog.ERROR, "gRPC server stopped: %v", err)
+		os.Exit(1)
+	}
+}
+{{- end}}
+` + groupsTemplate
+
+// groupsTemplate is the part of main.go that differs for the groups and versions of a spec with groups
+const groupsTemplate = `
+{{- define "groupImports"}}
+{{- if .Routes}}
+{{- range .Routes.Groups}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/{{.APIGroup}}"
+{{- end}}
+{{- range .Routes.Versions}}{{if ne .Name "v1"}}{{range .Groups}}
+	{{template "package" .}} "{{.GoModule}}/{{.WrkDir}}/api/{{.Version}}/{{.APIGroup}}"
+{{- end}}{{end}}{{end}}
+{{- if .Routes.Deprecated}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/mw/deprecation"
+{{- end}}
+{{- else}}
+	"{{.GoModule}}/{{.WrkDir}}/api/v1/{{.APIGroup}}"
+{{- end}}
+{{- end}}
+{{- define "serviceParams"}}
+{{- if .Routes}}{{range $i, $group := .Routes.Groups}}{{if $i}}, {{end}}{{.APIGroup}}Svc {{.APIGroup}}.Service{{end}}
+{{- else}}{{.APIGroup}}Svc {{.APIGroup}}.Service{{end}}
+{{- end}}
+{{- define "serviceArgs"}}
+{{- if .Routes}}{{range $i, $group := .Routes.Groups}}{{if $i}}, {{end}}&{{.APIGroup}}Svc{{end}}
+{{- else}}&{{.APIGroup}}Svc{{end}}
+{{- end}}
+{{- define "graphqlArgs"}}
+{{- if .Routes}}{{range .Routes.Groups}}, {{.APIGroup}}Svc{{end}}
+{{- else}}, {{.APIGroup}}Svc{{end}}
+{{- end}}
+{{- define "newServices"}}
+{{- if .Routes}}{{range $i, $group := .Routes.Groups}}{{if $i}}
+	{{end}}{{.APIGroup}}Svc := {{.APIGroup}}.New{{.APIGroupTitle}}Service(queries){{end}}
+{{- else}}{{.APIGroup}}Svc := {{.APIGroup}}.New{{.APIGroupTitle}}Service(queries){{end}}
+{{- end}}
+{{- define "package"}}{{.APIGroup}}{{if ne .Version "v1"}}{{.Version}}{{end}}{{end}}
+{{- define "deprecation"}}deprecation.Deprecation({{printf "%q" .DeprecationHeader}}, {{printf "%q" .SunsetHeader}}, {{printf "%q" .LinkHeader}}){{end}}
 `
 
 func createMainFile(apiInputs models.APIInputs) error {
 	fileName := apiInputs.WrkDir + "/main.go"
 	apiInputs.APIGroupTitle = common.ToCamelCase(apiInputs.APIGroup)
+	if common.NetHTTP(apiInputs.Framework) {
+		return common.CreateFileAndItsContent(fileName, apiInputs, httpMainContent)
+	}
 	return common.CreateFileAndItsContent(fileName, apiInputs, mainContent)
 }
 
@@ -121,10 +459,17 @@ DB_DRIVER={{.DBMS}}
 DB_SOURCE=%s
 SECURE=false
 AUTH=false
+DOCS=false
+{{- if .GRPC}}
+GRPC_PORT=9090
+{{- end}}
+{{- if .GraphQL}}
+GRAPHQL_PLAYGROUND=false
+{{- end}}
 `
 
 func createENVFile(dbInputs models.DBInputs) error {
-	newEnvFile, err := envFIleUpdate(dbInputs.DBMS)
+	newEnvFile, err := envFileContent(dbInputs)
 	if err != nil {
 		return err
 	}
@@ -132,10 +477,49 @@ func createENVFile(dbInputs models.DBInputs) error {
 	return common.CreateFileAndItsContent(envFileName, dbInputs, newEnvFile)
 }
 
+func envFileContent(dbInputs models.DBInputs) (string, error) {
+	if dbInputs.External {
+		return fmt.Sprintf(envFile, ExternalDBSource), nil
+	}
+	return envFIleUpdate(dbInputs.DBMS)
+}
+
+// AppEnv is the content of app.env as key value pairs, in the order of the file
+func AppEnv(dbInputs models.DBInputs) ([]models.EnvVar, error) {
+	content, err := envFileContent(dbInputs)
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	if err = template.Must(template.New("app.env").Parse(content)).Execute(&rendered, dbInputs); err != nil {
+		return nil, err
+	}
+
+	var env []models.EnvVar
+	for _, line := range strings.Split(rendered.String(), "\n") {
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.HasPrefix(line, "#") {
+			continue
+		}
+		env = append(env, models.EnvVar{Key: key, Value: value})
+	}
+	return env, nil
+}
+
 const makeFileContent = `# Generated By API Service Generator
 
 include app.env
 
+IMAGE ?= $(shell basename "$(CURDIR)" | tr '[:upper:]' '[:lower:]')
+{{- if not .External}}
+
+up: ## start the database from docker-compose.yml
+	docker compose up -d
+
+down: ## stop the containers, the data volume is kept
+	docker compose down
+{{- end}}
+
 migrateup:
 	migrate -path pkg/db/migrations -database "$(DB_SOURCE)" -verbose up
 
@@ -157,13 +541,24 @@ sqlc: ## run all new database migrations
 	@echo "Running sqlc code generation..."
 	sqlc generate
 
-.PHONY: migrateup, migratedown, run, test, build, sqlc
+seed: ## load the fixtures in pkg/db/seeds, rows that already exist are skipped
+	go run ./cmd/seed
+
+docker-build: ## build the container image of the service with the local Go version
+	docker build --build-arg GO_VERSION=$$(go env GOVERSION | sed 's/^go//') -t $(IMAGE) .
+{{- if .GRPC}}
+
+proto: ## generate the Go code of the .proto files in proto to pkg/pb, needs protoc, protoc-gen-go and protoc-gen-go-grpc
+	protoc --go_out=. --go_opt=module={{.GoModule}}/{{.WrkDir}} --go-grpc_out=. --go-grpc_opt=module={{.GoModule}}/{{.WrkDir}} $$(find proto -name '*.proto')
+{{- end}}
+
+.PHONY:{{if not .External}} up, down,{{end}} migrateup, migratedown, run, test, build, sqlc, seed, docker-build{{if .GRPC}}, proto{{end}}
 
 `
 
-func createMakeFile(wrkDir string) error {
-	fileName := wrkDir + "/Makefile"
-	return common.CreateFileAndItsContent(fileName, nil, makeFileContent)
+func createMakeFile(dbInputs models.DBInputs) error {
+	fileName := dbInputs.WrkDir + "/Makefile"
+	return common.CreateFileAndItsContent(fileName, dbInputs, makeFileContent)
 }
 
 const api_HTTP = ` # Generated By API Service Generator
@@ -172,10 +567,59 @@ const api_HTTP = ` # Generated By API Service Generator
 
 ### health 
 GET http://localhost:8080/health
+{{- if not .GRPCOnly}}
+{{- if .Routes}}{{range .Routes.Versions}}{{range .Groups}}{{template "requests" .}}{{end}}{{end}}
+{{- else}}{{template "requests" .}}{{end}}
+{{- if .GraphQL}}
+
+###GraphQL
+POST http://localhost:8080/graphql
+Authorization: Bearer <token>
+Content-Type: application/json
+
+{"query": "{ {{.APIGroup}} { id } }"}
+{{- end}}
+{{- end}}
+{{- define "requests"}}
+
+###Get{{.APIGroupTitle}}{{template "version" .}}
+GET http://localhost:8080/{{or .Version "v1"}}/{{.APIGroup}}
+Authorization: Bearer <token>
 
-###Get{{.APIGroupTitle}}
-GET http://localhost:8080/v1/{{.APIGroup}}
+###Get{{.APIGroupTitle}}ByID{{template "version" .}}
+GET http://localhost:8080/{{or .Version "v1"}}/{{.APIGroup}}/<id>
 Authorization: Bearer <token>
+
+###Create{{.APIGroupTitle}}{{template "version" .}}
+POST http://localhost:8080/{{or .Version "v1"}}/{{.APIGroup}}
+Authorization: Bearer <token>
+Content-Type: application/json
+
+{
+{{- range $i, $c := .Table.Columns}}{{if $i}},{{end}}
+    "{{$c.Name}}": {{$c.Example}}
+{{- end}}
+}
+
+###Update{{.APIGroupTitle}}{{template "version" .}}
+PUT http://localhost:8080/{{or .Version "v1"}}/{{.APIGroup}}/<id>
+Authorization: Bearer <token>
+Content-Type: application/json
+
+{
+{{- range $i, $c := .Table.Columns}}{{if $i}},{{end}}
+    "{{$c.Name}}": {{$c.Example}}
+{{- end}}
+{{- if .Table.Versioned}},
+    "version": 1
+{{- end}}
+}
+
+###Delete{{.APIGroupTitle}}{{template "version" .}}
+DELETE http://localhost:8080/{{or .Version "v1"}}/{{.APIGroup}}/<id>
+Authorization: Bearer <token>
+{{- end}}
+{{- define "version"}}{{if .Version}} {{.Version}}{{end}}{{end}}
 `
 
 func createAPIHTTPFile(apiInputs models.APIInputs) error {
@@ -183,6 +627,90 @@ func createAPIHTTPFile(apiInputs models.APIInputs) error {
 	return common.CreateFileAndItsContent(filepath, apiInputs, api_HTTP)
 } 
 
+const dockerfileContent = `# syntax=docker/dockerfile:1
+# Generated By API Service Generator
+# make docker-build builds the image. app.env and its credentials are left out,
+# the service is configured by the environment: docker run -p 8080:8080 -e DB_SOURCE=... <image>
+ARG GO_VERSION=1.22
+
+FROM golang:${GO_VERSION} AS build
+WORKDIR /src
+
+# the modules are downloaded in their own layer, cached until go.mod or go.sum change
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod go mod download
+
+COPY . .
+RUN --mount=type=cache,target=/go/pkg/mod --mount=type=cache,target=/root/.cache/go-build \
+    CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /out/service . && \
+    CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /out/healthcheck ./cmd/healthcheck
+
+FROM gcr.io/distroless/static-debian12:nonroot
+# the service reads the migrations relative to its working directory
+WORKDIR /app
+COPY --from=build /out/service /out/healthcheck ./
+COPY pkg/db/migrations ./pkg/db/migrations
+
+USER nonroot:nonroot
+EXPOSE 8080
+HEALTHCHECK --interval=30s --timeout=5s --start-period=10s --retries=3 CMD ["/app/healthcheck"]
+ENTRYPOINT ["/app/service"]
+`
+
+const dockerIgnoreContent = `# Generated By API Service Generator
+# pkg/db/migrations is part of the image, keep it in the context
+.git
+app.env
+.apigen
+docker
+docker-compose.yml
+Dockerfile
+.dockerignore
+deploy
+api-service-generator.yaml
+api.http
+coverage.*
+main
+`
+
+const healthcheckContent = `// Generated By API Service Generator
+
+// healthcheck exits with 0 when the service answers on /health. It is the
+// HEALTHCHECK of the container image, which has no shell or curl.
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://127.0.0.1:8080/health")
+	if err != nil {
+		os.Exit(1)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		os.Exit(1)
+	}
+}
+`
+
+// createDockerFiles writes the Dockerfile, its .dockerignore and the healthcheck command of the image
+func createDockerFiles(wrkDir string) error {
+	err := common.CreateFileAndItsContent(wrkDir+"/Dockerfile", nil, dockerfileContent)
+	if err != nil {
+		return err
+	}
+	err = common.CreateFileAndItsContent(wrkDir+"/.dockerignore", nil, dockerIgnoreContent)
+	if err != nil {
+		return err
+	}
+	return common.CreateFileAndItsContent(wrkDir+"/cmd/healthcheck/main.go", nil, healthcheckContent)
+}
+
 func envFIleUpdate(driver string) (string, error) {
 	switch driver {
 	case "postgres":
@@ -7,7 +7,9 @@ import (
 	"github.com/abhijithk1/api-service-generator/db/docker"
 	"github.com/abhijithk1/api-service-generator/db/migrations"
 	"github.com/abhijithk1/api-service-generator/db/query"
+	"github.com/abhijithk1/api-service-generator/db/seeds"
 	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/abhijithk1/api-service-generator/spec"
 )
 
 var (
@@ -15,14 +17,14 @@ var (
 	connectionPath = "/pkg/db/"
 )
 
-func runSQLC(driver, wrkDir string) (err error) {
+func runSQLC(driver, wrkDir string, overrides []models.Override) (err error) {
 	err = initialiseSQLC(wrkDir)
 	if err != nil {
 		return
 	}
 	fmt.Println("\n\n*** Successfully initialised SLQC `postgres_db` ***")
 
-	err = editSQLCYAML(driver, wrkDir)
+	err = editSQLCYAML(driver, wrkDir, overrides)
 	if err != nil {
 		return
 	}
@@ -55,7 +57,7 @@ func generateSQLC(wrkDir string) (err error) {
 	return nil
 }
 
-func editSQLCYAML(driver, wrkDir string) error {
+func editSQLCYAML(driver, wrkDir string, overrides []models.Override) error {
 	sqlcYaml := models.SQLCYAML{
 		Version: "1",
 		Packages: []models.Packages{
@@ -65,6 +67,7 @@ func editSQLCYAML(driver, wrkDir string) error {
 				Schema:        "./pkg/db/migrations",
 				Queries:       "./pkg/db/query/",
 				EmitInterface: false,
+				Overrides:     overrides,
 			},
 		},
 	}
@@ -99,18 +102,36 @@ func editSQLCYAML(driver, wrkDir string) error {
 func Setup(dbInputs models.DBInputs) (err error) {
 	fmt.Println("*** Database setup ***")
 
-	fmt.Println("\n*** Running Postgres in Docker Container ***")
-	err = docker.RunContainer(dbInputs)
-	if err != nil {
-		fmt.Println("\nError : ", err)
-		return
+	if dbInputs.External {
+		fmt.Println("\n*** Using the existing database, no container is started ***")
+	} else {
+		fmt.Println("\n*** Running Postgres in Docker Container ***")
+		err = docker.RunContainer(dbInputs)
+		if err != nil {
+			fmt.Println("\nError : ", err)
+			return
+		}
+
+		fmt.Println("\n\n*** Postgres is Successfully Running in Docker Container `postgres_db` ***")
 	}
 
-	fmt.Println("\n\n*** Postgres is Successfully Running in Docker Container `postgres_db` ***")
-
 	initSchema := models.InitSchema{
-		TableName: dbInputs.TableName,
-		WrkDir:    dbInputs.WrkDir,
+		TableName:      dbInputs.TableName,
+		TableNameTitle: common.ToCamelCase(dbInputs.TableName),
+		WrkDir:         dbInputs.WrkDir,
+		DBMS:           dbInputs.DBMS,
+		Table:          dbInputs.Table,
+		GraphQL:        dbInputs.GraphQL,
+	}
+	for _, table := range dbInputs.Tables {
+		initSchema.Tables = append(initSchema.Tables, models.InitSchema{
+			TableName:      table.Name,
+			TableNameTitle: common.ToCamelCase(table.Name),
+			WrkDir:         dbInputs.WrkDir,
+			DBMS:           dbInputs.DBMS,
+			Table:          table.TableSpec,
+			GraphQL:        dbInputs.GraphQL,
+		})
 	}
 
 	err = migrations.Migration(dbInputs, initSchema)
@@ -121,15 +142,19 @@ func Setup(dbInputs models.DBInputs) (err error) {
 
 	fmt.Println("\n\n*** Successfully Migrated ***")
 
-	err = query.SetTableQuery(initSchema)
-	if err != nil {
-		fmt.Println("Error : ", err)
-		return
+	overrides := []models.Override{}
+	for _, table := range tables(initSchema) {
+		err = query.SetTableQuery(table)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+		overrides = append(overrides, spec.SQLCOverrides(table.TableName, table.Table)...)
 	}
 
 	fmt.Println("\n\n*** Query are successfully written ***")
 
-	err = runSQLC(dbInputs.DBMS, dbInputs.WrkDir)
+	err = runSQLC(dbInputs.DBMS, dbInputs.WrkDir, overrides)
 	if err != nil {
 		fmt.Println("Error : ", err)
 		return
@@ -157,9 +182,32 @@ func Setup(dbInputs models.DBInputs) (err error) {
 		return
 	}
 
+	for _, table := range tables(initSchema) {
+		err = tableTest(table)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+
+		err = seeds.SetSeeds(dbInputs, table)
+		if err != nil {
+			fmt.Println("Error : ", err)
+			return
+		}
+	}
+	fmt.Println("\n\n*** Successfully setup seed fixtures ***")
+
 	return nil
 }
 
+// tables are the tables of a spec with tables, or the table of the spec
+func tables(initSchema models.InitSchema) []models.InitSchema {
+	if initSchema.Tables != nil {
+		return initSchema.Tables
+	}
+	return []models.InitSchema{initSchema}
+}
+
 const connection = `// Generated BY API Service Generator
 
 package db
@@ -210,6 +258,10 @@ import (
 
 var TestQueries *Queries
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestMain(m *testing.M) {
 
 	conn := GetConnection()
@@ -224,6 +276,84 @@ func mainTest(wrkDir string) error {
 	return common.CreateFileAndItsContent(fileName, nil, mainTestContent)
 }
 
+const tableTestContent = `// Generated By API Service Generator
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+{{- range .Table.TestImports}}
+	"{{.}}"
+{{- end}}
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTest{{.TableNameTitle}}(t *testing.T) {{.TableNameTitle}} {
+	ctx := context.Background()
+{{- range .Table.Columns}}{{if .Parent}}
+	parent{{.FieldName}} := createTest{{.Parent}}(t)
+{{- end}}{{end}}
+{{- if .Table.Key.AppGenerated}}
+	id := {{if eq .Table.PrimaryKey "ulid"}}ulid.Make().String(){{else}}uuid.New(){{end}}
+{{- end}}
+{{- if eq .DBMS "mysql"}}
+	{{if .Table.Key.AppGenerated}}_{{else}}result{{end}}, err := TestQueries.Create{{.TableName}}(ctx, {{template "createArgs" .}})
+	require.NoError(t, err)
+{{- if not .Table.Key.AppGenerated}}
+	lastID, err := result.LastInsertId()
+	require.NoError(t, err)
+	id := {{.Table.Key.GoType}}(lastID)
+{{- end}}
+
+	row, err := TestQueries.Get{{.TableName}}(ctx, id)
+{{- else}}
+	row, err := TestQueries.Create{{.TableName}}(ctx, {{template "createArgs" .}})
+{{- end}}
+	require.NoError(t, err)
+	return row
+}
+
+func TestCreate{{.TableNameTitle}}(t *testing.T) {
+	row := createTest{{.TableNameTitle}}(t)
+	require.NotEmpty(t, row.ID)
+}
+
+func TestGet{{.TableNameTitle}}(t *testing.T) {
+	created := createTest{{.TableNameTitle}}(t)
+
+	row, err := TestQueries.Get{{.TableName}}(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, row.ID)
+}
+
+func TestDelete{{.TableNameTitle}}(t *testing.T) {
+	created := createTest{{.TableNameTitle}}(t)
+
+	rows, err := TestQueries.Delete{{.TableName}}(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = TestQueries.Get{{.TableName}}(context.Background(), created.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+{{define "createArgs"}}
+{{- if and (eq (len .Table.Columns) 1) (not .Table.Key.AppGenerated)}}{{(index .Table.Columns 0).Sample}}
+{{- else}}Create{{.TableName}}Params{
+{{- if .Table.Key.AppGenerated}}ID: id, {{end}}
+{{- range $i, $c := .Table.Columns}}{{if $i}}, {{end}}{{$c.FieldName}}: {{$c.Sample}}{{end}}}
+{{- end}}
+{{- end}}
+`
+
+func tableTest(initSchema models.InitSchema) error {
+	fileName := initSchema.WrkDir + connectionPath + initSchema.TableName + "_test.go"
+	return common.CreateFileAndItsContent(fileName, initSchema, tableTestContent)
+}
+
 func sqlcEngine(driver string, sqlc *models.SQLCYAML) {
 	switch driver {
 	case "postgres":
@@ -1,16 +1,72 @@
 package docker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/abhijithk1/api-service-generator/common"
 	"github.com/abhijithk1/api-service-generator/models"
 )
 
 var (
-	PostgresRun = `docker run --name %s -p %d:5432 -e POSTGRES_USER=%s -e POSTGRES_PASSWORD=%s -e POSTGRES_DB=%s -v pgdata:/var/lib/postgresql/data -d postgres`
-	MySqlRun = `docker run --name %s -p %d:3306 -e MYSQL_ROOT_PASSWORD=%s -e MYSQL_USER=%s -e MYSQL_PASSWORD=%s -e MYSQL_DATABASE=%s -v mysql_data:/var/lib/mysql -d mysql`
+	composeFileName = "/docker-compose.yml"
+	initDir         = "/docker/init"
+	ComposeUp       = []string{"compose", "up", "-d", "db"}
+	projectInvalid  = regexp.MustCompile(`[^a-z0-9_-]+`)
+	goDirective     = regexp.MustCompile(`(?m)^go ([0-9]+\.[0-9]+)`)
+	Sleep           = time.Sleep
+	Now             = time.Now
+	PortFree        = portFree
+	ReadFile        = os.ReadFile
+	WriteFile       = os.WriteFile
+)
+
+// Images and container ports of the database services, the images are pinned
+// so a new major version does not change the defaults under a generated service
+var (
+	Images = map[string]string{
+		"postgres": "postgres:16",
+		"mysql":    "mysql:8.4",
+	}
+	// GoImage is the image of the api service when go.mod has no go directive, the default
+	// GO_VERSION of the Dockerfile
+	GoImage        = "golang:1.22"
+	containerPorts = map[string]string{
+		"postgres": "5432/tcp",
+		"mysql":    "3306/tcp",
+	}
+	volumes = map[string]string{
+		"postgres": "pgdata",
+		"mysql":    "mysql_data",
+	}
+	dataDirs = map[string]string{
+		"postgres": "/var/lib/postgresql/data",
+		"mysql":    "/var/lib/mysql",
+	}
+)
+
+// Readiness polling defaults, the pause between checks doubles up to maxWaitInterval
+var (
+	DefaultWaitTimeout  = 60 * time.Second
+	DefaultWaitInterval = 500 * time.Millisecond
+	maxWaitInterval     = 5 * time.Second
+)
+
+// Labels on the containers and volumes of a generated service, cleanup filters on LabelService
+const (
+	LabelGenerator = "api-service-generator"
+	LabelService   = "api-service-generator.service"
 )
 
 type DockerContainer interface {
@@ -21,44 +77,445 @@ var DefaultDockerClient DockerContainer = &DockerClient{}
 
 type DockerClient struct{}
 
-func (d *DockerClient)RunContainer(dbInputs models.DBInputs) error {
-	var runCmd string
+func (d *DockerClient) RunContainer(dbInputs models.DBInputs) error {
+	var content string
 	switch dbInputs.DBMS {
 	case "postgres":
-		runCmd = runPostgresContainer(dbInputs)
+		content = postgresCompose
 	case "mysql":
-		runCmd = runMySqlContainer(dbInputs)
+		content = mysqlCompose
 	default:
 		fmt.Println("Driver not supported")
 		return fmt.Errorf("driver not supported")
 	}
 
-	dockerCMds := strings.Split(runCmd, " ")
-	fmt.Println("\n\nRunning command: ", runCmd)
-	
-	output, err := common.ExecuteCmds(dockerCMds[0], dockerCMds[1:], ".")
+	err := common.CreateFileAndItsContent(dbInputs.WrkDir+composeFileName, composeData(dbInputs), content)
+	if err != nil {
+		return err
+	}
+
+	err = copyInitScripts(dbInputs)
+	if err != nil {
+		fmt.Println("Error : ", err)
+		return err
+	}
+
+	if dbInputs.ReuseContainer {
+		err = DefaultRuntime.Start(dbInputs.ContainerName)
+	} else {
+		err = DefaultRuntime.Up(dbInputs)
+	}
 	if err != nil {
-		if strings.Contains(string(output), `already in use by container`) {
-			fmt.Printf("\nOutput : %s\n", output)
-			return err
+		switch {
+		case errors.Is(err, ErrNameConflict):
+			fmt.Printf("\nA container named %s already exists\n", dbInputs.ContainerName)
+		case errors.Is(err, ErrPortBusy):
+			fmt.Printf("\nPort %d is already in use\n", dbInputs.ContainerPort)
+		case errors.Is(err, ErrImageNotFound):
+			fmt.Printf("\nThe image %s could not be found\n", Image(dbInputs))
 		}
-		fmt.Printf("\nError running command: %s\nOutput: %s\n", err, output)
+		fmt.Printf("\nError starting the database: %s\n", err)
 		return err
 	}
 
-	fmt.Println("\n\nSuccessfully started the PostgreSQL container")
+	fmt.Println("\n\nWaiting for the database to accept connections")
+	err = waitForDB(dbInputs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n\nSuccessfully started the database container")
 	return nil
 }
 
+// waitForDB polls the database inside the container until it answers over TCP.
+// The images answer on the unix socket while they are still initialising.
+func waitForDB(dbInputs models.DBInputs) error {
+	timeout, interval := dbInputs.WaitTimeout, dbInputs.WaitInterval
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	env, readyCmd := readyCheck(dbInputs)
+	deadline := Now().Add(timeout)
+	for {
+		output, err := DefaultRuntime.Exec(dbInputs.ContainerName, env, readyCmd)
+		if err == nil {
+			return nil
+		}
+		if !Now().Before(deadline) {
+			logs, _ := DefaultRuntime.Logs(dbInputs.ContainerName, 50)
+			fmt.Printf("\nLogs of the container %s:\n%s\n", dbInputs.ContainerName, logs)
+			return fmt.Errorf("database in container %s not ready after %s: %s", dbInputs.ContainerName, timeout, strings.TrimSpace(string(output)))
+		}
+		Sleep(interval)
+		interval = min(interval*2, maxWaitInterval)
+	}
+}
+
+// WaitForDB waits until the database in the container accepts connections
+func WaitForDB(dbInputs models.DBInputs) error {
+	return waitForDB(dbInputs)
+}
+
+// Ready reports whether the database in the container accepts connections right now
+func Ready(dbInputs models.DBInputs) bool {
+	env, readyCmd := readyCheck(dbInputs)
+	_, err := DefaultRuntime.Exec(dbInputs.ContainerName, env, readyCmd)
+	return err == nil
+}
+
+// ShellCommand is the env and command of the database client inside the container
+func ShellCommand(dbInputs models.DBInputs) (env, cmd []string) {
+	database := strings.ToLower(dbInputs.DBName)
+	if dbInputs.DBMS == "mysql" {
+		return []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlPassword},
+			[]string{"mysql", "-u", dbInputs.MySQL.MysqlUser, database}
+	}
+	return nil, []string{"psql", "-U", dbInputs.Postgres.PsqlUser, "-d", database}
+}
+
+// readyCheck is the env and command, run in the container, that succeed once the database is ready
+func readyCheck(dbInputs models.DBInputs) (env, cmd []string) {
+	if dbInputs.DBMS == "mysql" {
+		return []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlRootPassword},
+			[]string{"mysqladmin", "ping", "-h", "127.0.0.1", "-u", "root", "--silent"}
+	}
+	return nil, []string{"pg_isready", "-h", "127.0.0.1", "-U", dbInputs.Postgres.PsqlUser, "-d", strings.ToLower(dbInputs.DBName)}
+}
+
+// healthcheck of the database container, the same as in the compose file
+func healthcheck(dbInputs models.DBInputs) []string {
+	if dbInputs.DBMS == "mysql" {
+		return []string{"CMD", "mysqladmin", "ping", "-h", "127.0.0.1", "-u", "root", "-p" + dbInputs.MySQL.MysqlRootPassword}
+	}
+	return []string{"CMD-SHELL", "pg_isready -h 127.0.0.1 -U " + dbInputs.Postgres.PsqlUser + " -d " + strings.ToLower(dbInputs.DBName)}
+}
 
 func RunContainer(dbInputs models.DBInputs) error {
 	return DefaultDockerClient.RunContainer(dbInputs)
 }
 
-func runPostgresContainer(dbInputs models.DBInputs) string {
-	return fmt.Sprintf(PostgresRun, dbInputs.ContainerName, dbInputs.ContainerPort, dbInputs.Postgres.PsqlUser, dbInputs.Postgres.PsqlPassword, strings.ToLower(dbInputs.DBName))
+// InspectContainer looks up an existing container by name
+func InspectContainer(name string) (container models.Container, found bool, err error) {
+	container, err = DefaultRuntime.Inspect(name)
+	if errors.Is(err, ErrNotFound) {
+		return container, false, nil
+	}
+	if err != nil {
+		return container, false, fmt.Errorf("error inspecting container %s: %w", name, err)
+	}
+	return container, true, nil
+}
+
+// Reusable reports whether the container runs the database image with the same credentials
+func Reusable(container models.Container, dbInputs models.DBInputs) bool {
+	if container.Image != Image(dbInputs) || container.Port == 0 {
+		return false
+	}
+
+	env := map[string]bool{}
+	for _, e := range container.Env {
+		env[e] = true
+	}
+	for _, e := range credentials(dbInputs) {
+		if !env[e] {
+			return false
+		}
+	}
+	return true
+}
+
+func credentials(dbInputs models.DBInputs) []string {
+	database := strings.ToLower(dbInputs.DBName)
+	switch dbInputs.DBMS {
+	case "postgres":
+		return []string{
+			"POSTGRES_USER=" + dbInputs.Postgres.PsqlUser,
+			"POSTGRES_PASSWORD=" + dbInputs.Postgres.PsqlPassword,
+			"POSTGRES_DB=" + database,
+		}
+	case "mysql":
+		return []string{
+			"MYSQL_ROOT_PASSWORD=" + dbInputs.MySQL.MysqlRootPassword,
+			"MYSQL_USER=" + dbInputs.MySQL.MysqlUser,
+			"MYSQL_PASSWORD=" + dbInputs.MySQL.MysqlPassword,
+			"MYSQL_DATABASE=" + database,
+		}
+	}
+	return nil
+}
+
+// containerEnv is the environment of the database container, the credentials and the env of the spec
+func containerEnv(dbInputs models.DBInputs) []string {
+	env := credentials(dbInputs)
+	extra := make([]string, 0, len(dbInputs.Table.Database.Env))
+	for name, value := range dbInputs.Table.Database.Env {
+		extra = append(extra, name+"="+value)
+	}
+	sort.Strings(extra)
+	return append(env, extra...)
+}
+
+// Image is the pinned database image, the one of the spec or the default of the driver
+func Image(dbInputs models.DBInputs) string {
+	database := dbInputs.Table.Database
+	if database.Image != "" {
+		return database.Image + ":" + database.Tag
+	}
+	image := Images[dbInputs.DBMS]
+	if database.Tag != "" {
+		name, _ := SplitImage(image)
+		image = name + ":" + database.Tag
+	}
+	return image
+}
+
+// SplitImage splits an image into name and tag, a registry port is part of the name
+func SplitImage(image string) (name, tag string) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, "latest"
+	}
+	return image[:i], image[i+1:]
+}
+
+// InitScripts are the copies of the init scripts, relative to the service
+func InitScripts(dbInputs models.DBInputs) []string {
+	var copies []string
+	for i, script := range dbInputs.Table.Database.InitScripts {
+		copies = append(copies, fmt.Sprintf("%s/%02d_%s", strings.TrimPrefix(initDir, "/"), i+1, filepath.Base(script)))
+	}
+	return copies
 }
 
-func runMySqlContainer(dbInputs models.DBInputs) string {
-	return fmt.Sprintf(MySqlRun, dbInputs.ContainerName, dbInputs.ContainerPort, dbInputs.MySQL.MysqlRootPassword, dbInputs.MySQL.MysqlUser, dbInputs.MySQL.MysqlPassword, strings.ToLower(dbInputs.DBName))
+// copyInitScripts copies the init scripts of the spec into the service, numbered so the
+// image runs them in the order of the spec. They only run when the data volume is empty.
+func copyInitScripts(dbInputs models.DBInputs) error {
+	scripts := dbInputs.Table.Database.InitScripts
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	dir := dbInputs.WrkDir + initDir
+	err := common.CreateDirectory(dir)
+	if err != nil {
+		return err
+	}
+	copies := InitScripts(dbInputs)
+	for i, script := range scripts {
+		content, err := ReadFile(script)
+		if err != nil {
+			return fmt.Errorf("error reading init script %s: %w", script, err)
+		}
+		err = WriteFile(filepath.Join(dbInputs.WrkDir, copies[i]), content, 0644)
+		if err != nil {
+			return fmt.Errorf("error copying init script %s: %w", script, err)
+		}
+	}
+	return nil
 }
+
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// NextFreePort finds a free host port after port, 0 when there is none close by
+func NextFreePort(port int) int {
+	for next := port + 1; next <= port+100 && next <= 65535; next++ {
+		if PortFree(next) {
+			return next
+		}
+	}
+	return 0
+}
+
+// ProjectName is the compose project name of the service in wrkDir, the labels and the volumes
+// are keyed by it. It is the name of the directory and a short hash of its absolute path, so
+// the services with the same name in other directories keep their own.
+func ProjectName(wrkDir string) string {
+	path, err := filepath.Abs(wrkDir)
+	if err != nil {
+		path = wrkDir
+	}
+	sum := sha256.Sum256([]byte(path))
+	return strings.TrimLeft(ServiceName(wrkDir)+"_"+hex.EncodeToString(sum[:4]), "_")
+}
+
+// ServiceName is the name of the directory of the service, in the characters of a project name
+func ServiceName(wrkDir string) string {
+	name := projectInvalid.ReplaceAllString(strings.ToLower(filepath.Base(wrkDir)), "_")
+	return strings.TrimLeft(name, "_-")
+}
+
+func composeData(dbInputs models.DBInputs) models.Compose {
+	compose := models.Compose{
+		DBInputs:    dbInputs,
+		Project:     ProjectName(dbInputs.WrkDir),
+		Database:    strings.ToLower(dbInputs.DBName),
+		Image:       Image(dbInputs),
+		GoImage:     goImage(dbInputs.WrkDir),
+		Env:         map[string]string{},
+		InitScripts: len(dbInputs.Table.Database.InitScripts) > 0,
+	}
+	// quoted, so values like "on" or "1.0" stay strings in YAML
+	for name, value := range dbInputs.Table.Database.Env {
+		compose.Env[name] = strconv.Quote(value)
+	}
+	if args := dbInputs.Table.Database.Args; len(args) > 0 {
+		command, _ := json.Marshal(args)
+		compose.Command = string(command)
+	}
+	return compose
+}
+
+// goImage is the golang image of the toolchain of the go.mod in wrkDir
+func goImage(wrkDir string) string {
+	content, err := ReadFile(wrkDir + "/go.mod")
+	if err != nil {
+		return GoImage
+	}
+	if match := goDirective.FindSubmatch(content); match != nil {
+		return "golang:" + string(match[1])
+	}
+	return GoImage
+}
+
+const postgresCompose = `# Generated By API Service Generator
+# make up starts the database, docker compose --profile api up -d starts the api as well
+name: {{.Project}}
+
+services:
+  db:
+    image: {{.Image}}
+    container_name: {{.ContainerName}}
+    environment:
+      POSTGRES_USER: "{{.Postgres.PsqlUser}}"
+      POSTGRES_PASSWORD: "{{.Postgres.PsqlPassword}}"
+      POSTGRES_DB: "{{.Database}}"
+{{- range $name, $value := .Env}}
+      {{$name}}: {{$value}}
+{{- end}}
+{{- if .Command}}
+    command: {{.Command}}
+{{- end}}
+    ports:
+      - "{{.ContainerPort}}:5432"
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+{{- if .InitScripts}}
+      - ./docker/init:/docker-entrypoint-initdb.d:ro
+{{- end}}
+{{- with .Table.Database.Memory}}
+    mem_limit: {{.}}
+{{- end}}
+{{- with .Table.Database.CPUs}}
+    cpus: {{.}}
+{{- end}}
+    labels:
+      {{template "labels" .}}
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -h 127.0.0.1 -U {{.Postgres.PsqlUser}} -d {{.Database}}"]
+      interval: 2s
+      timeout: 5s
+      retries: 30
+
+  api:
+    image: {{.GoImage}}
+    profiles: ["api"]
+    working_dir: /app
+    command: go run main.go
+    environment:
+      DB_SOURCE: "postgres://{{.Postgres.PsqlUser}}:{{.Postgres.PsqlPassword}}@db:5432/{{.Database}}?sslmode=disable"
+    ports:
+      - "8080:8080"
+    volumes:
+      - .:/app
+    labels:
+      {{template "labels" .}}
+    depends_on:
+      db:
+        condition: service_healthy
+
+volumes:
+  pgdata:
+    name: {{.Project}}_pgdata
+    labels:
+      {{template "labels" .}}
+{{define "labels"}}api-service-generator: "true"
+      api-service-generator.service: "{{.Project}}"{{end}}
+`
+
+const mysqlCompose = `# Generated By API Service Generator
+# make up starts the database, docker compose --profile api up -d starts the api as well
+name: {{.Project}}
+
+services:
+  db:
+    image: {{.Image}}
+    container_name: {{.ContainerName}}
+    environment:
+      MYSQL_ROOT_PASSWORD: "{{.MySQL.MysqlRootPassword}}"
+      MYSQL_USER: "{{.MySQL.MysqlUser}}"
+      MYSQL_PASSWORD: "{{.MySQL.MysqlPassword}}"
+      MYSQL_DATABASE: "{{.Database}}"
+{{- range $name, $value := .Env}}
+      {{$name}}: {{$value}}
+{{- end}}
+{{- if .Command}}
+    command: {{.Command}}
+{{- end}}
+    ports:
+      - "{{.ContainerPort}}:3306"
+    volumes:
+      - mysql_data:/var/lib/mysql
+{{- if .InitScripts}}
+      - ./docker/init:/docker-entrypoint-initdb.d:ro
+{{- end}}
+{{- with .Table.Database.Memory}}
+    mem_limit: {{.}}
+{{- end}}
+{{- with .Table.Database.CPUs}}
+    cpus: {{.}}
+{{- end}}
+    labels:
+      {{template "labels" .}}
+    healthcheck:
+      test: ["CMD", "mysqladmin", "ping", "-h", "127.0.0.1", "-u", "root", "-p{{.MySQL.MysqlRootPassword}}"]
+      interval: 2s
+      timeout: 5s
+      retries: 30
+
+  api:
+    image: {{.GoImage}}
+    profiles: ["api"]
+    working_dir: /app
+    command: go run main.go
+    environment:
+      DB_SOURCE: "{{.MySQL.MysqlUser}}:{{.MySQL.MysqlPassword}}@tcp(db:3306)/{{.Database}}?charset=utf8&parseTime=True&loc=Local&multiStatements=true"
+    ports:
+      - "8080:8080"
+    volumes:
+      - .:/app
+    labels:
+      {{template "labels" .}}
+    depends_on:
+      db:
+        condition: service_healthy
+
+volumes:
+  mysql_data:
+    name: {{.Project}}_mysql_data
+    labels:
+      {{template "labels" .}}
+{{define "labels"}}api-service-generator: "true"
+      api-service-generator.service: "{{.Project}}"{{end}}
+`
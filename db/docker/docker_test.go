@@ -1,46 +1,85 @@
 package docker
 
 import (
+	"bytes"
 	"errors"
-	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/abhijithk1/api-service-generator/common"
 	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/abhijithk1/api-service-generator/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
+// readyArgs is the docker exec command line of the readiness check
+func readyArgs(dbInputs models.DBInputs) []string {
+	env, cmd := readyCheck(dbInputs)
+	cmdArgs := []string{"exec"}
+	for _, e := range env {
+		cmdArgs = append(cmdArgs, "-e", e)
+	}
+	return append(append(cmdArgs, dbInputs.ContainerName), cmd...)
+}
+
 func TestMain(m *testing.M) {
+	Sleep = func(time.Duration) {}
+	PortFree = func(port int) bool { return port%2 == 0 }
 	os.Exit(m.Run())
 }
 
+var postgresInputs = models.DBInputs{
+	DBMS:          "postgres",
+	ContainerName: "postgres_db",
+	ContainerPort: 6432,
+	Postgres: models.PostgresDriver{
+		PsqlUser:     "root",
+		PsqlPassword: "password",
+	},
+	DBName: "Postgres",
+	WrkDir: "wrkdir",
+}
+
 func TestRunContainer_Postgres(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	dbInput := models.DBInputs{
-		DBMS:          "postgres",
-		ContainerName: "postgres_db",
-		ContainerPort: 6432,
-		Postgres: models.PostgresDriver{
-			PsqlUser:      "root",
-			PsqlPassword:  "password",
-		},
-		DBName:        "postgres",
-		WrkDir:        "wrkdir",
-	}
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(postgresInputs), postgresCompose).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", ComposeUp, "wrkdir").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(postgresInputs), ".").Return([]byte("accepting connections"), nil)
 
-	runCmd := fmt.Sprintf(PostgresRun, "postgres_db", 6432, "root", "password", "postgres")
+	err := RunContainer(postgresInputs)
+	assert.NoError(t, err)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
 
-	cmdSplits := strings.Split(runCmd, " ")
+func TestRunContainer_MYSQL(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
 
-	cmdStr := cmdSplits[0]
-	cmdArgs := cmdSplits[1:]
+	dbInput := models.DBInputs{
+		DBMS:          "mysql",
+		ContainerName: "mysql_db",
+		ContainerPort: 3306,
+		MySQL: models.MySQLDriver{
+			MysqlRootPassword: "secret",
+			MysqlUser:         "root",
+			MysqlPassword:     "password",
+		},
+		DBName: "mysql",
+		WrkDir: "wrkdir",
+	}
 
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(dbInput), mysqlCompose).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", ComposeUp, "wrkdir").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(dbInput), ".").Return([]byte("mysqld is alive"), nil)
 
 	err := RunContainer(dbInput)
 	assert.NoError(t, err)
@@ -48,34 +87,29 @@ func TestRunContainer_Postgres(t *testing.T) {
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
-func TestRunContainer_Error(t *testing.T) {
+func TestRunContainer_ComposeFileError(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	dbInput := models.DBInputs{
-		DBMS:          "postgres",
-		ContainerName: "postgres_db",
-		ContainerPort: 6432,
-		Postgres: models.PostgresDriver{		
-			PsqlUser:      "root",
-			PsqlPassword:  "password",
-		},
-		DBName:        "postgres",
-		WrkDir:        "wrkdir",
-	}
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(postgresInputs), postgresCompose).Return(errors.New("error in writing compose file"))
 
-	runCmd := fmt.Sprintf(PostgresRun, "postgres_db", 6432, "root", "password", "postgres")
+	err := RunContainer(postgresInputs)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error in writing compose file")
 
-	cmdSplits := strings.Split(runCmd, " ")
+	mockCmdsExecutor.AssertExpectations(t)
+}
 
-	cmdStr := cmdSplits[0]
-	cmdArgs := cmdSplits[1:]
+func TestRunContainer_Error(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
 
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), errors.New("error in running docker run"))
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(postgresInputs), postgresCompose).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", ComposeUp, "wrkdir").Return([]byte(""), errors.New("error in running docker compose"))
 
-	err := RunContainer(dbInput)
+	err := RunContainer(postgresInputs)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error in running docker run")
+	assert.Contains(t, err.Error(), "error in running docker compose")
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
@@ -84,113 +118,275 @@ func TestRunContainer_SpecialCaseError(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(postgresInputs), postgresCompose).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", ComposeUp, "wrkdir").Return([]byte(`Conflict. The container name "/postgres_db" is already in use by container "a1b2"`), errors.New("exit status 1"))
+
+	err := RunContainer(postgresInputs)
+	assert.ErrorIs(t, err, ErrNameConflict)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestRunContainer_Default(t *testing.T) {
 	dbInput := models.DBInputs{
-		DBMS:          "postgres",
-		ContainerName: "postgres_db",
-		ContainerPort: 6432,
-		Postgres: models.PostgresDriver{		
-			PsqlUser:      "root",
-			PsqlPassword:  "password",
-		},
-		DBName:        "postgres",
-		WrkDir:        "wrkdir",
+		DBMS: "anotherDriver",
 	}
 
-	runCmd := fmt.Sprintf(PostgresRun, "postgres_db", 6432, "root", "password", "postgres")
+	err := RunContainer(dbInput)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "driver not supported")
+}
 
-	cmdSplits := strings.Split(runCmd, " ")
+func TestComposeData(t *testing.T) {
+	data := composeData(postgresInputs)
+	assert.Equal(t, ProjectName("wrkdir"), data.Project)
+	assert.Equal(t, "postgres", data.Database)
+	assert.Equal(t, postgresInputs, data.DBInputs)
+}
 
-	cmdStr := cmdSplits[0]
-	cmdArgs := cmdSplits[1:]
+func TestComposeData_GoImage(t *testing.T) {
+	defer func() { ReadFile = os.ReadFile }()
 
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(`already in use by container`), errors.New("error in running docker run"))
+	// the api service runs on the toolchain of go.mod, the Dockerfile default without one
+	ReadFile = func(string) ([]byte, error) { return []byte("module example\n\ngo 1.23.4\n"), nil }
+	assert.Equal(t, "golang:1.23", composeData(postgresInputs).GoImage)
 
-	err := RunContainer(dbInput)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error in running docker run")
+	ReadFile = func(string) ([]byte, error) { return nil, os.ErrNotExist }
+	data := composeData(postgresInputs)
+	assert.Equal(t, GoImage, data.GoImage)
 
-	mockCmdsExecutor.AssertExpectations(t)
+	for _, content := range []string{postgresCompose, mysqlCompose} {
+		var rendered bytes.Buffer
+		require.NoError(t, template.Must(template.New("compose").Parse(content)).Execute(&rendered, data))
+		assert.Contains(t, rendered.String(), "image: golang:1.22\n")
+		assert.NotContains(t, rendered.String(), "image: golang\n")
+	}
 }
 
-func TestRunPostgresContainer(t *testing.T) {
+var tunedDatabase = models.DatabaseSpec{
+	Tag:         "16.4",
+	Env:         map[string]string{"TZ": "UTC", "PGDATA_CHECKSUMS": "on"},
+	Args:        []string{"-c", "max_connections=200"},
+	Memory:      "512m",
+	MemoryBytes: 512 << 20,
+	CPUs:        1.5,
+	InitScripts: []string{"specs/extensions.sql"},
+}
 
-	dbInput := models.DBInputs{
-		DBMS:          "postgres",
-		ContainerName: "postgres_db",
-		ContainerPort: 6432,
-		Postgres: models.PostgresDriver{	
-			PsqlUser:      "root",
-			PsqlPassword:  "password",
-		},
-		DBName:        "postgres",
-		WrkDir:        "wrkdir",
+func TestComposeData_Database(t *testing.T) {
+	dbInputs := postgresInputs
+	dbInputs.Table.Database = tunedDatabase
+
+	data := composeData(dbInputs)
+	assert.Equal(t, "postgres:16.4", data.Image)
+	assert.Equal(t, map[string]string{"TZ": `"UTC"`, "PGDATA_CHECKSUMS": `"on"`}, data.Env)
+	assert.Equal(t, `["-c","max_connections=200"]`, data.Command)
+	assert.True(t, data.InitScripts)
+
+	var rendered bytes.Buffer
+	require.NoError(t, template.Must(template.New("compose").Parse(postgresCompose)).Execute(&rendered, data))
+	var compose struct {
+		Services struct {
+			DB struct {
+				Image       string
+				Environment map[string]string
+				Command     []string
+				Volumes     []string
+				MemLimit    string  `yaml:"mem_limit"`
+				CPUs        float64 `yaml:"cpus"`
+			}
+		}
 	}
+	require.NoError(t, yaml.Unmarshal(rendered.Bytes(), &compose))
+
+	db := compose.Services.DB
+	assert.Equal(t, "postgres:16.4", db.Image)
+	assert.Equal(t, "on", db.Environment["PGDATA_CHECKSUMS"])
+	assert.Equal(t, "root", db.Environment["POSTGRES_USER"])
+	assert.Equal(t, []string{"-c", "max_connections=200"}, db.Command)
+	assert.Equal(t, []string{"pgdata:/var/lib/postgresql/data", "./docker/init:/docker-entrypoint-initdb.d:ro"}, db.Volumes)
+	assert.Equal(t, "512m", db.MemLimit)
+	assert.Equal(t, 1.5, db.CPUs)
+}
 
-	runCmd := fmt.Sprintf(PostgresRun, "postgres_db", 6432, "root", "password", "postgres")
+func TestImage(t *testing.T) {
+	assert.Equal(t, "postgres:16", Image(postgresInputs))
+	assert.Equal(t, "mysql:8.4", Image(models.DBInputs{DBMS: "mysql"}))
 
-	str := runPostgresContainer(dbInput)
-	assert.Contains(t, runCmd, str)
-} 
+	dbInputs := postgresInputs
+	dbInputs.Table.Database.Tag = "16.4-alpine"
+	assert.Equal(t, "postgres:16.4-alpine", Image(dbInputs))
 
-func TestRunMySQLContainer(t *testing.T) {
+	dbInputs.Table.Database.Image = "registry.local:5000/postgis"
+	assert.Equal(t, "registry.local:5000/postgis:16.4-alpine", Image(dbInputs))
 
-	dbInput := models.DBInputs{
-		DBMS:          "mysql",
-		ContainerName: "mysql_db",
-		ContainerPort: 3306,
-		MySQL: models.MySQLDriver{
-			MysqlRootPassword: "secret",
-			MysqlUser: "root",
-			MysqlPassword: "password",
-		},
-		DBName:        "mysql",
-		WrkDir:        "wrkdir",
-	}
+	name, tag := SplitImage("registry.local:5000/postgis")
+	assert.Equal(t, "registry.local:5000/postgis", name)
+	assert.Equal(t, "latest", tag)
+}
 
-	runCmd := fmt.Sprintf(MySqlRun, "mysql_db", 3306, "secret", "root", "password", "mysql")
+func TestCopyInitScripts(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
 
-	str := runMySqlContainer(dbInput)
-	assert.Contains(t, runCmd, str)
-} 
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "extensions.sql"), []byte("CREATE EXTENSION citext;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "roles.sh"), []byte("#!/bin/sh"), 0644))
+
+	dbInputs := postgresInputs
+	dbInputs.WrkDir = t.TempDir()
+	dbInputs.Table.Database.InitScripts = []string{filepath.Join(source, "roles.sh"), filepath.Join(source, "extensions.sql")}
+	initPath := dbInputs.WrkDir + initDir
+	mockCmdsExecutor.On("CreateDirectory", initPath).Return(nil).Run(func(mock.Arguments) {
+		os.MkdirAll(initPath, 0755)
+	})
+
+	require.NoError(t, copyInitScripts(dbInputs))
+	// numbered in the order of the spec, the image runs them alphabetically
+	content, err := os.ReadFile(filepath.Join(initPath, "01_roles.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh", string(content))
+	content, err = os.ReadFile(filepath.Join(initPath, "02_extensions.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE EXTENSION citext;", string(content))
+
+	dbInputs.Table.Database.InitScripts = []string{filepath.Join(source, "missing.sql")}
+	assert.ErrorContains(t, copyInitScripts(dbInputs), "error reading init script")
 
-func TestRunContainer_MYSQL(t *testing.T) {
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestServiceName(t *testing.T) {
+	assert.Equal(t, "my_service", ServiceName("My Service"))
+	assert.Equal(t, "api", ServiceName("../services/api"))
+	assert.Equal(t, "svc-1", ServiceName("_svc-1"))
+}
+
+func TestProjectName(t *testing.T) {
+	// the services named api in two directories do not share their labels and volumes
+	project := ProjectName("../services/api")
+	assert.Regexp(t, `^api_[0-9a-f]{8}$`, project)
+	assert.NotEqual(t, project, ProjectName("../other/api"))
+
+	abs, err := filepath.Abs("../services/api")
+	require.NoError(t, err)
+	assert.Equal(t, project, ProjectName(abs))
+}
+
+func TestReadyCheck(t *testing.T) {
+	env, cmd := readyCheck(postgresInputs)
+	assert.Nil(t, env)
+	assert.Equal(t, []string{"pg_isready", "-h", "127.0.0.1", "-U", "root", "-d", "postgres"}, cmd)
+
+	mysqlInputs := models.DBInputs{DBMS: "mysql", ContainerName: "mysql_db", MySQL: models.MySQLDriver{MysqlRootPassword: "secret"}}
+	assert.Equal(t, []string{"exec", "-e", "MYSQL_PWD=secret", "mysql_db", "mysqladmin", "ping", "-h", "127.0.0.1", "-u", "root", "--silent"}, readyArgs(mysqlInputs))
+}
+
+func TestWaitForDB_Retries(t *testing.T) {
 	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
 	common.DefaultExecutor = mockCmdsExecutor
 
-	dbInput := models.DBInputs{
-		DBMS:          "mysql",
-		ContainerName: "mysql_db",
-		ContainerPort: 3306,
-		MySQL: models.MySQLDriver{
-			MysqlRootPassword: "secret",
-			MysqlUser: "root",
-			MysqlPassword: "password",
-		},
-		DBName:        "mysql",
-		WrkDir:        "wrkdir",
-	}
+	var pauses []time.Duration
+	Sleep = func(d time.Duration) { pauses = append(pauses, d) }
+	defer func() { Sleep = func(time.Duration) {} }()
 
-	runCmd := fmt.Sprintf(MySqlRun, "mysql_db", 3306, "secret", "root", "password", "mysql")
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(postgresInputs), ".").Return([]byte("no response"), errors.New("exit status 2")).Times(4)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(postgresInputs), ".").Return([]byte("accepting connections"), nil).Once()
 
-	cmdSplits := strings.Split(runCmd, " ")
+	dbInputs := postgresInputs
+	dbInputs.WaitInterval = 2 * time.Second
+	err := waitForDB(dbInputs)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{2 * time.Second, 4 * time.Second, maxWaitInterval, maxWaitInterval}, pauses)
 
-	cmdStr := cmdSplits[0]
-	cmdArgs := cmdSplits[1:]
+	mockCmdsExecutor.AssertExpectations(t)
+}
 
-	mockCmdsExecutor.On("ExecuteCmds", cmdStr, cmdArgs, ".").Return([]byte(""), nil)
+func TestWaitForDB_Timeout(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
 
-	err := RunContainer(dbInput)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return clock }
+	Sleep = func(d time.Duration) { clock = clock.Add(d) }
+	defer func() {
+		Now = time.Now
+		Sleep = func(time.Duration) {}
+	}()
+
+	logsCmd := []string{"logs", "--tail", "50", "postgres_db"}
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(postgresInputs), ".").Return([]byte("no response"), errors.New("exit status 2"))
+	mockCmdsExecutor.On("ExecuteCmds", "docker", logsCmd, ".").Return([]byte("FATAL: data directory has wrong ownership"), nil)
+
+	dbInputs := postgresInputs
+	dbInputs.WaitTimeout = 3 * time.Second
+	err := waitForDB(dbInputs)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not ready after 3s: no response")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestRunContainer_Reuse(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	dbInputs := postgresInputs
+	dbInputs.ReuseContainer = true
+	mockCmdsExecutor.On("CreateFileAndItsContent", "wrkdir/docker-compose.yml", composeData(dbInputs), postgresCompose).Return(nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"start", "postgres_db"}, ".").Return([]byte("postgres_db"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", readyArgs(dbInputs), ".").Return([]byte("accepting connections"), nil)
+
+	err := RunContainer(dbInputs)
 	assert.NoError(t, err)
 
 	mockCmdsExecutor.AssertExpectations(t)
 }
 
-func TestRunContainer_Default(t *testing.T) {
-	dbInput := models.DBInputs{
-		DBMS:          "anotherDriver",
+func TestInspectContainer(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	inspect := func(name string) []string { return []string{"inspect", "--type", "container", name} }
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("mysql_db"), ".").Return([]byte(`[{"Config": {"Image": "mysql:8", "Env": ["MYSQL_USER=root"]},
+		"State": {"Running": false}, "HostConfig": {"PortBindings": {"3306/tcp": [{"HostIp": "", "HostPort": "3307"}]}}}]`), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("missing"), ".").Return([]byte("Error: No such container: missing"), errors.New("exit status 1"))
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspect("broken"), ".").Return([]byte("Cannot connect to the Docker daemon"), errors.New("exit status 1"))
+
+	container, found, err := InspectContainer("mysql_db")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, models.Container{Image: "mysql:8", Env: []string{"MYSQL_USER=root"}, Port: 3307}, container)
+
+	_, found, err = InspectContainer("missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	_, _, err = InspectContainer("broken")
+	assert.ErrorContains(t, err, "Cannot connect to the Docker daemon")
+}
+
+func TestReusable(t *testing.T) {
+	container := models.Container{
+		Image: "postgres:16",
+		Env:   []string{"PATH=/usr/bin", "POSTGRES_USER=root", "POSTGRES_PASSWORD=password", "POSTGRES_DB=postgres"},
+		Port:  6432,
 	}
+	assert.True(t, Reusable(container, postgresInputs))
 
-	err := RunContainer(dbInput)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "driver not supported")
+	other := postgresInputs
+	other.Postgres.PsqlPassword = "secret"
+	assert.False(t, Reusable(container, other))
+
+	container.Image = "mysql"
+	assert.False(t, Reusable(container, postgresInputs))
+}
+
+func TestNextFreePort(t *testing.T) {
+	assert.Equal(t, 6434, NextFreePort(6433))
+	assert.Equal(t, 6434, NextFreePort(6432))
+
+	PortFree = func(int) bool { return false }
+	defer func() { PortFree = func(port int) bool { return port%2 == 0 } }()
+	assert.Equal(t, 0, NextFreePort(6432))
 }
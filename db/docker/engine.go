@@ -0,0 +1,431 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+// engineRuntime talks to the Docker Engine API, or Podman's compatible API, over a unix socket.
+// The CLI of the engine is only needed for an interactive shell.
+type engineRuntime struct {
+	socket string
+	cli    string
+	client *http.Client
+}
+
+// hostEnv points the CLI of an engine to the socket
+var hostEnv = map[string]string{
+	"docker": "DOCKER_HOST",
+	"podman": "CONTAINER_HOST",
+}
+
+func newEngineRuntime(socket, cli string) *engineRuntime {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	return &engineRuntime{
+		socket: socket,
+		cli:    cli,
+		client: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}},
+	}
+}
+
+func dockerSocket() string {
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		return strings.TrimPrefix(host, "unix://")
+	}
+	return "/var/run/docker.sock"
+}
+
+func podmanSocket() string {
+	if host := os.Getenv("CONTAINER_HOST"); strings.HasPrefix(host, "unix://") {
+		return strings.TrimPrefix(host, "unix://")
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		socket := filepath.Join(dir, "podman", "podman.sock")
+		if _, err := os.Stat(socket); err == nil {
+			return socket
+		}
+	}
+	return "/run/podman/podman.sock"
+}
+
+// engineError is an error response of the API
+type engineError struct {
+	Status  int
+	Message string `json:"message"`
+}
+
+func (e *engineError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.Status, e.Message)
+}
+
+// do sends the request and returns the body of a successful response.
+// A body that is an io.Reader is sent as a tar archive, any other as JSON.
+func (r *engineRuntime) do(method, path string, query url.Values, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	contentType := "application/json"
+	switch body := body.(type) {
+	case nil:
+	case io.Reader:
+		reader, contentType = body, "application/x-tar"
+	default:
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	target := "http://engine" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", r.socket, err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &engineError{Status: resp.StatusCode}
+		if json.Unmarshal(content, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(content))
+		}
+		return nil, apiErr
+	}
+	return content, nil
+}
+
+// classify turns an API error into one of the runtime errors
+func classify(err error, notFound error) error {
+	apiErr, ok := err.(*engineError)
+	if !ok {
+		return err
+	}
+	lower := strings.ToLower(apiErr.Message)
+	switch {
+	case apiErr.Status == http.StatusNotFound && notFound != nil:
+		return fmt.Errorf("%w: %s", notFound, apiErr.Message)
+	case apiErr.Status == http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrNameConflict, apiErr.Message)
+	case strings.Contains(lower, "port is already allocated"), strings.Contains(lower, "address already in use"):
+		return fmt.Errorf("%w: %s", ErrPortBusy, apiErr.Message)
+	}
+	return err
+}
+
+func (r *engineRuntime) Up(dbInputs models.DBInputs) error {
+	project := ProjectName(dbInputs.WrkDir)
+	image := Image(dbInputs)
+
+	fmt.Printf("\n\nStarting %s through the API at %s\n", image, r.socket)
+	if _, err := r.do(http.MethodGet, "/images/"+image+"/json", nil, nil); err != nil {
+		if err = r.pull(image); err != nil {
+			return err
+		}
+	}
+
+	volume := project + "_" + volumes[dbInputs.DBMS]
+	_, err := r.do(http.MethodPost, "/volumes/create", nil, map[string]interface{}{
+		"Name":   volume,
+		"Labels": engineLabels(project, map[string]string{"com.docker.compose.volume": volumes[dbInputs.DBMS]}),
+	})
+	if err != nil {
+		return err
+	}
+
+	binds := []string{volume + ":" + dataDirs[dbInputs.DBMS]}
+	if len(dbInputs.Table.Database.InitScripts) > 0 {
+		dir, err := filepath.Abs(dbInputs.WrkDir + initDir)
+		if err != nil {
+			return err
+		}
+		binds = append(binds, dir+":/docker-entrypoint-initdb.d:ro")
+	}
+
+	port := containerPorts[dbInputs.DBMS]
+	database := dbInputs.Table.Database
+	config := map[string]interface{}{
+		"Image":        image,
+		"Env":          containerEnv(dbInputs),
+		"Labels":       engineLabels(project, map[string]string{"com.docker.compose.service": "db"}),
+		"ExposedPorts": map[string]struct{}{port: {}},
+		"Healthcheck": map[string]interface{}{
+			"Test":     healthcheck(dbInputs),
+			"Interval": 2 * time.Second,
+			"Timeout":  5 * time.Second,
+			"Retries":  30,
+		},
+		"HostConfig": map[string]interface{}{
+			"PortBindings": map[string][]map[string]string{port: {{"HostPort": fmt.Sprint(dbInputs.ContainerPort)}}},
+			"Binds":        binds,
+			"Memory":       database.MemoryBytes,
+			"NanoCpus":     int64(database.CPUs * 1e9),
+		},
+	}
+	if len(database.Args) > 0 {
+		config["Cmd"] = database.Args
+	}
+	_, err = r.do(http.MethodPost, "/containers/create", url.Values{"name": {dbInputs.ContainerName}}, config)
+	if err != nil {
+		return classify(err, ErrImageNotFound)
+	}
+
+	return r.Start(dbInputs.ContainerName)
+}
+
+// pull downloads the image, the API reports failures inside the progress stream
+func (r *engineRuntime) pull(image string) error {
+	name, tag := SplitImage(image)
+	content, err := r.do(http.MethodPost, "/images/create", url.Values{"fromImage": {name}, "tag": {tag}}, nil)
+	if err != nil {
+		return classify(err, ErrImageNotFound)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var progress struct {
+			Error string `json:"error"`
+		}
+		if decoder.Decode(&progress) != nil {
+			break
+		}
+		if progress.Error != "" {
+			lower := strings.ToLower(progress.Error)
+			if strings.Contains(lower, "not found") || strings.Contains(lower, "manifest unknown") || strings.Contains(lower, "pull access denied") {
+				return fmt.Errorf("%w: %s", ErrImageNotFound, progress.Error)
+			}
+			return fmt.Errorf("error pulling %s: %s", image, progress.Error)
+		}
+	}
+	return nil
+}
+
+func (r *engineRuntime) Start(name string) error {
+	_, err := r.do(http.MethodPost, "/containers/"+url.PathEscape(name)+"/start", nil, nil)
+	return classify(err, ErrNotFound)
+}
+
+func (r *engineRuntime) Stop(name string) error {
+	_, err := r.do(http.MethodPost, "/containers/"+url.PathEscape(name)+"/stop", nil, nil)
+	return classify(err, ErrNotFound)
+}
+
+func (r *engineRuntime) Inspect(name string) (models.Container, error) {
+	content, err := r.do(http.MethodGet, "/containers/"+url.PathEscape(name)+"/json", nil, nil)
+	if err != nil {
+		return models.Container{}, classify(err, ErrNotFound)
+	}
+
+	var inspected inspectResponse
+	if err = json.Unmarshal(content, &inspected); err != nil {
+		return models.Container{}, fmt.Errorf("error reading inspect response for %s", name)
+	}
+	return inspected.container(), nil
+}
+
+func (r *engineRuntime) Exec(name string, env, cmd []string) ([]byte, error) {
+	content, err := r.do(http.MethodPost, "/containers/"+url.PathEscape(name)+"/exec", nil, map[string]interface{}{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Env":          env,
+		"Cmd":          cmd,
+	})
+	if err != nil {
+		return nil, classify(err, ErrNotFound)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err = json.Unmarshal(content, &created); err != nil {
+		return nil, err
+	}
+
+	content, err = r.do(http.MethodPost, "/exec/"+created.ID+"/start", nil, map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return nil, err
+	}
+	output := demux(content)
+
+	content, err = r.do(http.MethodGet, "/exec/"+created.ID+"/json", nil, nil)
+	if err != nil {
+		return output, err
+	}
+	var result struct {
+		ExitCode int
+	}
+	if err = json.Unmarshal(content, &result); err != nil {
+		return output, err
+	}
+	if result.ExitCode != 0 {
+		return output, fmt.Errorf("exit status %d", result.ExitCode)
+	}
+	return output, nil
+}
+
+// CopyFrom writes the first file of the archive of src in the container to dst
+func (r *engineRuntime) CopyFrom(name, src, dst string) error {
+	content, err := r.do(http.MethodGet, "/containers/"+url.PathEscape(name)+"/archive", url.Values{"path": {src}}, nil)
+	if err != nil {
+		return classify(err, ErrNotFound)
+	}
+
+	archive := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return fmt.Errorf("no file %s in container %s", src, name)
+		}
+		if err != nil {
+			return fmt.Errorf("error reading the archive of %s: %w", src, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			file, err := io.ReadAll(archive)
+			if err != nil {
+				return err
+			}
+			return WriteFile(dst, file, 0600)
+		}
+	}
+}
+
+// CopyTo puts src in a tar archive and extracts it into the directory of dst in the container
+func (r *engineRuntime) CopyTo(name, src, dst string) error {
+	file, err := ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	err = writer.WriteHeader(&tar.Header{Name: path.Base(dst), Mode: 0644, Size: int64(len(file)), ModTime: Now()})
+	if err == nil {
+		_, err = writer.Write(file)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.do(http.MethodPut, "/containers/"+url.PathEscape(name)+"/archive", url.Values{"path": {path.Dir(dst)}}, &archive)
+	return classify(err, ErrNotFound)
+}
+
+// Shell hands the terminal to the CLI, attaching it through the API needs a hijacked connection
+func (r *engineRuntime) Shell(name string, env, cmd []string) error {
+	return Interactive(r.cli, execArgs(name, env, cmd, true), []string{hostEnv[r.cli] + "=unix://" + r.socket})
+}
+
+func (r *engineRuntime) Logs(name string, tail int) ([]byte, error) {
+	content, err := r.do(http.MethodGet, "/containers/"+url.PathEscape(name)+"/logs",
+		url.Values{"stdout": {"1"}, "stderr": {"1"}, "tail": {fmt.Sprint(tail)}}, nil)
+	if err != nil {
+		return nil, classify(err, ErrNotFound)
+	}
+	return demux(content), nil
+}
+
+func (r *engineRuntime) RemoveService(project string) error {
+	filters, _ := json.Marshal(map[string][]string{"label": {LabelService + "=" + project}})
+	query := url.Values{"filters": {string(filters)}}
+
+	content, err := r.do(http.MethodGet, "/containers/json", url.Values{"all": {"1"}, "filters": query["filters"]}, nil)
+	if err != nil {
+		return err
+	}
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err = json.Unmarshal(content, &containers); err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if _, err = r.do(http.MethodDelete, "/containers/"+container.ID, url.Values{"force": {"1"}}, nil); err != nil {
+			return err
+		}
+		fmt.Printf("\n\nSuccessfully removed the container: %s\n", container.ID)
+	}
+
+	content, err = r.do(http.MethodGet, "/volumes", query, nil)
+	if err != nil {
+		return err
+	}
+	var listed struct {
+		Volumes []struct {
+			Name string
+		}
+	}
+	if err = json.Unmarshal(content, &listed); err != nil {
+		return err
+	}
+	for _, volume := range listed.Volumes {
+		if _, err = r.do(http.MethodDelete, "/volumes/"+url.PathEscape(volume.Name), nil, nil); err != nil {
+			return err
+		}
+		fmt.Printf("\n\nSuccessfully removed the volume: %s\n", volume.Name)
+	}
+	return nil
+}
+
+// engineLabels are the labels compose would set, so `make up` adopts what the API created
+func engineLabels(project string, extra map[string]string) map[string]string {
+	labels := map[string]string{
+		LabelGenerator:               "true",
+		LabelService:                 project,
+		"com.docker.compose.project": project,
+	}
+	for key, value := range extra {
+		labels[key] = value
+	}
+	return labels
+}
+
+// demux strips the stream headers the API puts in front of every chunk of output
+// when the container has no TTY
+func demux(content []byte) []byte {
+	var output bytes.Buffer
+	for len(content) >= 8 {
+		size := int(binary.BigEndian.Uint32(content[4:8]))
+		if content[0] > 2 || content[1] != 0 || content[2] != 0 || content[3] != 0 || size > len(content)-8 {
+			break
+		}
+		output.Write(content[8 : 8+size])
+		content = content[8+size:]
+	}
+	if output.Len() == 0 {
+		return content
+	}
+	return append(output.Bytes(), content...)
+}
@@ -0,0 +1,305 @@
+package docker
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEngine serves the handler on a unix socket and returns a runtime talking to it
+func fakeEngine(t *testing.T, handler http.HandlerFunc) *engineRuntime {
+	socket := filepath.Join(t.TempDir(), "engine.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return newEngineRuntime(socket, "docker")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// frame is one chunk of multiplexed output
+func frame(stream byte, content string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(content)))
+	return append(header, content...)
+}
+
+func TestEngineRuntime_Up(t *testing.T) {
+	var calls []string
+	var created map[string]interface{}
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/images/postgres:16/json":
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such image: postgres:latest"})
+		case "/images/create":
+			assert.Equal(t, "postgres", r.URL.Query().Get("fromImage"))
+			io.WriteString(w, `{"status":"Pulling from library/postgres"}`+"\n"+`{"status":"Downloaded newer image"}`)
+		case "/volumes/create":
+			writeJSON(w, http.StatusCreated, map[string]string{"Name": ProjectName("wrkdir") + "_pgdata"})
+		case "/containers/create":
+			assert.Equal(t, "postgres_db", r.URL.Query().Get("name"))
+			json.NewDecoder(r.Body).Decode(&created)
+			writeJSON(w, http.StatusCreated, map[string]string{"Id": "a1b2"})
+		case "/containers/postgres_db/start":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	require.NoError(t, runtime.Up(postgresInputs))
+	assert.Equal(t, []string{
+		"GET /images/postgres:16/json",
+		"POST /images/create",
+		"POST /volumes/create",
+		"POST /containers/create",
+		"POST /containers/postgres_db/start",
+	}, calls)
+
+	assert.Equal(t, "postgres:16", created["Image"])
+	assert.Contains(t, created["Env"], "POSTGRES_PASSWORD=password")
+	assert.Equal(t, ProjectName("wrkdir"), created["Labels"].(map[string]interface{})[LabelService])
+	hostConfig := created["HostConfig"].(map[string]interface{})
+	assert.Equal(t, []interface{}{ProjectName("wrkdir") + "_pgdata:/var/lib/postgresql/data"}, hostConfig["Binds"])
+	assert.Equal(t, "6432", hostConfig["PortBindings"].(map[string]interface{})["5432/tcp"].([]interface{})[0].(map[string]interface{})["HostPort"])
+}
+
+func TestEngineRuntime_UpDatabase(t *testing.T) {
+	var created struct {
+		Image      string
+		Env        []string
+		Cmd        []string
+		HostConfig struct {
+			Binds    []string
+			Memory   int64
+			NanoCpus int64
+		}
+	}
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/create" {
+			json.NewDecoder(r.Body).Decode(&created)
+		}
+		writeJSON(w, http.StatusOK, map[string]string{})
+	})
+
+	dbInputs := postgresInputs
+	dbInputs.Table.Database = tunedDatabase
+	require.NoError(t, runtime.Up(dbInputs))
+
+	initPath, _ := filepath.Abs("wrkdir/docker/init")
+	assert.Equal(t, "postgres:16.4", created.Image)
+	assert.Equal(t, []string{"POSTGRES_USER=root", "POSTGRES_PASSWORD=password", "POSTGRES_DB=postgres", "PGDATA_CHECKSUMS=on", "TZ=UTC"}, created.Env)
+	assert.Equal(t, []string{"-c", "max_connections=200"}, created.Cmd)
+	assert.Equal(t, []string{ProjectName("wrkdir") + "_pgdata:/var/lib/postgresql/data", initPath + ":/docker-entrypoint-initdb.d:ro"}, created.HostConfig.Binds)
+	assert.Equal(t, int64(512<<20), created.HostConfig.Memory)
+	assert.Equal(t, int64(1.5e9), created.HostConfig.NanoCpus)
+}
+
+func TestEngineRuntime_UpErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected error
+	}{
+		{"image not found", func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/images/postgres:16/json":
+				writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such image"})
+			default:
+				io.WriteString(w, `{"error":"manifest unknown: manifest unknown"}`)
+			}
+		}, ErrImageNotFound},
+		{"name conflict", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/containers/create" {
+				writeJSON(w, http.StatusConflict, map[string]string{"message": `Conflict. The container name "/postgres_db" is already in use`})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{})
+		}, ErrNameConflict},
+		{"port busy", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/containers/postgres_db/start" {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"message": "Bind for 0.0.0.0:6432 failed: port is already allocated"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{})
+		}, ErrPortBusy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := fakeEngine(t, tt.handler)
+			assert.ErrorIs(t, runtime.Up(postgresInputs), tt.expected)
+		})
+	}
+}
+
+func TestEngineRuntime_Inspect(t *testing.T) {
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/postgres_db/json" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such container"})
+			return
+		}
+		io.WriteString(w, `{"Config": {"Image": "postgres", "Env": ["POSTGRES_USER=root"]}, "State": {"Running": true},
+			"HostConfig": {"PortBindings": {"5432/tcp": [{"HostIp": "", "HostPort": "6432"}]}}}`)
+	})
+
+	container, err := runtime.Inspect("postgres_db")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", container.Image)
+	assert.Equal(t, 6432, container.Port)
+	assert.True(t, container.Running)
+
+	_, err = runtime.Inspect("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestEngineRuntime_Exec(t *testing.T) {
+	exitCode := 0
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/postgres_db/exec":
+			var body struct{ Cmd, Env []string }
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, []string{"pg_isready"}, body.Cmd)
+			writeJSON(w, http.StatusCreated, map[string]string{"Id": "e1"})
+		case "/exec/e1/start":
+			w.Write(append(frame(1, "accepting "), frame(2, "connections")...))
+		case "/exec/e1/json":
+			writeJSON(w, http.StatusOK, map[string]int{"ExitCode": exitCode})
+		}
+	})
+
+	output, err := runtime.Exec("postgres_db", nil, []string{"pg_isready"})
+	require.NoError(t, err)
+	assert.Equal(t, "accepting connections", string(output))
+
+	exitCode = 2
+	_, err = runtime.Exec("postgres_db", nil, []string{"pg_isready"})
+	assert.EqualError(t, err, "exit status 2")
+}
+
+func TestEngineRuntime_Logs(t *testing.T) {
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/containers/postgres_db/logs", r.URL.Path)
+		assert.Equal(t, "50", r.URL.Query().Get("tail"))
+		w.Write(frame(2, "FATAL: password authentication failed"))
+	})
+
+	logs, err := runtime.Logs("postgres_db", 50)
+	require.NoError(t, err)
+	assert.Equal(t, "FATAL: password authentication failed", string(logs))
+}
+
+func TestEngineRuntime_RemoveService(t *testing.T) {
+	var removed []string
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/containers/json":
+			assert.Equal(t, `{"label":["api-service-generator.service=wrkdir"]}`, r.URL.Query().Get("filters"))
+			writeJSON(w, http.StatusOK, []map[string]string{{"Id": "a1b2"}})
+		case r.Method == http.MethodGet && r.URL.Path == "/volumes":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"Volumes": []map[string]string{{"Name": "wrkdir_pgdata"}}})
+		case r.Method == http.MethodDelete:
+			removed = append(removed, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	require.NoError(t, runtime.RemoveService("wrkdir"))
+	assert.Equal(t, []string{"/containers/a1b2", "/volumes/wrkdir_pgdata"}, removed)
+}
+
+func TestEngineRuntime_Unreachable(t *testing.T) {
+	runtime := newEngineRuntime(filepath.Join(t.TempDir(), "missing.sock"), "docker")
+	err := runtime.Start("postgres_db")
+	assert.ErrorContains(t, err, "error connecting to")
+}
+
+func TestDemux(t *testing.T) {
+	assert.Equal(t, "out err", string(demux(append(frame(1, "out "), frame(2, "err")...))))
+	// output of a container with a TTY is not multiplexed
+	assert.Equal(t, "plain output", string(demux([]byte("plain output"))))
+}
+
+func TestEngineRuntime_StopAndShell(t *testing.T) {
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/postgres_db/stop":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such container"})
+		}
+	})
+	assert.NoError(t, runtime.Stop("postgres_db"))
+	assert.ErrorIs(t, runtime.Stop("missing"), ErrNotFound)
+
+	interactive := Interactive
+	defer func() { Interactive = interactive }()
+	var shell, shellEnv []string
+	Interactive = func(cmdStr string, cmdArgs, env []string) error {
+		shell, shellEnv = append([]string{cmdStr}, cmdArgs...), env
+		return nil
+	}
+
+	// the terminal is handed to the CLI, pointed at the same socket
+	require.NoError(t, runtime.Shell("postgres_db", []string{"A=1"}, []string{"psql"}))
+	assert.Equal(t, []string{"docker", "exec", "-it", "-e", "A=1", "postgres_db", "psql"}, shell)
+	assert.Equal(t, []string{"DOCKER_HOST=unix://" + runtime.socket}, shellEnv)
+}
+
+func TestEngineRuntime_Copy(t *testing.T) {
+	var uploaded, uploadedPath string
+	runtime := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /containers/postgres_db/archive":
+			assert.Equal(t, "/tmp/snap.dump", r.URL.Query().Get("path"))
+			archive := tar.NewWriter(w)
+			archive.WriteHeader(&tar.Header{Name: "snap.dump", Mode: 0644, Size: 4})
+			io.WriteString(archive, "PGDM")
+			archive.Close()
+		case "PUT /containers/postgres_db/archive":
+			assert.Equal(t, "application/x-tar", r.Header.Get("Content-Type"))
+			uploadedPath = r.URL.Query().Get("path")
+			archive := tar.NewReader(r.Body)
+			header, err := archive.Next()
+			require.NoError(t, err)
+			content, _ := io.ReadAll(archive)
+			uploaded = header.Name + ":" + string(content)
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "No such container"})
+		}
+	})
+
+	dst := filepath.Join(t.TempDir(), "snap.dump")
+	require.NoError(t, runtime.CopyFrom("postgres_db", "/tmp/snap.dump", dst))
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "PGDM", string(content))
+
+	require.NoError(t, runtime.CopyTo("postgres_db", dst, "/tmp/restore.dump"))
+	assert.Equal(t, "/tmp", uploadedPath)
+	assert.Equal(t, "restore.dump:PGDM", uploaded)
+
+	assert.ErrorIs(t, runtime.CopyFrom("missing", "/tmp/snap.dump", dst), ErrNotFound)
+}
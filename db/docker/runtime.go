@@ -0,0 +1,225 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+// Runtime is the container engine the database container runs on
+type Runtime interface {
+	// Up creates and starts the db service described by the compose file of the service
+	Up(dbInputs models.DBInputs) error
+	Start(name string) error
+	Stop(name string) error
+	Inspect(name string) (models.Container, error)
+	Exec(name string, env, cmd []string) ([]byte, error)
+	// Shell runs cmd in the container attached to the terminal
+	Shell(name string, env, cmd []string) error
+	Logs(name string, tail int) ([]byte, error)
+	// CopyFrom copies the file src in the container to dst on the host
+	CopyFrom(name, src, dst string) error
+	// CopyTo copies the file src on the host to dst in the container
+	CopyTo(name, src, dst string) error
+	// RemoveService removes the containers and volumes labelled with the service
+	RemoveService(project string) error
+}
+
+// Errors of the runtimes, match them with errors.Is
+var (
+	ErrNotFound      = errors.New("container not found")
+	ErrImageNotFound = errors.New("image not found")
+	ErrPortBusy      = errors.New("port already in use")
+	ErrNameConflict  = errors.New("container name already in use")
+)
+
+// Runtimes that can be chosen with --runtime
+var Runtimes = []string{"docker", "docker-api", "podman", "podman-api"}
+
+var DefaultRuntime Runtime = &cliRuntime{binary: "docker"}
+
+// Interactive runs a command attached to the terminal, with env added to the environment
+var Interactive = func(cmdStr string, cmdArgs, env []string) error {
+	cmd := exec.Command(cmdStr, cmdArgs...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// NewRuntime returns the runtime with the given name
+func NewRuntime(name string) (Runtime, error) {
+	switch name {
+	case "", "docker":
+		return &cliRuntime{binary: "docker"}, nil
+	case "podman":
+		return &cliRuntime{binary: "podman"}, nil
+	case "docker-api":
+		return newEngineRuntime(dockerSocket(), "docker"), nil
+	case "podman-api":
+		return newEngineRuntime(podmanSocket(), "podman"), nil
+	}
+	return nil, fmt.Errorf("runtime %q not supported, use one of %s", name, strings.Join(Runtimes, ", "))
+}
+
+// cliRuntime drives the docker or podman binary
+type cliRuntime struct {
+	binary string
+}
+
+func (r *cliRuntime) run(cmdArgs []string, dir string) ([]byte, error) {
+	output, err := common.ExecuteCmds(r.binary, cmdArgs, dir)
+	if err != nil {
+		return output, cliError(output, err)
+	}
+	return output, nil
+}
+
+func (r *cliRuntime) Up(dbInputs models.DBInputs) error {
+	fmt.Printf("\n\nRunning command: %s %s\n", r.binary, strings.Join(ComposeUp, " "))
+	_, err := r.run(ComposeUp, dbInputs.WrkDir)
+	return err
+}
+
+func (r *cliRuntime) Start(name string) error {
+	_, err := r.run([]string{"start", name}, ".")
+	return err
+}
+
+func (r *cliRuntime) Inspect(name string) (models.Container, error) {
+	output, err := r.run([]string{"inspect", "--type", "container", name}, ".")
+	if err != nil {
+		return models.Container{}, err
+	}
+
+	var inspected []inspectResponse
+	if err = json.Unmarshal(output, &inspected); err != nil || len(inspected) == 0 {
+		return models.Container{}, fmt.Errorf("error reading %s inspect output for %s", r.binary, name)
+	}
+	return inspected[0].container(), nil
+}
+
+func (r *cliRuntime) Stop(name string) error {
+	_, err := r.run([]string{"stop", name}, ".")
+	return err
+}
+
+func (r *cliRuntime) Exec(name string, env, cmd []string) ([]byte, error) {
+	return r.run(execArgs(name, env, cmd, false), ".")
+}
+
+func (r *cliRuntime) Shell(name string, env, cmd []string) error {
+	return Interactive(r.binary, execArgs(name, env, cmd, true), nil)
+}
+
+// execArgs is the command line of an exec in the container
+func execArgs(name string, env, cmd []string, interactive bool) []string {
+	cmdArgs := []string{"exec"}
+	if interactive {
+		cmdArgs = append(cmdArgs, "-it")
+	}
+	for _, e := range env {
+		cmdArgs = append(cmdArgs, "-e", e)
+	}
+	return append(append(cmdArgs, name), cmd...)
+}
+
+func (r *cliRuntime) Logs(name string, tail int) ([]byte, error) {
+	return r.run([]string{"logs", "--tail", strconv.Itoa(tail), name}, ".")
+}
+
+func (r *cliRuntime) CopyFrom(name, src, dst string) error {
+	_, err := r.run([]string{"cp", name + ":" + src, dst}, ".")
+	return err
+}
+
+func (r *cliRuntime) CopyTo(name, src, dst string) error {
+	_, err := r.run([]string{"cp", src, name + ":" + dst}, ".")
+	return err
+}
+
+func (r *cliRuntime) RemoveService(project string) error {
+	filter := "label=" + LabelService + "=" + project
+
+	output, err := r.run([]string{"ps", "-aq", "--filter", filter}, ".")
+	if err != nil {
+		fmt.Printf("\nError listing the containers: %s\n", err)
+		return err
+	}
+	if containers := strings.Fields(string(output)); len(containers) > 0 {
+		if _, err = r.run(append([]string{"rm", "-f"}, containers...), "."); err != nil {
+			fmt.Printf("\nError removing the containers: %s\n", err)
+			return err
+		}
+		fmt.Printf("\n\nSuccessfully removed the containers: %s\n", strings.Join(containers, ", "))
+	}
+
+	output, err = r.run([]string{"volume", "ls", "-q", "--filter", filter}, ".")
+	if err != nil {
+		fmt.Printf("\nError listing the volumes: %s\n", err)
+		return err
+	}
+	if volumes := strings.Fields(string(output)); len(volumes) > 0 {
+		if _, err = r.run(append([]string{"volume", "rm"}, volumes...), "."); err != nil {
+			fmt.Printf("\nError removing the volumes: %s\n", err)
+			return err
+		}
+		fmt.Printf("\n\nSuccessfully removed the volumes: %s\n", strings.Join(volumes, ", "))
+	}
+	return nil
+}
+
+// cliError turns the output of a failed command into one of the runtime errors
+func cliError(output []byte, err error) error {
+	message := strings.TrimSpace(string(output))
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "no such container"), strings.Contains(lower, "no container with name or id"):
+		return fmt.Errorf("%w: %s", ErrNotFound, message)
+	case strings.Contains(lower, "port is already allocated"), strings.Contains(lower, "address already in use"):
+		return fmt.Errorf("%w: %s", ErrPortBusy, message)
+	case strings.Contains(lower, "already in use"):
+		return fmt.Errorf("%w: %s", ErrNameConflict, message)
+	case strings.Contains(lower, "pull access denied"), strings.Contains(lower, "manifest unknown"), strings.Contains(lower, "no such image"):
+		return fmt.Errorf("%w: %s", ErrImageNotFound, message)
+	case message == "":
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, message)
+}
+
+// inspectResponse is the part of the container inspect output the generator reads
+type inspectResponse struct {
+	Config struct {
+		Image string
+		Env   []string
+	}
+	State struct {
+		Running bool
+	}
+	HostConfig struct {
+		PortBindings map[string][]struct {
+			HostPort string
+		}
+	}
+}
+
+func (i inspectResponse) container() models.Container {
+	container := models.Container{
+		Image:   i.Config.Image,
+		Env:     i.Config.Env,
+		Running: i.State.Running,
+	}
+	for _, port := range containerPorts {
+		if bindings := i.HostConfig.PortBindings[port]; len(bindings) > 0 {
+			container.Port, _ = strconv.Atoi(bindings[0].HostPort)
+		}
+	}
+	return container
+}
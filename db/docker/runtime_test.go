@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRuntime(t *testing.T) {
+	runtime, err := NewRuntime("podman")
+	require.NoError(t, err)
+	assert.Equal(t, &cliRuntime{binary: "podman"}, runtime)
+
+	runtime, err = NewRuntime("")
+	require.NoError(t, err)
+	assert.Equal(t, &cliRuntime{binary: "docker"}, runtime)
+
+	t.Setenv("DOCKER_HOST", "unix:///tmp/docker.sock")
+	runtime, err = NewRuntime("docker-api")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/docker.sock", runtime.(*engineRuntime).socket)
+
+	t.Setenv("CONTAINER_HOST", "unix:///tmp/podman.sock")
+	runtime, err = NewRuntime("podman-api")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/podman.sock", runtime.(*engineRuntime).socket)
+
+	_, err = NewRuntime("containerd")
+	assert.ErrorContains(t, err, `runtime "containerd" not supported`)
+}
+
+func TestCLIError(t *testing.T) {
+	exit := errors.New("exit status 1")
+	tests := []struct {
+		output   string
+		expected error
+	}{
+		{"Error: No such container: db", ErrNotFound},
+		{"Error: no container with name or ID \"db\" found: no such container", ErrNotFound},
+		{"Bind for 0.0.0.0:6432 failed: port is already allocated", ErrPortBusy},
+		{"listen tcp4 0.0.0.0:6432: bind: address already in use", ErrPortBusy},
+		{`The container name "/db" is already in use by container "a1b2"`, ErrNameConflict},
+		{"Error response from daemon: pull access denied for postgress", ErrImageNotFound},
+		{"manifest unknown", ErrImageNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			err := cliError([]byte(tt.output), exit)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Contains(t, err.Error(), tt.output)
+		})
+	}
+
+	assert.Equal(t, exit, cliError(nil, exit))
+	assert.EqualError(t, cliError([]byte("permission denied\n"), exit), "exit status 1: permission denied")
+}
+
+func TestCLIRuntime_Podman(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	runtime := &cliRuntime{binary: "podman"}
+	mockCmdsExecutor.On("ExecuteCmds", "podman", ComposeUp, "wrkdir").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "podman", []string{"exec", "-e", "A=1", "db", "true"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "podman", []string{"logs", "--tail", "20", "db"}, ".").Return([]byte("ready"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "podman", []string{"start", "db"}, ".").Return([]byte("Error: no container with name or ID \"db\" found"), errors.New("exit status 125"))
+
+	assert.NoError(t, runtime.Up(postgresInputs))
+	_, err := runtime.Exec("db", []string{"A=1"}, []string{"true"})
+	assert.NoError(t, err)
+	logs, err := runtime.Logs("db", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "ready", string(logs))
+	assert.ErrorIs(t, runtime.Start("db"), ErrNotFound)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
@@ -79,32 +79,98 @@ func initialiseMigration(wrkDir string) (err error) {
 var init_schema_up = `/*
 Generated using API Service Generator
 */
-
+{{range .Table.Columns}}{{if and .Values (ne $.DBMS "mysql")}}
+CREATE TYPE {{$.TableName}}_{{.Name}} AS ENUM ({{.ValueList}});
+{{end}}{{end}}
 CREATE TABLE IF NOT EXISTS {{.TableName}} (
-    id                          SERIAL PRIMARY KEY,
-    name                  		VARCHAR(255)
+    id                          {{.Table.Key.Definition}}{{range .Table.Columns}},
+    {{.Name}}                  		{{if not .Values}}{{.Type}}{{else if eq $.DBMS "mysql"}}ENUM({{.ValueList}}){{else}}{{$.TableName}}_{{.Name}}{{end}}{{if not .Nullable}} NOT NULL{{end}}{{end}}
+{{- if .Table.Timestamps}},
+    created_at                  {{if eq .DBMS "mysql"}}TIMESTAMP{{else}}TIMESTAMPTZ{{end}} NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  {{if eq .DBMS "mysql"}}TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP{{else}}TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP{{end}}
+{{- end}}
+{{- if .Table.SoftDelete}},
+    deleted_at                  {{if eq .DBMS "mysql"}}TIMESTAMP NULL{{else}}TIMESTAMPTZ{{end}}
+{{- end}}
+{{- if .Table.Versioned}},
+    version                     INTEGER NOT NULL DEFAULT 1
+{{- end}}
+{{- range .Table.Constraints}},
+    CONSTRAINT {{$.TableName}}_{{.Name}} {{if .Check}}CHECK ({{.Check}}){{else}}UNIQUE ({{.ColumnList}}){{end}}
+{{- end}}
+{{- range .Table.Columns}}{{if .References}},
+    CONSTRAINT {{$.TableName}}_{{.Name}}_fkey FOREIGN KEY ({{.Name}}) REFERENCES {{.References}} (id)
+{{- end}}{{end}}
 );
+{{- if and .Table.Timestamps (ne .DBMS "mysql")}}
+
+CREATE OR REPLACE FUNCTION {{.TableName}}_set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER {{.TableName}}_updated_at BEFORE UPDATE ON {{.TableName}}
+FOR EACH ROW EXECUTE FUNCTION {{.TableName}}_set_updated_at();
+{{- end}}
+{{- range .Table.Indexes}}
+
+CREATE {{if .Unique}}UNIQUE {{end}}INDEX {{$.TableName}}_{{.Name}} ON {{$.TableName}} ({{.ColumnList}}){{if .Where}} WHERE {{.Where}}{{end}};
+{{- end}}
 
 `
 
 var init_schema_down = `/*
 Generated using API Service Generator
 */
-
+{{range .Table.Indexes}}
+DROP INDEX {{if eq $.DBMS "mysql"}}{{$.TableName}}_{{.Name}} ON {{$.TableName}}{{else}}IF EXISTS {{$.TableName}}_{{.Name}}{{end}};
+{{- end}}
+{{- range .Table.Constraints}}
+ALTER TABLE {{$.TableName}} DROP {{if ne $.DBMS "mysql"}}CONSTRAINT IF EXISTS{{else if .Check}}CHECK{{else}}INDEX{{end}} {{$.TableName}}_{{.Name}};
+{{- end}}
+{{- if or .Table.Indexes .Table.Constraints}}
+{{end}}
 DROP TABLE IF EXISTS {{.TableName}};
+{{- range .Table.Columns}}{{if and .Values (ne $.DBMS "mysql")}}
+
+DROP TYPE IF EXISTS {{$.TableName}}_{{.Name}};
+{{- end}}{{end}}
+{{- if and .Table.Timestamps (ne .DBMS "mysql")}}
+
+DROP FUNCTION IF EXISTS {{.TableName}}_set_updated_at();
+{{- end}}
 
 `
 
 func writeSchemaUpFile(initSchema models.InitSchema) error {
 	fileName := initSchema.WrkDir + migrationDirectoryPath + migrationUpFileName
+	if initSchema.Tables != nil {
+		return common.CreateFileAndItsContent(fileName, initSchema.Tables, tablesContent(init_schema_up))
+	}
 	return common.CreateFileAndItsContent(fileName, initSchema, init_schema_up)
 }
 
 func writeSchemaDownFile(initSchema models.InitSchema) error {
 	fileName := initSchema.WrkDir + migrationDirectoryPath + migrationDownFileName
+	if initSchema.Tables != nil {
+		// the tables that reference others are dropped first
+		tables := make([]models.InitSchema, len(initSchema.Tables))
+		for i, table := range initSchema.Tables {
+			tables[len(tables)-1-i] = table
+		}
+		return common.CreateFileAndItsContent(fileName, tables, tablesContent(init_schema_down))
+	}
 	return common.CreateFileAndItsContent(fileName, initSchema, init_schema_down)
 }
 
+// tablesContent renders the schema template of a table for every table of a spec with tables, under one header
+func tablesContent(content string) string {
+	header, table, _ := strings.Cut(content, "*/\n")
+	return header + "*/\n{{range .}}{{template \"table\" .}}{{end}}{{define \"table\"}}" + table + "{{end}}"
+}
+
 const migrateUp_content = `// Generated By API Service Generator
 
 package db
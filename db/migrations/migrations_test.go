@@ -1,15 +1,18 @@
 package migrations
 
 import (
+	"bytes"
 	"errors"
 	"os"
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/abhijithk1/api-service-generator/common"
 	"github.com/abhijithk1/api-service-generator/mocks"
 	"github.com/abhijithk1/api-service-generator/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -321,3 +324,36 @@ func TestMigration_WriteSchemaDownFileError(t *testing.T) {
 	mockCmdsExecutor.AssertExpectations(t)
 
 }
+
+// the templates of the package, the tests above replace them
+var schemaUp, schemaDown = init_schema_up, init_schema_down
+
+func TestWriteSchemaFiles_Tables(t *testing.T) {
+	init_schema_up, init_schema_down = schemaUp, schemaDown
+	migrationUpFileName, migrationDownFileName = "000001_init_schema.up.sql", "000001_init_schema.down.sql"
+	authors := models.InitSchema{TableName: "authors", DBMS: "postgres", Table: models.TableSpec{Key: models.Key{Definition: "SERIAL PRIMARY KEY"}, Columns: []models.Column{{Name: "name", Type: "TEXT"}}}}
+	books := models.InitSchema{TableName: "books", DBMS: "postgres", Table: models.TableSpec{Key: models.Key{Definition: "SERIAL PRIMARY KEY"}, Columns: []models.Column{{Name: "author_id", Type: "INTEGER", References: "authors"}}}}
+	initSchema := authors
+	initSchema.WrkDir = "dir"
+	initSchema.Tables = []models.InitSchema{authors, books}
+
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	files := map[string]string{}
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var rendered bytes.Buffer
+		require.NoError(t, template.Must(template.New("schema").Parse(args.String(2))).Execute(&rendered, args.Get(1)))
+		files[args.String(0)] = rendered.String()
+	}).Return(nil)
+
+	require.NoError(t, writeSchemaUpFile(initSchema))
+	require.NoError(t, writeSchemaDownFile(initSchema))
+
+	// the referenced table is created first and dropped last, under one header
+	up := files["dir/pkg/db/migrations/000001_init_schema.up.sql"]
+	assert.Equal(t, 1, strings.Count(up, "Generated using API Service Generator"))
+	assert.Less(t, strings.Index(up, "CREATE TABLE IF NOT EXISTS authors ("), strings.Index(up, "CREATE TABLE IF NOT EXISTS books ("))
+	assert.Contains(t, up, "CONSTRAINT books_author_id_fkey FOREIGN KEY (author_id) REFERENCES authors (id)")
+	down := files["dir/pkg/db/migrations/000001_init_schema.down.sql"]
+	assert.Less(t, strings.Index(down, "DROP TABLE IF EXISTS books;"), strings.Index(down, "DROP TABLE IF EXISTS authors;"))
+}
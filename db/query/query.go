@@ -18,7 +18,45 @@ type QueryClient struct{}
 var table_sql = `-- Generated using API Service Generator
 
 -- name: List{{.TableName}} :many
-SELECT * FROM {{.TableName}};
+SELECT * FROM {{.TableName}}{{if .Table.SoftDelete}}
+WHERE deleted_at IS NULL{{end}};
+
+-- name: Get{{.TableName}} :one
+SELECT * FROM {{.TableName}}
+WHERE id = sqlc.arg(id){{if .Table.SoftDelete}} AND deleted_at IS NULL{{end}} LIMIT 1;
+
+-- name: Create{{.TableName}} {{if eq .DBMS "mysql"}}:execresult{{else}}:one{{end}}
+INSERT INTO {{.TableName}} (
+    {{if .Table.Key.AppGenerated}}id, {{end}}{{range $i, $c := .Table.Columns}}{{if $i}}, {{end}}{{$c.Name}}{{end}}
+) VALUES (
+    {{if .Table.Key.AppGenerated}}sqlc.arg(id), {{end}}{{range $i, $c := .Table.Columns}}{{if $i}}, {{end}}sqlc.arg({{$c.Name}}){{end}}
+){{if ne .DBMS "mysql"}}
+RETURNING *{{end}};
+
+-- name: Update{{.TableName}} {{if eq .DBMS "mysql"}}{{if .Table.Versioned}}:execrows{{else}}:exec{{end}}{{else}}:one{{end}}
+UPDATE {{.TableName}}
+SET {{range $i, $c := .Table.Columns}}{{if $i}}, {{end}}{{$c.Name}} = sqlc.arg({{$c.Name}}){{end}}{{if .Table.Versioned}}, version = version + 1{{end}}
+WHERE id = sqlc.arg(id){{if .Table.Versioned}} AND version = sqlc.arg(version){{end}}{{if .Table.SoftDelete}} AND deleted_at IS NULL{{end}}{{if ne .DBMS "mysql"}}
+RETURNING *{{end}};
+
+-- name: Delete{{.TableName}} :execrows
+{{if .Table.SoftDelete}}UPDATE {{.TableName}}
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;{{else}}DELETE FROM {{.TableName}}
+WHERE id = sqlc.arg(id);{{end}}
+{{- if .GraphQL}}
+
+-- name: List{{.TableName}}ByIDs :many
+SELECT * FROM {{.TableName}}
+WHERE id {{if eq .DBMS "mysql"}}IN (sqlc.slice(ids)){{else}}= ANY(sqlc.arg(ids)::{{template "keyArray" .}}){{end}}{{if .Table.SoftDelete}} AND deleted_at IS NULL{{end}};
+{{- range .Table.Columns}}{{if .References}}
+
+-- name: List{{$.TableName}}By{{.FieldName}} :many
+SELECT * FROM {{$.TableName}}
+WHERE {{.Name}} {{if eq $.DBMS "mysql"}}IN (sqlc.slice(values)){{else}}= ANY(sqlc.arg(values)::{{.Type}}[]){{end}}{{if $.Table.SoftDelete}} AND deleted_at IS NULL{{end}};
+{{- end}}{{end}}
+{{- end}}
+{{define "keyArray"}}{{if eq .Table.PrimaryKey "serial"}}INTEGER{{else if eq .Table.PrimaryKey "bigserial"}}BIGINT{{else if eq .Table.PrimaryKey "uuid"}}UUID{{else}}TEXT{{end}}[]{{end}}
 `
 
 func (q * QueryClient) SetTableQuery(initSchema models.InitSchema) (err error) {
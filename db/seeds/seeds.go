@@ -0,0 +1,475 @@
+package seeds
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	seedDirectoryPath = "/pkg/db/seeds/"
+	seedRunnerPath    = "/pkg/db/"
+	seedCmdPath       = "/cmd/seed/"
+	// fixed source so that regenerating a service produces the same fixtures
+	fakeSource int64 = 1
+)
+
+type SeedInterface interface {
+	SetSeeds(dbInputs models.DBInputs, initSchema models.InitSchema) (err error)
+}
+
+var DefaultSeedClient SeedInterface = &SeedClient{}
+
+type SeedClient struct{}
+
+func (s *SeedClient) SetSeeds(dbInputs models.DBInputs, initSchema models.InitSchema) (err error) {
+	fmt.Println("\n\n*** Writing the seed fixtures ***")
+	err = writeSeedFile(dbInputs, initSchema)
+	if err != nil {
+		return
+	}
+
+	err = writeSeedRunner(dbInputs)
+	if err != nil {
+		return
+	}
+
+	err = writeSeedCmd(dbInputs)
+	if err != nil {
+		return
+	}
+
+	return nil
+}
+
+func SetSeeds(dbInputs models.DBInputs, initSchema models.InitSchema) (err error) {
+	return DefaultSeedClient.SetSeeds(dbInputs, initSchema)
+}
+
+var seed_sql = `-- Generated using API Service Generator
+-- Fixtures for {{.TableName}}. Loading them again skips the rows that already exist.
+{{range .Rows}}
+{{if eq $.DBMS "mysql"}}INSERT IGNORE INTO {{$.TableName}} ({{$.Columns}}) VALUES ({{.}});{{else}}INSERT INTO {{$.TableName}} ({{$.Columns}}) VALUES ({{.}}) ON CONFLICT (id) DO NOTHING;{{end}}
+{{- end}}
+{{if and .Rows .Sequence}}
+SELECT setval(pg_get_serial_sequence('{{.TableName}}', 'id'), (SELECT MAX(id) FROM {{.TableName}}));
+{{end}}`
+
+func writeSeedFile(dbInputs models.DBInputs, initSchema models.InitSchema) error {
+	fileName := initSchema.WrkDir + seedDirectoryPath + seedFileName(dbInputs, initSchema.TableName)
+	return common.CreateFileAndItsContent(fileName, seedData(dbInputs, initSchema), seed_sql)
+}
+
+// seedFileName prefixes the fixture with the position of its table in the spec. The fixtures
+// load in name order, so the referenced tables are seeded first.
+func seedFileName(dbInputs models.DBInputs, tableName string) string {
+	position := 1
+	for i, table := range dbInputs.Tables {
+		if table.Name == tableName {
+			position = i + 1
+		}
+	}
+	return fmt.Sprintf("%02d_%s.sql", position, tableName)
+}
+
+func seedData(dbInputs models.DBInputs, initSchema models.InitSchema) models.SeedData {
+	columns := []string{"id"}
+	for _, column := range initSchema.Table.Columns {
+		columns = append(columns, column.Name)
+	}
+
+	return models.SeedData{
+		TableName: initSchema.TableName,
+		DBMS:      dbInputs.DBMS,
+		Columns:   strings.Join(columns, ", "),
+		Rows:      FakeRows(initSchema.Table, dbInputs.SeedRows, parentIDs(dbInputs, initSchema)),
+		// explicit ids leave a postgres sequence behind, it is moved past them
+		Sequence: dbInputs.DBMS == "postgres" && hasSequence(initSchema.Table.PrimaryKey),
+	}
+}
+
+// parentIDs are the ids of the fixtures of the tables the foreign keys that are not null
+// reference, by table. A table that references itself points at its first row.
+func parentIDs(dbInputs models.DBInputs, initSchema models.InitSchema) map[string][]string {
+	ids := map[string][]string{}
+	for _, column := range initSchema.Table.Columns {
+		if column.References == "" || column.Nullable {
+			continue
+		}
+		if column.References == initSchema.TableName {
+			ids[column.References] = fakeIDs(initSchema.Table.PrimaryKey, min(dbInputs.SeedRows, 1))
+			continue
+		}
+		primaryKey := ""
+		for _, table := range dbInputs.Tables {
+			if table.Name == column.References {
+				primaryKey = table.PrimaryKey
+			}
+		}
+		ids[column.References] = fakeIDs(primaryKey, dbInputs.SeedRows)
+	}
+	return ids
+}
+
+const seedRunnerContent = `// Generated By API Service Generator
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+{{- if ne .DBMS "mysql"}}
+	"strconv"
+{{- end}}
+	"strings"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+	"gopkg.in/yaml.v3"
+)
+
+// identifier is a table or column name a YAML fixture may use
+var identifier = regexp.MustCompile(` + "`" + `^[A-Za-z_][A-Za-z0-9_]*$` + "`" + `)
+
+// position is the prefix that orders the fixtures, like 01_ in 01_authors.yaml
+var position = regexp.MustCompile(` + "`" + `^[0-9]+_` + "`" + `)
+
+type seedStatement struct {
+	query string
+	args  []any
+}
+
+// RunSeeds loads every .sql, .yaml and .yml fixture of dir in name order, one transaction per file.
+// The fixtures are prefixed with the position of their table, so the referenced tables are seeded first.
+func RunSeeds(conn *sql.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		var stmts []seedStatement
+		switch filepath.Ext(file) {
+		case ".sql":
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			for _, stmt := range seedStatements(string(content)) {
+				stmts = append(stmts, seedStatement{query: stmt})
+			}
+		case ".yaml", ".yml":
+			stmts, err = yamlStatements(file)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", file, err)
+			}
+		default:
+			continue
+		}
+
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range stmts {
+			if _, err = tx.Exec(stmt.query, stmt.args...); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("error seeding %s: %w", file, err)
+			}
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		ch.Log(alog.INFO, "Seeded %s", file)
+	}
+
+	return nil
+}
+
+func seedStatements(content string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	stmts := []string{}
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";\n") {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// yamlStatements inserts the rows of a YAML fixture, a list of rows keyed by column, into the
+// table the file is named after, without its position prefix. The rows that already exist are skipped.
+func yamlStatements(file string) ([]seedStatement, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]any
+	if err = yaml.Unmarshal(content, &rows); err != nil {
+		return nil, err
+	}
+	table := position.ReplaceAllString(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), "")
+	if !identifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	stmts := []seedStatement{}
+	for _, row := range rows {
+		columns := []string{}
+		for column := range row {
+			if !identifier.MatchString(column) {
+				return nil, fmt.Errorf("invalid column name %q", column)
+			}
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		placeholders := make([]string, len(columns))
+		args := make([]any, len(columns))
+		for i, column := range columns {
+{{- if eq .DBMS "mysql"}}
+			placeholders[i] = "?"
+{{- else}}
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+{{- end}}
+			if args[i], err = seedValue(row[column]); err != nil {
+				return nil, err
+			}
+		}
+{{- if eq .DBMS "mysql"}}
+		query := fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+{{- else}}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+{{- end}}
+		stmts = append(stmts, seedStatement{query: query, args: args})
+	}
+{{- if ne .DBMS "mysql"}}
+	if len(rows) > 0 {
+		// explicit ids leave a serial sequence behind, it is moved past them
+		stmts = append(stmts, seedStatement{query: fmt.Sprintf("SELECT setval(seq, (SELECT MAX(id) FROM %s)) FROM pg_get_serial_sequence('%s', 'id') AS seq WHERE seq IS NOT NULL", table, table)})
+	}
+{{- end}}
+	return stmts, nil
+}
+
+// seedValue passes the lists and maps of a YAML row as JSON, for the JSON columns
+func seedValue(value any) (any, error) {
+	switch value.(type) {
+	case []any, map[string]any:
+		content, err := json.Marshal(value)
+		return string(content), err
+	}
+	return value, nil
+}
+`
+
+func writeSeedRunner(dbInputs models.DBInputs) error {
+	fileName := dbInputs.WrkDir + seedRunnerPath + "seed.go"
+	return common.CreateFileAndItsContent(fileName, dbInputs, seedRunnerContent)
+}
+
+const seedCmdContent = `// Generated By API Service Generator
+package main
+
+import (
+	"os"
+
+	"{{.GoModule}}/{{.WrkDir}}/pkg/db"
+	util "{{.GoModule}}/{{.WrkDir}}/utils"
+
+	"github.com/IBM/alchemy-logging/src/go/alog"
+)
+
+var ch = alog.UseChannel("SEED")
+
+func main() {
+	util.StartLogServer()
+	conn := db.GetConnection()
+	defer conn.Close()
+
+	if err := db.RunSeeds(conn, "pkg/db/seeds"); err != nil {
+		ch.Log(alog.ERROR, "Failed to seed: %v", err)
+		os.Exit(1)
+	}
+}
+`
+
+func writeSeedCmd(dbInputs models.DBInputs) error {
+	fileName := dbInputs.WrkDir + seedCmdPath + "main.go"
+	return common.CreateFileAndItsContent(fileName, dbInputs, seedCmdContent)
+}
+
+var (
+	firstNames = []string{"Ada", "Alan", "Grace", "Linus", "Barbara", "Dennis", "Margaret", "Ken", "Radia", "Edsger"}
+	lastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Liskov", "Ritchie", "Hamilton", "Thompson", "Perlman", "Dijkstra"}
+	cities     = []string{"Bengaluru", "Berlin", "Lisbon", "Nairobi", "Osaka", "Toronto", "Santiago", "Oslo"}
+	countries  = []string{"India", "Germany", "Portugal", "Kenya", "Japan", "Canada", "Chile", "Norway"}
+	words      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do"}
+	typeLength = regexp.MustCompile(`\((\d+)`)
+)
+
+// FakeRows synthesises n rows of SQL literals, id first, from the column types. The foreign
+// keys that are not null take one of the ids of parents of the table they reference.
+func FakeRows(table models.TableSpec, n int, parents map[string][]string) []string {
+	ids := fakeIDs(table.PrimaryKey, n)
+	r := rand.New(rand.NewSource(fakeSource))
+	rows := []string{}
+	for i := 1; i <= n; i++ {
+		values := []string{ids[i-1]}
+		for _, column := range table.Columns {
+			values = append(values, fakeValue(column, i, r, parents))
+		}
+		rows = append(rows, strings.Join(values, ", "))
+	}
+	return rows
+}
+
+// fakeIDs are the ids of the n rows FakeRows synthesises for the primary key, they have a
+// source of their own so the fixtures of a table referencing them get the same ones
+func fakeIDs(primaryKey string, n int) []string {
+	r := rand.New(rand.NewSource(fakeSource))
+	ids := []string{}
+	for i := 1; i <= n; i++ {
+		ids = append(ids, fakeID(primaryKey, i, r))
+	}
+	return ids
+}
+
+func fakeValue(column models.Column, row int, r *rand.Rand, parents map[string][]string) string {
+	sqlType := strings.ToUpper(column.Type)
+	switch {
+	case column.References != "":
+		// a made up key would break the foreign key
+		ids := parents[column.References]
+		if column.Nullable || len(ids) == 0 {
+			return "NULL"
+		}
+		return ids[r.Intn(len(ids))]
+	case len(column.Values) > 0:
+		return quote(column.Values[r.Intn(len(column.Values))])
+	case strings.HasSuffix(sqlType, "[]"):
+		return "'{}'"
+	case strings.HasPrefix(sqlType, "BOOL"), strings.HasPrefix(sqlType, "TINYINT(1)"):
+		if r.Intn(2) == 0 {
+			return "FALSE"
+		}
+		return "TRUE"
+	case strings.Contains(sqlType, "INT"), strings.Contains(sqlType, "SERIAL"):
+		if strings.HasPrefix(sqlType, "SMALLINT") || strings.HasPrefix(sqlType, "TINYINT") {
+			return strconv.Itoa(r.Intn(100))
+		}
+		return strconv.Itoa(r.Intn(10000))
+	case strings.HasPrefix(sqlType, "DECIMAL"), strings.HasPrefix(sqlType, "NUMERIC"),
+		strings.HasPrefix(sqlType, "REAL"), strings.HasPrefix(sqlType, "FLOAT"), strings.HasPrefix(sqlType, "DOUBLE"):
+		return fmt.Sprintf("%d.%02d", r.Intn(1000), r.Intn(100))
+	case strings.HasPrefix(sqlType, "TIMESTAMP"), strings.HasPrefix(sqlType, "DATETIME"):
+		return fmt.Sprintf("'2024-%02d-%02d %02d:%02d:00'", r.Intn(12)+1, r.Intn(28)+1, r.Intn(24), r.Intn(60))
+	case strings.HasPrefix(sqlType, "DATE"):
+		return fmt.Sprintf("'2024-%02d-%02d'", r.Intn(12)+1, r.Intn(28)+1)
+	case strings.HasPrefix(sqlType, "UUID"):
+		return quote(fakeUUID(r))
+	case strings.HasPrefix(sqlType, "JSON"):
+		return "'{}'"
+	case strings.Contains(sqlType, "CHAR"), strings.Contains(sqlType, "TEXT"):
+		return quote(truncate(fakeText(column.Name, row, r), typeLength.FindStringSubmatch(sqlType)))
+	default:
+		return "NULL"
+	}
+}
+
+// fakeText picks a value that looks right for the column name
+func fakeText(name string, row int, r *rand.Rand) string {
+	name = strings.ToLower(name)
+	first, last := firstNames[r.Intn(len(firstNames))], lastNames[r.Intn(len(lastNames))]
+	switch {
+	case strings.Contains(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), row)
+	case strings.Contains(name, "phone"):
+		return fmt.Sprintf("+1-555-%04d", r.Intn(10000))
+	case strings.Contains(name, "url"), strings.Contains(name, "website"):
+		return fmt.Sprintf("https://example.com/%s/%d", strings.ToLower(last), row)
+	case strings.Contains(name, "city"):
+		return cities[r.Intn(len(cities))]
+	case strings.Contains(name, "country"):
+		return countries[r.Intn(len(countries))]
+	case strings.Contains(name, "first"):
+		return first
+	case strings.Contains(name, "last"), strings.Contains(name, "surname"):
+		return last
+	case strings.Contains(name, "name"):
+		return first + " " + last
+	case strings.Contains(name, "desc"), strings.Contains(name, "comment"), strings.Contains(name, "note"):
+		sentence := make([]string, 6)
+		for i := range sentence {
+			sentence[i] = words[r.Intn(len(words))]
+		}
+		return strings.Join(sentence, " ")
+	default:
+		return fmt.Sprintf("%s %d", name, row)
+	}
+}
+
+func fakeID(primaryKey string, row int, r *rand.Rand) string {
+	switch primaryKey {
+	case "uuid":
+		return quote(fakeUUID(r))
+	case "ulid":
+		return quote(fakeULID(row, r))
+	default:
+		return strconv.Itoa(row)
+	}
+}
+
+func hasSequence(primaryKey string) bool {
+	return primaryKey == "serial" || primaryKey == "bigserial"
+}
+
+// fakeULID keeps the fixtures in insertion order, as real ULIDs would be
+func fakeULID(row int, r *rand.Rand) string {
+	const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	id := []byte(fmt.Sprintf("01HZ%06d", row))
+	for len(id) < 26 {
+		id = append(id, crockford[r.Intn(len(crockford))])
+	}
+	return string(id)
+}
+
+func fakeUUID(r *rand.Rand) string {
+	b := make([]byte, 16)
+	r.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func truncate(s string, length []string) string {
+	if len(length) < 2 {
+		return s
+	}
+	n, err := strconv.Atoi(length[1])
+	if err != nil || n >= len(s) {
+		return s
+	}
+	return s[:n]
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
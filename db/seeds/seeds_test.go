@@ -0,0 +1,274 @@
+package seeds
+
+import (
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+var testColumns = []models.Column{
+	{Name: "name", Type: "VARCHAR(255)"},
+	{Name: "email", Type: "TEXT"},
+	{Name: "age", Type: "INTEGER"},
+	{Name: "active", Type: "BOOLEAN"},
+	{Name: "code", Type: "CHAR(3)"},
+}
+
+var testTable = models.TableSpec{
+	PrimaryKey: "serial",
+	Columns:    testColumns,
+}
+
+func TestFakeRows(t *testing.T) {
+	rows := FakeRows(testTable, 3, nil)
+	assert.Len(t, rows, 3)
+
+	for i, row := range rows {
+		values := strings.Split(row, ", ")
+		assert.Len(t, values, len(testColumns)+1)
+		assert.Equal(t, []string{"1", "2", "3"}[i], values[0])
+		assert.Contains(t, values[2], "@example.com")
+		assert.Contains(t, []string{"TRUE", "FALSE"}, values[4])
+		assert.Len(t, values[5], len("'abc'"))
+	}
+
+	// same fixtures on every run
+	assert.Equal(t, rows, FakeRows(testTable, 3, nil))
+	assert.Empty(t, FakeRows(testTable, 0, nil))
+}
+
+func TestFakeRows_PrimaryKeys(t *testing.T) {
+	tests := []struct {
+		primaryKey string
+		length     int
+	}{
+		{"serial", 1},
+		{"bigserial", 1},
+		{"uuid", 38},
+		{"ulid", 28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.primaryKey, func(t *testing.T) {
+			table := models.TableSpec{PrimaryKey: tt.primaryKey, Columns: testColumns[:1]}
+			rows := FakeRows(table, 2, nil)
+			first := strings.SplitN(rows[0], ", ", 2)[0]
+			second := strings.SplitN(rows[1], ", ", 2)[0]
+			assert.Len(t, first, tt.length)
+			assert.NotEqual(t, first, second)
+		})
+	}
+}
+
+func TestFakeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   models.Column
+		expected func(string) bool
+	}{
+		{"uuid", models.Column{Name: "ref", Type: "UUID"}, func(v string) bool { return len(v) == 38 }},
+		{"json", models.Column{Name: "meta", Type: "JSONB"}, func(v string) bool { return v == "'{}'" }},
+		{"date", models.Column{Name: "born", Type: "DATE"}, func(v string) bool { return strings.HasPrefix(v, "'2024-") }},
+		{"decimal", models.Column{Name: "price", Type: "DECIMAL(10,2)"}, func(v string) bool { return strings.Contains(v, ".") }},
+		{"enum", models.Column{Name: "status", Type: "ENUM", Values: []string{"live"}}, func(v string) bool { return v == "'live'" }},
+		{"array", models.Column{Name: "tags", Type: "TEXT[]"}, func(v string) bool { return v == "'{}'" }},
+		{"unknown", models.Column{Name: "blob", Type: "BYTEA"}, func(v string) bool { return v == "NULL" }},
+		{"quoted", models.Column{Name: "note", Type: "TEXT"}, func(v string) bool { return strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSource = 1
+			rows := FakeRows(models.TableSpec{Columns: []models.Column{tt.column}}, 1, nil)
+			value := strings.SplitN(rows[0], ", ", 2)[1]
+			assert.True(t, tt.expected(value), value)
+		})
+	}
+}
+
+func TestFakeRows_ForeignKeys(t *testing.T) {
+	dbInputs := models.DBInputs{
+		SeedRows: 3,
+		Tables: []models.Table{
+			{Name: "authors", TableSpec: models.TableSpec{PrimaryKey: "uuid"}},
+			{Name: "books"},
+		},
+	}
+	initSchema := models.InitSchema{
+		TableName: "books",
+		Table: models.TableSpec{PrimaryKey: "serial", Columns: []models.Column{
+			{Name: "author_id", Type: "UUID", References: "authors"},
+			{Name: "sequel_of", Type: "INTEGER", References: "books"},
+			{Name: "editor_id", Type: "INTEGER", References: "authors", Nullable: true},
+		}},
+	}
+
+	// the keys are the ids of the seeded authors and books, whatever their type
+	keys := func(rows []string) []string {
+		ids := []string{}
+		for _, row := range rows {
+			ids = append(ids, strings.SplitN(row, ", ", 2)[0])
+		}
+		return ids
+	}
+	authorIDs := keys(seedData(dbInputs, models.InitSchema{TableName: "authors", Table: dbInputs.Tables[0].TableSpec}).Rows)
+	books := seedData(dbInputs, initSchema).Rows
+	bookIDs := keys(books)
+	require.Len(t, books, 3)
+	for _, row := range books {
+		values := strings.Split(row, ", ")
+		assert.Contains(t, authorIDs, values[1])
+		assert.Contains(t, bookIDs, values[2])
+		assert.Equal(t, "NULL", values[3])
+	}
+}
+
+func TestSeedFileName(t *testing.T) {
+	dbInputs := models.DBInputs{Tables: []models.Table{{Name: "authors"}, {Name: "books"}}}
+	assert.Equal(t, "01_authors.sql", seedFileName(dbInputs, "authors"))
+	assert.Equal(t, "02_books.sql", seedFileName(dbInputs, "books"))
+	assert.Equal(t, "01_dummy.sql", seedFileName(models.DBInputs{}, "dummy"))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "'O''Hara'", quote("O'Hara"))
+}
+
+func TestSeedData(t *testing.T) {
+	dbInputs := models.DBInputs{
+		DBMS:     "postgres",
+		SeedRows: 2,
+	}
+	initSchema := models.InitSchema{
+		TableName: "dummy",
+		WrkDir:    "dir",
+		Table:     models.TableSpec{PrimaryKey: "serial", Columns: testColumns[:1]},
+	}
+
+	data := seedData(dbInputs, initSchema)
+	assert.Equal(t, "dummy", data.TableName)
+	assert.Equal(t, "postgres", data.DBMS)
+	assert.Equal(t, "id, name", data.Columns)
+	assert.Len(t, data.Rows, 2)
+	assert.True(t, data.Sequence)
+
+	initSchema.Table.PrimaryKey = "uuid"
+	assert.False(t, seedData(dbInputs, initSchema).Sequence)
+}
+
+func TestSetSeeds_Success(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	dbInputs := models.DBInputs{
+		DBMS:     "mysql",
+		WrkDir:   "dir",
+		GoModule: "example",
+		SeedRows: 1,
+	}
+	initSchema := models.InitSchema{
+		TableName: "dummy",
+		WrkDir:    "dir",
+		Table:     testTable,
+	}
+
+	seedFileName := initSchema.WrkDir + seedDirectoryPath + "01_dummy.sql"
+	runnerFileName := dbInputs.WrkDir + seedRunnerPath + "seed.go"
+	cmdFileName := dbInputs.WrkDir + seedCmdPath + "main.go"
+
+	mockCmdsExecutor.On("CreateFileAndItsContent", seedFileName, seedData(dbInputs, initSchema), seed_sql).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", runnerFileName, dbInputs, seedRunnerContent).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", cmdFileName, dbInputs, seedCmdContent).Return(nil)
+
+	err := SetSeeds(dbInputs, initSchema)
+	assert.NoError(t, err)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestSetSeeds_SeedFileError(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	dbInputs := models.DBInputs{
+		DBMS:   "postgres",
+		WrkDir: "dir",
+	}
+	initSchema := models.InitSchema{
+		TableName: "dummy",
+		WrkDir:    "dir",
+	}
+
+	seedFileName := initSchema.WrkDir + seedDirectoryPath + "01_dummy.sql"
+	mockCmdsExecutor.On("CreateFileAndItsContent", seedFileName, seedData(dbInputs, initSchema), seed_sql).Return(errors.New("error in writing seed file"))
+
+	err := SetSeeds(dbInputs, initSchema)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error in writing seed file")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestSetSeeds_SeedRunnerError(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	dbInputs := models.DBInputs{
+		DBMS:   "postgres",
+		WrkDir: "dir",
+	}
+	initSchema := models.InitSchema{
+		TableName: "dummy",
+		WrkDir:    "dir",
+	}
+
+	seedFileName := initSchema.WrkDir + seedDirectoryPath + "01_dummy.sql"
+	runnerFileName := dbInputs.WrkDir + seedRunnerPath + "seed.go"
+	mockCmdsExecutor.On("CreateFileAndItsContent", seedFileName, seedData(dbInputs, initSchema), seed_sql).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", runnerFileName, dbInputs, seedRunnerContent).Return(errors.New("error in writing seed runner"))
+
+	err := SetSeeds(dbInputs, initSchema)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error in writing seed runner")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestSeedRunnerContent(t *testing.T) {
+	render := func(dbms string) string {
+		var rendered bytes.Buffer
+		assert.NoError(t, template.Must(template.New("seed.go").Parse(seedRunnerContent)).Execute(&rendered, models.DBInputs{DBMS: dbms}))
+		_, err := parser.ParseFile(token.NewFileSet(), "seed.go", rendered.String(), 0)
+		assert.NoError(t, err, rendered.String())
+		return rendered.String()
+	}
+
+	// the YAML fixtures are rows keyed by column, inserted with the placeholders of the driver
+	postgres := render("postgres")
+	assert.Contains(t, postgres, "case \".yaml\", \".yml\":")
+	assert.Contains(t, postgres, "placeholders[i] = \"$\" + strconv.Itoa(i+1)")
+	assert.Contains(t, postgres, "VALUES (%s) ON CONFLICT DO NOTHING\"")
+	assert.Contains(t, postgres, "pg_get_serial_sequence")
+	assert.Contains(t, postgres, "position.ReplaceAllString(")
+
+	mysql := render("mysql")
+	assert.Contains(t, mysql, "placeholders[i] = \"?\"")
+	assert.Contains(t, mysql, "INSERT IGNORE INTO %s")
+	assert.NotContains(t, mysql, "strconv")
+	assert.NotContains(t, mysql, "pg_get_serial_sequence")
+}
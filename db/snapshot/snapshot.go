@@ -0,0 +1,252 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/models"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Dir holds the snapshots, relative to the directory of the service
+	Dir = ".apigen/snapshots"
+	Now = time.Now
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+const header = `# Generated By API Service Generator
+# metadata of a snapshot taken with api-service-generator db snapshot
+`
+
+// Create dumps the database of the service in its container into the snapshot name
+func Create(dbInputs models.DBInputs, name string) (models.Snapshot, error) {
+	if err := checkName(name); err != nil {
+		return models.Snapshot{}, err
+	}
+	dir := filepath.Join(dbInputs.WrkDir, Dir)
+	if _, err := os.Stat(metadataFile(dir, name)); err == nil {
+		return models.Snapshot{}, fmt.Errorf("snapshot %s exists, delete %s to take it again", name, metadataFile(dir, name))
+	}
+	if err := checkRunning(dbInputs); err != nil {
+		return models.Snapshot{}, err
+	}
+
+	version, dirty, err := Version(dbInputs)
+	if err != nil {
+		return models.Snapshot{}, err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return models.Snapshot{}, err
+	}
+
+	snapshot := models.Snapshot{
+		Name:     name,
+		Driver:   dbInputs.DBMS,
+		Database: strings.ToLower(dbInputs.DBName),
+		Image:    docker.Image(dbInputs),
+		Version:  version,
+		Dirty:    dirty,
+		File:     name + extension(dbInputs.DBMS),
+		Created:  Now().UTC().Truncate(time.Second),
+	}
+
+	dump := containerFile(snapshot)
+	env, dumpCmd := dumpCommand(dbInputs, dump)
+	defer removeContainerFile(dbInputs, dump)
+	output, err := docker.DefaultRuntime.Exec(dbInputs.ContainerName, env, dumpCmd)
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("error dumping the database: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err = docker.DefaultRuntime.CopyFrom(dbInputs.ContainerName, dump, filepath.Join(dir, snapshot.File)); err != nil {
+		return models.Snapshot{}, fmt.Errorf("error copying the dump out of the container: %w", err)
+	}
+
+	content, err := common.MarshalYAML(snapshot)
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("error in marshalling the snapshot metadata")
+	}
+	if err = os.WriteFile(metadataFile(dir, name), append([]byte(header), content...), 0644); err != nil {
+		return models.Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// Restore replaces the database of the service with the snapshot name. The snapshot has to be
+// at the migration version of the database unless force is set.
+func Restore(dbInputs models.DBInputs, name string, force bool) (models.Snapshot, error) {
+	snapshot, err := Read(dbInputs.WrkDir, name)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Driver != dbInputs.DBMS {
+		return snapshot, fmt.Errorf("snapshot %s is a %s dump, the database of the service is %s", name, snapshot.Driver, dbInputs.DBMS)
+	}
+	if err = checkRunning(dbInputs); err != nil {
+		return snapshot, err
+	}
+
+	version, _, err := Version(dbInputs)
+	if err != nil {
+		return snapshot, err
+	}
+	if version != snapshot.Version && !force {
+		return snapshot, fmt.Errorf("snapshot %s is at migration version %d and the database at %d, migrate the database to %d first or pass --force",
+			name, snapshot.Version, version, snapshot.Version)
+	}
+
+	dump := containerFile(snapshot)
+	file := filepath.Join(dbInputs.WrkDir, Dir, snapshot.File)
+	if err = docker.DefaultRuntime.CopyTo(dbInputs.ContainerName, file, dump); err != nil {
+		return snapshot, fmt.Errorf("error copying the dump into the container: %w", err)
+	}
+	defer removeContainerFile(dbInputs, dump)
+
+	for _, step := range restoreCommands(dbInputs, dump) {
+		output, err := docker.DefaultRuntime.Exec(dbInputs.ContainerName, step.env, step.cmd)
+		if err != nil {
+			return snapshot, fmt.Errorf("error restoring the database: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+	return snapshot, nil
+}
+
+// Read reads the metadata of the snapshot name of the service in wrkDir
+func Read(wrkDir, name string) (snapshot models.Snapshot, err error) {
+	if err = checkName(name); err != nil {
+		return snapshot, err
+	}
+	file := metadataFile(filepath.Join(wrkDir, Dir), name)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return snapshot, fmt.Errorf("no snapshot %s in %s", name, filepath.Join(wrkDir, Dir))
+	}
+	if err = yaml.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("error parsing %s: %w", file, err)
+	}
+	return snapshot, nil
+}
+
+// Version is the migration version of the database, as recorded by golang-migrate.
+// A database that was never migrated is at version 0.
+func Version(dbInputs models.DBInputs) (version uint, dirty bool, err error) {
+	database := strings.ToLower(dbInputs.DBName)
+	query := "SELECT version, dirty FROM schema_migrations"
+	var env, cmd []string
+	if dbInputs.DBMS == "mysql" {
+		env = []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlPassword}
+		cmd = []string{"mysql", "-u", dbInputs.MySQL.MysqlUser, "-N", "-B", "-e", query, database}
+	} else {
+		cmd = []string{"psql", "-U", dbInputs.Postgres.PsqlUser, "-d", database, "-tAc", query}
+	}
+
+	output, err := docker.DefaultRuntime.Exec(dbInputs.ContainerName, env, cmd)
+	if err != nil {
+		message := string(output) + err.Error()
+		if strings.Contains(message, `"schema_migrations" does not exist`) || strings.Contains(message, "schema_migrations' doesn't exist") {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("error reading the migration version: %w", err)
+	}
+	return parseVersion(string(output))
+}
+
+// parseVersion reads the row of schema_migrations, psql separates the columns with |
+// and mysql with a tab. Lines around it, like client warnings, are skipped.
+func parseVersion(output string) (uint, bool, error) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == '|' || r == '\t' })
+		if len(fields) != 2 {
+			continue
+		}
+		version, err := strconv.ParseUint(strings.TrimSpace(fields[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		dirty := strings.TrimSpace(fields[1])
+		return uint(version), dirty == "t" || dirty == "1", nil
+	}
+	if strings.TrimSpace(output) == "" {
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("cannot read the migration version from %q", strings.TrimSpace(output))
+}
+
+func checkName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("snapshot name %q must start with a letter or digit and only hold letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+func checkRunning(dbInputs models.DBInputs) error {
+	container, found, err := docker.InspectContainer(dbInputs.ContainerName)
+	if err != nil {
+		return err
+	}
+	if !found || !container.Running {
+		return fmt.Errorf("container %s is not running, start it with db start", dbInputs.ContainerName)
+	}
+	return nil
+}
+
+func metadataFile(dir, name string) string {
+	return filepath.Join(dir, name+".yaml")
+}
+
+// extension of the dump, postgres dumps in the custom format of pg_restore
+func extension(dbms string) string {
+	if dbms == "mysql" {
+		return ".sql"
+	}
+	return ".dump"
+}
+
+// containerFile is where the dump is kept in the container while it is copied
+func containerFile(snapshot models.Snapshot) string {
+	return "/tmp/api-service-generator-" + snapshot.File
+}
+
+func removeContainerFile(dbInputs models.DBInputs, file string) {
+	docker.DefaultRuntime.Exec(dbInputs.ContainerName, nil, []string{"rm", "-f", file})
+}
+
+func dumpCommand(dbInputs models.DBInputs, file string) (env, cmd []string) {
+	database := strings.ToLower(dbInputs.DBName)
+	if dbInputs.DBMS == "mysql" {
+		return []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlPassword},
+			[]string{"mysqldump", "-u", dbInputs.MySQL.MysqlUser, "--single-transaction", "--no-tablespaces", "--result-file=" + file, database}
+	}
+	return nil, []string{"pg_dump", "-U", dbInputs.Postgres.PsqlUser, "-d", database, "-Fc", "-f", file}
+}
+
+type command struct {
+	env, cmd []string
+}
+
+// restoreCommands empty the database, so tables of later migrations do not survive, and load the dump.
+// Root recreates the mysql database, the grants of the service user are kept.
+func restoreCommands(dbInputs models.DBInputs, file string) []command {
+	database := strings.ToLower(dbInputs.DBName)
+	if dbInputs.DBMS == "mysql" {
+		user := []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlPassword}
+		root := []string{"MYSQL_PWD=" + dbInputs.MySQL.MysqlRootPassword}
+		return []command{
+			{root, []string{"mysql", "-u", "root", "-e", fmt.Sprintf("DROP DATABASE IF EXISTS `%s`; CREATE DATABASE `%s`", database, database)}},
+			{user, []string{"mysql", "-u", dbInputs.MySQL.MysqlUser, "-e", "source " + file, database}},
+		}
+	}
+	user := dbInputs.Postgres.PsqlUser
+	return []command{
+		{nil, []string{"psql", "-U", user, "-d", database, "-v", "ON_ERROR_STOP=1", "-c", "DROP SCHEMA public CASCADE; CREATE SCHEMA public"}},
+		{nil, []string{"pg_restore", "-U", user, "-d", database, "--no-owner", "--exit-on-error", file}},
+	}
+}
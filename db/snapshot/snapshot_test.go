@@ -0,0 +1,163 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	Now = func() time.Time { return time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC) }
+	os.Exit(m.Run())
+}
+
+var (
+	inspect = []string{"inspect", "--type", "container", "dummy_db"}
+	running = []byte(`[{"Config": {"Image": "postgres:16"}, "State": {"Running": true}}]`)
+	version = []string{"exec", "dummy_db", "psql", "-U", "postgres", "-d", "dummy_db", "-tAc", "SELECT version, dirty FROM schema_migrations"}
+	cleanup = []string{"exec", "dummy_db", "rm", "-f", "/tmp/api-service-generator-before-v4.dump"}
+)
+
+func serviceInputs(t *testing.T) models.DBInputs {
+	return models.DBInputs{
+		WrkDir:        t.TempDir(),
+		DBMS:          "postgres",
+		ContainerName: "dummy_db",
+		DBName:        "Dummy_DB",
+		Postgres:      models.PostgresDriver{PsqlUser: "postgres", PsqlPassword: "password"},
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		version uint
+		dirty   bool
+	}{
+		{"3|f\n", 3, false},
+		{"20240517093000|t\n", 20240517093000, true},
+		{"mysql: [Warning] deprecated option\n4\t1\n", 4, true},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		version, dirty, err := parseVersion(tt.output)
+		require.NoError(t, err)
+		assert.Equal(t, tt.version, version)
+		assert.Equal(t, tt.dirty, dirty)
+	}
+
+	_, _, err := parseVersion("psql: error: connection refused")
+	assert.ErrorContains(t, err, "cannot read the migration version")
+}
+
+func TestVersion_NotMigrated(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", version, ".").Return([]byte(`ERROR:  relation "schema_migrations" does not exist`), assert.AnError)
+
+	version, dirty, err := Version(serviceInputs(t))
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	assert.False(t, dirty)
+}
+
+func TestCreate(t *testing.T) {
+	dbInputs := serviceInputs(t)
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspect, ".").Return(running, nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", version, ".").Return([]byte("3|f\n"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"exec", "dummy_db", "pg_dump", "-U", "postgres", "-d", "dummy_db", "-Fc", "-f", "/tmp/api-service-generator-before-v4.dump"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"cp", "dummy_db:/tmp/api-service-generator-before-v4.dump", filepath.Join(dbInputs.WrkDir, Dir, "before-v4.dump")}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", cleanup, ".").Return([]byte(""), nil)
+
+	taken, err := Create(dbInputs, "before-v4")
+	require.NoError(t, err)
+	expected := models.Snapshot{
+		Name:     "before-v4",
+		Driver:   "postgres",
+		Database: "dummy_db",
+		Image:    "postgres:16",
+		Version:  3,
+		File:     "before-v4.dump",
+		Created:  Now(),
+	}
+	assert.Equal(t, expected, taken)
+
+	read, err := Read(dbInputs.WrkDir, "before-v4")
+	require.NoError(t, err)
+	assert.Equal(t, expected, read)
+
+	_, err = Create(dbInputs, "before-v4")
+	assert.ErrorContains(t, err, "snapshot before-v4 exists")
+	_, err = Create(dbInputs, "../app")
+	assert.ErrorContains(t, err, "must start with a letter or digit")
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+// writeSnapshot saves the metadata of a snapshot of the service
+func writeSnapshot(t *testing.T, dbInputs models.DBInputs, snapshot models.Snapshot) {
+	content, err := common.MarshalYAML(snapshot)
+	require.NoError(t, err)
+	dir := filepath.Join(dbInputs.WrkDir, Dir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, snapshot.Name+".yaml"), content, 0644))
+}
+
+func TestRestore(t *testing.T) {
+	dbInputs := serviceInputs(t)
+	writeSnapshot(t, dbInputs, models.Snapshot{Name: "before-v4", Driver: "postgres", Version: 3, File: "before-v4.dump"})
+
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("ExecuteCmds", "docker", inspect, ".").Return(running, nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", version, ".").Return([]byte("4|t\n"), nil)
+
+	_, err := Restore(dbInputs, "before-v4", false)
+	assert.EqualError(t, err, "snapshot before-v4 is at migration version 3 and the database at 4, migrate the database to 3 first or pass --force")
+
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"cp", filepath.Join(dbInputs.WrkDir, Dir, "before-v4.dump"), "dummy_db:/tmp/api-service-generator-before-v4.dump"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"exec", "dummy_db", "psql", "-U", "postgres", "-d", "dummy_db", "-v", "ON_ERROR_STOP=1", "-c", "DROP SCHEMA public CASCADE; CREATE SCHEMA public"}, ".").Return([]byte("DROP SCHEMA\nCREATE SCHEMA"), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", []string{"exec", "dummy_db", "pg_restore", "-U", "postgres", "-d", "dummy_db", "--no-owner", "--exit-on-error", "/tmp/api-service-generator-before-v4.dump"}, ".").Return([]byte(""), nil)
+	mockCmdsExecutor.On("ExecuteCmds", "docker", cleanup, ".").Return([]byte(""), nil)
+
+	restored, err := Restore(dbInputs, "before-v4", true)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), restored.Version)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestRestore_Errors(t *testing.T) {
+	dbInputs := serviceInputs(t)
+	writeSnapshot(t, dbInputs, models.Snapshot{Name: "orders", Driver: "mysql", Version: 3, File: "orders.sql"})
+
+	// nothing runs in the container, the mock has no expectations
+	common.DefaultExecutor = mocks.NewMockCmdsExecutor()
+
+	_, err := Restore(dbInputs, "orders", true)
+	assert.EqualError(t, err, "snapshot orders is a mysql dump, the database of the service is postgres")
+
+	_, err = Restore(dbInputs, "missing", false)
+	assert.ErrorContains(t, err, "no snapshot missing in")
+}
+
+func TestRestoreCommands_Mysql(t *testing.T) {
+	dbInputs := models.DBInputs{
+		DBMS:   "mysql",
+		DBName: "Orders",
+		MySQL:  models.MySQLDriver{MysqlRootPassword: "root-secret", MysqlUser: "orders", MysqlPassword: "secret"},
+	}
+	assert.Equal(t, []command{
+		{[]string{"MYSQL_PWD=root-secret"}, []string{"mysql", "-u", "root", "-e", "DROP DATABASE IF EXISTS `orders`; CREATE DATABASE `orders`"}},
+		{[]string{"MYSQL_PWD=secret"}, []string{"mysql", "-u", "orders", "-e", "source /tmp/orders.sql", "orders"}},
+	}, restoreCommands(dbInputs, "/tmp/orders.sql"))
+}
@@ -0,0 +1,346 @@
+package deploy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	finalsetup "github.com/abhijithk1/api-service-generator/common/finalSetup"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	K8sPath = "/deploy/k8s"
+	// SecretKeys of app.env go to the Secret, the other keys to the ConfigMap
+	SecretKeys = map[string]bool{"DB_SOURCE": true}
+	// SecretPlaceholder is the value of the secret keys in the manifests, the
+	// credentials of app.env are never written to them
+	SecretPlaceholder = "<DSN of the environment>"
+	Overlays          = []models.Overlay{
+		{Env: "dev", MinReplicas: 1, MaxReplicas: 1, RequestCPU: "50m", RequestMemory: "64Mi", LimitCPU: "250m", LimitMemory: "128Mi"},
+		{Env: "staging", MinReplicas: 2, MaxReplicas: 4, RequestCPU: "100m", RequestMemory: "128Mi", LimitCPU: "500m", LimitMemory: "256Mi"},
+		{Env: "prod", MinReplicas: 3, MaxReplicas: 10, RequestCPU: "250m", RequestMemory: "256Mi", LimitCPU: "1", LimitMemory: "512Mi"},
+	}
+)
+
+// file of the manifests, its path relative to deploy/k8s
+type file struct {
+	path    string
+	content string
+}
+
+var baseFiles = []file{
+	{"/base/kustomization.yaml", baseKustomization},
+	{"/base/configmap.yaml", configMap},
+	{"/base/secret.yaml", secret},
+	{"/base/deployment.yaml", deployment},
+	{"/base/service.yaml", service},
+	{"/base/hpa.yaml", hpa},
+	{"/base/migration-job.yaml", migrationJob},
+}
+
+var overlayFiles = []file{
+	{"/kustomization.yaml", overlayKustomization},
+	{"/namespace.yaml", namespace},
+	{"/hpa.yaml", hpaPatch},
+	{"/deployment.yaml", deploymentPatch},
+}
+
+// Setup writes the Kubernetes manifests of the service and the Kustomize overlays of its environments
+func Setup(dbInputs models.DBInputs) error {
+	data, err := deployData(dbInputs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n*** Creating the Kubernetes manifests ***")
+	root := dbInputs.WrkDir + K8sPath
+	for _, dir := range []string{root, root + "/base", root + "/overlays"} {
+		if err = common.CreateDirectory(dir); err != nil {
+			return err
+		}
+	}
+	for _, f := range baseFiles {
+		if err = common.CreateFileAndItsContent(root+f.path, data, f.content); err != nil {
+			return err
+		}
+	}
+
+	for _, overlay := range Overlays {
+		data.Overlay = overlay
+		dir := root + "/overlays/" + overlay.Env
+		if err = common.CreateDirectory(dir); err != nil {
+			return err
+		}
+		for _, f := range overlayFiles {
+			if err = common.CreateFileAndItsContent(dir+f.path, data, f.content); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Println("\n*** Successfully created the Kubernetes manifests ***")
+	return nil
+}
+
+func deployData(dbInputs models.DBInputs) (models.Deploy, error) {
+	env, err := finalsetup.AppEnv(dbInputs)
+	if err != nil {
+		return models.Deploy{}, err
+	}
+
+	data := models.Deploy{Name: Name(dbInputs.WrkDir)}
+	for _, v := range env {
+		if SecretKeys[v.Key] {
+			v.Value = strconv.Quote(SecretPlaceholder)
+			data.Secret = append(data.Secret, v)
+		} else {
+			v.Value = strconv.Quote(v.Value)
+			data.Config = append(data.Config, v)
+		}
+	}
+	return data, nil
+}
+
+// Name of the Kubernetes resources of the service, a DNS label short enough
+// for the suffixes of the resources and namespaces
+func Name(wrkDir string) string {
+	name := strings.ReplaceAll(docker.ServiceName(wrkDir), "_", "-")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return strings.Trim(name, "-")
+}
+
+const baseKustomization = `# Generated By API Service Generator
+# kubectl apply -k deploy/k8s/overlays/<env> deploys the service, the base is not applied on its own
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+labels:
+  - pairs:
+      app.kubernetes.io/part-of: {{.Name}}
+resources:
+  - configmap.yaml
+  - secret.yaml
+  - deployment.yaml
+  - service.yaml
+  - hpa.yaml
+  - migration-job.yaml
+images:
+  - name: {{.Name}}
+    newTag: latest
+`
+
+const configMap = `# Generated By API Service Generator
+# the keys of app.env that are not secret, the image has no app.env
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.Name}}-config
+data:
+{{- range .Config}}
+  {{.Key}}: {{.Value}}
+{{- end}}
+`
+
+const secret = `# Generated By API Service Generator
+# DB_SOURCE is a placeholder, the credentials of app.env are not written here.
+# Set the DSN of the environment, or create the Secret out of band and drop this file.
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.Name}}-db
+type: Opaque
+stringData:
+{{- range .Secret}}
+  {{.Key}}: {{.Value}}
+{{- end}}
+`
+
+const deployment = `# Generated By API Service Generator
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+spec:
+  # no replicas, the HorizontalPodAutoscaler owns them
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}
+    spec:
+      {{- template "podSecurityContext"}}
+      containers:
+        - name: api
+          image: {{.Name}}
+          ports:
+            - name: http
+              containerPort: 8080
+          {{- template "envFrom" .}}
+          livenessProbe:
+            httpGet:
+              path: /health
+              port: http
+            initialDelaySeconds: 10
+            periodSeconds: 10
+            failureThreshold: 3
+          readinessProbe:
+            httpGet:
+              path: /health
+              port: http
+            periodSeconds: 5
+            failureThreshold: 3
+          {{- template "resources"}}
+          {{- template "securityContext"}}
+` + sharedTemplates
+
+const service = `# Generated By API Service Generator
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+spec:
+  type: ClusterIP
+  selector:
+    app.kubernetes.io/name: {{.Name}}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+`
+
+const hpa = `# Generated By API Service Generator
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{.Name}}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{.Name}}
+  minReplicas: 1
+  maxReplicas: 3
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: 70
+`
+
+const migrationJob = `# Generated By API Service Generator
+# migrates the database with the image of the service. It is removed once it
+# finished, so the next kubectl apply -k runs it again.
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{.Name}}-migrate
+spec:
+  ttlSecondsAfterFinished: 300
+  backoffLimit: 3
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}-migrate
+    spec:
+      restartPolicy: Never
+      {{- template "podSecurityContext"}}
+      containers:
+        - name: migrate
+          image: {{.Name}}
+          args: ["-migrate-only"]
+          {{- template "envFrom" .}}
+          {{- template "resources"}}
+          {{- template "securityContext"}}
+` + sharedTemplates
+
+// sharedTemplates of the pods of the deployment and the migration job, the image runs as the distroless nonroot user
+const sharedTemplates = `{{define "podSecurityContext"}}
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+        runAsGroup: 65532
+        seccompProfile:
+          type: RuntimeDefault
+{{- end}}
+{{- define "envFrom"}}
+          envFrom:
+            - configMapRef:
+                name: {{.Name}}-config
+            - secretRef:
+                name: {{.Name}}-db
+{{- end}}
+{{- define "resources"}}
+          resources:
+            requests:
+              cpu: 100m
+              memory: 64Mi
+            limits:
+              cpu: 500m
+              memory: 256Mi
+{{- end}}
+{{- define "securityContext"}}
+          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities:
+              drop: ["ALL"]
+{{- end}}`
+
+const overlayKustomization = `# Generated By API Service Generator
+# kubectl apply -k deploy/k8s/overlays/{{.Overlay.Env}}
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: {{.Name}}-{{.Overlay.Env}}
+labels:
+  - pairs:
+      app.kubernetes.io/environment: {{.Overlay.Env}}
+resources:
+  - namespace.yaml
+  - ../../base
+patches:
+  - path: hpa.yaml
+  - path: deployment.yaml
+`
+
+const namespace = `# Generated By API Service Generator
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{.Name}}-{{.Overlay.Env}}
+`
+
+const hpaPatch = `# Generated By API Service Generator
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{.Name}}
+spec:
+  minReplicas: {{.Overlay.MinReplicas}}
+  maxReplicas: {{.Overlay.MaxReplicas}}
+`
+
+const deploymentPatch = `# Generated By API Service Generator
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          resources:
+            requests:
+              cpu: {{.Overlay.RequestCPU}}
+              memory: {{.Overlay.RequestMemory}}
+            limits:
+              cpu: {{.Overlay.LimitCPU}}
+              memory: {{.Overlay.LimitMemory}}
+`
@@ -0,0 +1,381 @@
+package deploy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+var serviceInputs = models.DBInputs{
+	WrkDir:        "services/Order_Service",
+	DBMS:          "postgres",
+	ContainerPort: 6432,
+	DBName:        "orders",
+	Postgres:      models.PostgresDriver{PsqlUser: "postgres", PsqlPassword: "p\"ss"},
+}
+
+// schema is the part of JSON schema the Kubernetes schemas use
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 interface{}        `json:"type"`
+	Format               string             `json:"format"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties interface{}        `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Required             []string           `json:"required"`
+	Enum                 []string           `json:"enum"`
+	GroupVersionKind     []struct {
+		Group, Version, Kind string
+	} `json:"x-kubernetes-group-version-kind"`
+}
+
+type schemas struct {
+	Definitions map[string]*schema `json:"definitions"`
+}
+
+// loadSchemas reads the OpenAPI schemas of the Kubernetes API, api/openapi-spec/swagger.json of
+// kubernetes v1.31.1 as it is, gzipped
+func loadSchemas(t *testing.T) schemas {
+	file, err := os.Open("testdata/k8s-swagger-v1.31.1.json.gz")
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	var s schemas
+	require.NoError(t, json.NewDecoder(content).Decode(&s))
+	return s
+}
+
+// intOrString are the definitions the API server takes a number or a string for
+var intOrString = map[string]bool{
+	"io.k8s.apimachinery.pkg.util.intstr.IntOrString": true,
+	"io.k8s.apimachinery.pkg.api.resource.Quantity":   true,
+}
+
+// forKind is the schema of the resource with the apiVersion and kind
+func (s schemas) forKind(apiVersion, kind string) *schema {
+	group, version, found := strings.Cut(apiVersion, "/")
+	if !found {
+		group, version = "", apiVersion
+	}
+	for _, definition := range s.Definitions {
+		for _, gvk := range definition.GroupVersionKind {
+			if gvk.Group == group && gvk.Version == version && gvk.Kind == kind {
+				return definition
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns the errors of value against the schema. A patch only holds
+// the fields it changes, so required fields are not checked for it. Like
+// kubeconform -strict, the fields an object does not define are errors.
+func (s schemas) validate(sc *schema, value interface{}, at string, patch bool) []string {
+	if sc.Ref != "" {
+		name := strings.TrimPrefix(sc.Ref, "#/definitions/")
+		if intOrString[name] && hasType([]interface{}{"integer", "number", "string"}, value) {
+			return nil
+		}
+		return s.validate(s.Definitions[name], value, at, patch)
+	}
+	if sc.Format == "int-or-string" && hasType([]interface{}{"integer", "string"}, value) {
+		return nil
+	}
+	if !hasType(sc.Type, value) {
+		return []string{fmt.Sprintf("%s: %v is not %v", at, value, sc.Type)}
+	}
+	if len(sc.Enum) > 0 && !contains(sc.Enum, fmt.Sprint(value)) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, sc.Enum)}
+	}
+
+	var errs []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if !patch {
+			for _, field := range sc.Required {
+				if _, ok := value[field]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: %s is required", at, field))
+				}
+			}
+		}
+		for field, fieldValue := range value {
+			fieldSchema := sc.Properties[field]
+			if fieldSchema == nil {
+				switch additional := sc.AdditionalProperties.(type) {
+				case nil:
+					if sc.Properties != nil {
+						errs = append(errs, fmt.Sprintf("%s: unknown field %s", at, field))
+					}
+					continue
+				case bool:
+					if !additional {
+						errs = append(errs, fmt.Sprintf("%s: unknown field %s", at, field))
+					}
+					continue
+				case map[string]interface{}:
+					content, _ := json.Marshal(additional)
+					fieldSchema = &schema{}
+					json.Unmarshal(content, fieldSchema)
+				default:
+					continue
+				}
+			}
+			errs = append(errs, s.validate(fieldSchema, fieldValue, at+"."+field, patch)...)
+		}
+	case []interface{}:
+		if sc.Items != nil {
+			for i, item := range value {
+				errs = append(errs, s.validate(sc.Items, item, fmt.Sprintf("%s[%d]", at, i), patch)...)
+			}
+		}
+	}
+	return errs
+}
+
+func hasType(types interface{}, value interface{}) bool {
+	var names []string
+	switch types := types.(type) {
+	case nil:
+		return true
+	case string:
+		names = []string{types}
+	case []interface{}:
+		for _, name := range types {
+			names = append(names, name.(string))
+		}
+	}
+	for _, name := range names {
+		switch value.(type) {
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case int:
+			if name == "integer" || name == "number" {
+				return true
+			}
+		case float64:
+			if name == "number" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// generate runs Setup and returns the rendered files by their path under deploy/k8s
+func generate(t *testing.T, dbInputs models.DBInputs) map[string]string {
+	return capture(t, dbInputs.WrkDir+K8sPath+"/", func() error { return Setup(dbInputs) })
+}
+
+// capture returns the files the setup writes, rendered as they are on disk, by their path under dir
+func capture(t *testing.T, dir string, setup func() error) map[string]string {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	files := map[string]string{}
+	mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var rendered bytes.Buffer
+		tmpl := template.Must(template.New("file").Parse(args.String(2)))
+		require.NoError(t, tmpl.Execute(&rendered, args.Get(1)))
+		files[strings.TrimPrefix(args.String(0), dir)] = rendered.String()
+	}).Return(nil)
+
+	require.NoError(t, setup())
+	return files
+}
+
+func decode(t *testing.T, name, content string) map[string]interface{} {
+	var resource map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), &resource), name)
+	return resource
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "order-service", Name("services/Order_Service"))
+	assert.Equal(t, "a-very-long-service-name-that-does-not-f", Name("a_very_long_service_name_that_does_not_fit_a_label"))
+}
+
+func TestSetup_Schemas(t *testing.T) {
+	s := loadSchemas(t)
+	files := generate(t, serviceInputs)
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Len(t, names, len(baseFiles)+len(Overlays)*len(overlayFiles))
+
+	for _, name := range names {
+		resource := decode(t, name, files[name])
+		apiVersion, _ := resource["apiVersion"].(string)
+		kind, _ := resource["kind"].(string)
+		if kind == "Kustomization" {
+			// not a resource of the API, TestSetup_References checks them
+			continue
+		}
+		sc := s.forKind(apiVersion, kind)
+		if !assert.NotNil(t, sc, "%s: no schema for %s %s", name, apiVersion, kind) {
+			continue
+		}
+		patch := strings.HasPrefix(name, "overlays/") && (kind == "Deployment" || kind == "HorizontalPodAutoscaler")
+		assert.Empty(t, s.validate(sc, resource, name, patch))
+	}
+}
+
+func TestSetup_References(t *testing.T) {
+	files := generate(t, serviceInputs)
+
+	// resources of the base by kind/name
+	base := map[string]map[string]interface{}{}
+	for name, content := range files {
+		if strings.HasPrefix(name, "base/") && name != "base/kustomization.yaml" {
+			resource := decode(t, name, content)
+			metadata := resource["metadata"].(map[string]interface{})
+			base[resource["kind"].(string)+"/"+metadata["name"].(string)] = resource
+		}
+	}
+
+	for name, content := range files {
+		if path.Base(name) != "kustomization.yaml" {
+			continue
+		}
+		dir := path.Dir(name)
+		var kustomization struct {
+			Resources []string
+			Patches   []struct{ Path string }
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(content), &kustomization))
+		for _, resource := range kustomization.Resources {
+			target := path.Join(dir, resource)
+			_, file := files[target]
+			_, kustomize := files[target+"/kustomization.yaml"]
+			assert.True(t, file || kustomize, "%s: resource %s does not exist", name, resource)
+		}
+		for _, patch := range kustomization.Patches {
+			resource := decode(t, patch.Path, files[path.Join(dir, patch.Path)])
+			metadata := resource["metadata"].(map[string]interface{})
+			assert.Contains(t, base, resource["kind"].(string)+"/"+metadata["name"].(string), "%s: patch %s matches no resource", name, patch.Path)
+		}
+	}
+
+	data, err := deployData(serviceInputs)
+	require.NoError(t, err)
+	assert.Contains(t, base, "ConfigMap/"+data.Name+"-config")
+	assert.Contains(t, base, "Secret/"+data.Name+"-db")
+	assert.Contains(t, base, "Deployment/"+data.Name)
+	assert.Contains(t, base, "Job/"+data.Name+"-migrate")
+	assert.Contains(t, files["base/deployment.yaml"], "path: /health")
+	assert.Contains(t, files["base/migration-job.yaml"], `args: ["-migrate-only"]`)
+}
+
+func TestDeployData(t *testing.T) {
+	data, err := deployData(serviceInputs)
+	require.NoError(t, err)
+	assert.Equal(t, []models.EnvVar{
+		{Key: "DB_DRIVER", Value: `"postgres"`},
+		{Key: "SECURE", Value: `"false"`},
+		{Key: "AUTH", Value: `"false"`},
+		{Key: "DOCS", Value: `"false"`},
+	}, data.Config)
+	assert.Equal(t, []models.EnvVar{
+		{Key: "DB_SOURCE", Value: `"<DSN of the environment>"`},
+	}, data.Secret)
+
+	// the credentials of app.env, local or external, are not written to the manifests
+	files := generate(t, serviceInputs)
+	secret := decode(t, "secret.yaml", files["base/secret.yaml"])
+	assert.Equal(t, map[string]interface{}{"DB_SOURCE": SecretPlaceholder}, secret["stringData"])
+	for name, content := range files {
+		assert.NotContains(t, content, "p\"ss", name)
+	}
+
+	external := models.DBInputs{WrkDir: "orders", DBMS: "mysql", External: true, DSN: "app:secret@tcp(db.internal:3306)/orders"}
+	data, err = deployData(external)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Quote(SecretPlaceholder), data.Secret[0].Value)
+}
+
+func TestValidate_Typo(t *testing.T) {
+	s := loadSchemas(t)
+	resource := decode(t, "deployment.yaml", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: orders
+spec:
+  selector:
+    matchLabels:
+      app: orders
+  template:
+    spec:
+      containers:
+        - name: api
+          readinessProbes:
+            httpGet:
+              path: /health
+          ports:
+            - containerPort: "8080"
+`)
+	errs := s.validate(s.forKind("apps/v1", "Deployment"), resource, "deployment.yaml", false)
+	assert.ElementsMatch(t, []string{
+		"deployment.yaml.spec.template.spec.containers[0]: unknown field readinessProbes",
+		"deployment.yaml.spec.template.spec.containers[0].ports[0].containerPort: 8080 is not integer",
+	}, errs)
+}
+
+func TestSetup_Error(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+	mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", "orders/deploy/k8s/base/kustomization.yaml", mock.Anything, baseKustomization).Return(assert.AnError)
+
+	err := Setup(models.DBInputs{WrkDir: "orders", DBMS: "postgres"})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, err = deployData(models.DBInputs{WrkDir: "orders", DBMS: "sqlite"})
+	assert.EqualError(t, err, "driver not supported")
+}
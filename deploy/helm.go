@@ -0,0 +1,377 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var HelmPath = "/deploy/helm/"
+
+// helmBraces keeps the actions of the chart templates as they are, Helm renders them and not the generator
+var helmBraces = strings.NewReplacer("{{", `{{"{{"}}`, "}}", `{{"}}"}}`)
+
+var chartFiles = []file{
+	{"/Chart.yaml", chartYAML},
+	{"/values.yaml", chartValues},
+	{"/.helmignore", helmIgnore},
+	{"/templates/_helpers.tpl", chartHelpers},
+	{"/templates/configmap.yaml", chartConfigMap},
+	{"/templates/secret.yaml", chartSecret},
+	{"/templates/deployment.yaml", chartDeployment},
+	{"/templates/service.yaml", chartService},
+	{"/templates/ingress.yaml", chartIngress},
+	{"/templates/migration-secret.yaml", chartMigrationSecret},
+	{"/templates/migration-job.yaml", chartMigrationJob},
+}
+
+// SetupHelm writes the Helm chart of the service to deploy/helm/<name>
+func SetupHelm(dbInputs models.DBInputs) error {
+	data, err := deployData(dbInputs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n*** Creating the Helm chart ***")
+	root := dbInputs.WrkDir + HelmPath + data.Name
+	for _, dir := range []string{root, root + "/templates"} {
+		if err = common.CreateDirectory(dir); err != nil {
+			return err
+		}
+	}
+	for _, f := range chartFiles {
+		// the generator fills in [[ ]], the {{ }} of Helm are written as they are
+		var rendered bytes.Buffer
+		err = template.Must(template.New(f.path).Delims("[[", "]]").Parse(f.content)).Execute(&rendered, data)
+		if err != nil {
+			return err
+		}
+		if err = common.CreateFileAndItsContent(root+f.path, nil, helmBraces.Replace(rendered.String())); err != nil {
+			return err
+		}
+	}
+	fmt.Println("\n*** Successfully created the Helm chart ***")
+	return nil
+}
+
+const chartYAML = `# Generated By API Service Generator
+apiVersion: v2
+name: [[.Name]]
+description: Helm chart of the [[.Name]] API service
+type: application
+version: 0.1.0
+appVersion: "0.1.0"
+`
+
+const chartValues = `# Generated By API Service Generator
+image:
+  repository: [[.Name]]
+  tag: latest
+  pullPolicy: IfNotPresent
+
+replicaCount: 1
+
+resources:
+  requests:
+    cpu: 100m
+    memory: 64Mi
+  limits:
+    cpu: 500m
+    memory: 256Mi
+
+service:
+  type: ClusterIP
+  port: 80
+
+ingress:
+  enabled: false
+  className: ""
+  annotations: {}
+  host: [[.Name]].local
+  # name of the TLS secret of the host, no TLS when empty
+  tlsSecret: ""
+
+# keys of util.Config that are not secret, the image has no app.env
+config:
+[[- range .Config]]
+  [[.Key]]: [[.Value]]
+[[- end]]
+
+database:
+  # DB_SOURCE of util.Config, a placeholder: set the DSN of the release or name
+  # an existing Secret that holds it.
+  source: [[range .Secret]][[if eq .Key "DB_SOURCE"]][[.Value]][[end]][[end]]
+  existingSecret: ""
+  existingSecretKey: DB_SOURCE
+
+migrations:
+  # migrate the database in a pre-install and pre-upgrade hook
+  enabled: true
+  backoffLimit: 3
+`
+
+const helmIgnore = `# Generated By API Service Generator
+.DS_Store
+.git/
+*.swp
+*.bak
+*.tmp
+`
+
+const chartHelpers = `{{/* Generated By API Service Generator */}}
+
+{{- define "[[.Name]].name" -}}
+{{- .Chart.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/* the release name, followed by the chart name when they differ */}}
+{{- define "[[.Name]].fullname" -}}
+{{- if eq .Release.Name .Chart.Name }}
+{{- .Release.Name | trunc 63 | trimSuffix "-" }}
+{{- else }}
+{{- printf "%s-%s" .Release.Name .Chart.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+{{- end }}
+
+{{- define "[[.Name]].labels" -}}
+helm.sh/chart: {{ printf "%s-%s" .Chart.Name .Chart.Version }}
+{{ include "[[.Name]].selectorLabels" . }}
+app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- end }}
+
+{{- define "[[.Name]].selectorLabels" -}}
+app.kubernetes.io/name: {{ include "[[.Name]].name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
+
+{{- define "[[.Name]].image" -}}
+{{- printf "%s:%s" .Values.image.repository .Values.image.tag }}
+{{- end }}
+
+{{/* the Secret and its key that hold DB_SOURCE */}}
+{{- define "[[.Name]].secretName" -}}
+{{- default (printf "%s-db" (include "[[.Name]].fullname" .)) .Values.database.existingSecret }}
+{{- end }}
+
+{{- define "[[.Name]].secretKey" -}}
+{{- if .Values.database.existingSecret }}
+{{- .Values.database.existingSecretKey }}
+{{- else }}
+{{- "DB_SOURCE" }}
+{{- end }}
+{{- end }}
+
+{{/* the image runs as the distroless nonroot user */}}
+{{- define "[[.Name]].podSecurityContext" -}}
+runAsNonRoot: true
+runAsUser: 65532
+runAsGroup: 65532
+seccompProfile:
+  type: RuntimeDefault
+{{- end }}
+
+{{- define "[[.Name]].securityContext" -}}
+allowPrivilegeEscalation: false
+readOnlyRootFilesystem: true
+capabilities:
+  drop: ["ALL"]
+{{- end }}
+`
+
+const chartConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+data:
+  {{- range $key, $value := .Values.config }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+`
+
+const chartSecret = `{{- if not .Values.database.existingSecret }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ include "[[.Name]].secretName" . }}
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+type: Opaque
+stringData:
+  DB_SOURCE: {{ required "database.source is required without database.existingSecret" .Values.database.source | quote }}
+{{- end }}
+`
+
+const chartDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.replicaCount }}
+  selector:
+    matchLabels:
+      {{- include "[[.Name]].selectorLabels" . | nindent 6 }}
+  template:
+    metadata:
+      labels:
+        {{- include "[[.Name]].selectorLabels" . | nindent 8 }}
+      annotations:
+        # rolls the pods when the config changes
+        checksum/config: {{ include (print $.Template.BasePath "/configmap.yaml") . | sha256sum }}
+    spec:
+      securityContext:
+        {{- include "[[.Name]].podSecurityContext" . | nindent 8 }}
+      containers:
+        - name: api
+          image: {{ include "[[.Name]].image" . }}
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          ports:
+            - name: http
+              containerPort: 8080
+          envFrom:
+            - configMapRef:
+                name: {{ include "[[.Name]].fullname" . }}
+          env:
+            - name: DB_SOURCE
+              valueFrom:
+                secretKeyRef:
+                  name: {{ include "[[.Name]].secretName" . }}
+                  key: {{ include "[[.Name]].secretKey" . }}
+          livenessProbe:
+            httpGet:
+              path: /health
+              port: http
+            initialDelaySeconds: 10
+            periodSeconds: 10
+          readinessProbe:
+            httpGet:
+              path: /health
+              port: http
+            periodSeconds: 5
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+          securityContext:
+            {{- include "[[.Name]].securityContext" . | nindent 12 }}
+`
+
+const chartService = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+spec:
+  type: {{ .Values.service.type }}
+  selector:
+    {{- include "[[.Name]].selectorLabels" . | nindent 4 }}
+  ports:
+    - name: http
+      port: {{ .Values.service.port }}
+      targetPort: http
+`
+
+const chartIngress = `{{- if .Values.ingress.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+  {{- with .Values.ingress.annotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  {{- if .Values.ingress.className }}
+  ingressClassName: {{ .Values.ingress.className }}
+  {{- end }}
+  {{- if .Values.ingress.tlsSecret }}
+  tls:
+    - hosts:
+        - {{ .Values.ingress.host | quote }}
+      secretName: {{ .Values.ingress.tlsSecret }}
+  {{- end }}
+  rules:
+    - host: {{ .Values.ingress.host | quote }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ include "[[.Name]].fullname" . }}
+                port:
+                  name: http
+{{- end }}
+`
+
+// chartMigrationSecret is DB_SOURCE for the migration hook, which runs before the Secret of the release exists.
+// Helm does not manage hooks, so it is deleted once the hooks succeeded, like the Job.
+const chartMigrationSecret = `{{- if and .Values.migrations.enabled (not .Values.database.existingSecret) }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}-migrate
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+  annotations:
+    helm.sh/hook: pre-install,pre-upgrade
+    helm.sh/hook-weight: "-5"
+    helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
+type: Opaque
+stringData:
+  DB_SOURCE: {{ required "database.source is required without database.existingSecret" .Values.database.source | quote }}
+{{- end }}
+`
+
+const chartMigrationJob = `{{- if .Values.migrations.enabled }}
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ include "[[.Name]].fullname" . }}-migrate
+  labels:
+    {{- include "[[.Name]].labels" . | nindent 4 }}
+  annotations:
+    helm.sh/hook: pre-install,pre-upgrade
+    helm.sh/hook-weight: "0"
+    helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
+spec:
+  backoffLimit: {{ .Values.migrations.backoffLimit }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ include "[[.Name]].name" . }}-migrate
+        app.kubernetes.io/instance: {{ .Release.Name }}
+    spec:
+      restartPolicy: Never
+      securityContext:
+        {{- include "[[.Name]].podSecurityContext" . | nindent 8 }}
+      containers:
+        - name: migrate
+          image: {{ include "[[.Name]].image" . }}
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          args: ["-migrate-only"]
+          env:
+            {{- range $key, $value := .Values.config }}
+            - name: {{ $key }}
+              value: {{ $value | quote }}
+            {{- end }}
+            - name: DB_SOURCE
+              valueFrom:
+                secretKeyRef:
+                  name: {{ default (printf "%s-migrate" (include "[[.Name]].fullname" .)) .Values.database.existingSecret }}
+                  key: {{ include "[[.Name]].secretKey" . }}
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+          securityContext:
+            {{- include "[[.Name]].securityContext" . | nindent 12 }}
+{{- end }}
+`
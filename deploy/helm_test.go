@@ -0,0 +1,136 @@
+package deploy
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the rendered chart")
+
+// renderChart renders the chart with helm template, the test is skipped when helm is not installed
+func renderChart(t *testing.T, files map[string]string, release, override string) (string, error) {
+	helm, err := exec.LookPath("helm")
+	if err != nil {
+		t.Skip("helm is not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(path.Dir(dir+"/chart/"+name), 0755))
+		require.NoError(t, os.WriteFile(dir+"/chart/"+name, []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(dir+"/values.yaml", []byte(override), 0644))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(helm, "template", release, dir+"/chart", "--namespace", "default", "--values", dir+"/values.yaml")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return string(out), nil
+}
+
+func generateChart(t *testing.T) map[string]string {
+	return capture(t, serviceInputs.WrkDir+HelmPath+"order-service/", func() error { return SetupHelm(serviceInputs) })
+}
+
+func TestSetupHelm_Golden(t *testing.T) {
+	s := loadSchemas(t)
+	files := generateChart(t)
+	assert.Len(t, files, len(chartFiles))
+
+	tests := []struct {
+		name    string
+		values  string
+		sources []string
+	}{
+		{"default", "", []string{"secret", "configmap", "service", "deployment", "migration-secret", "migration-job"}},
+		{"ingress-existing-secret", `
+replicaCount: 3
+ingress:
+  enabled: true
+  className: nginx
+  annotations:
+    nginx.ingress.kubernetes.io/proxy-body-size: 8m
+  host: orders.example.com
+  tlsSecret: orders-tls
+database:
+  existingSecret: orders-db
+  existingSecretKey: dsn
+`, []string{"configmap", "service", "deployment", "ingress", "migration-job"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := renderChart(t, files, "orders", tt.values)
+			require.NoError(t, err)
+
+			golden := "testdata/helm/" + tt.name + ".golden"
+			if *update {
+				require.NoError(t, os.MkdirAll(path.Dir(golden), 0755))
+				require.NoError(t, os.WriteFile(golden, []byte(rendered), 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err, "run go test ./deploy -update to write the golden files")
+			assert.Equal(t, string(expected), rendered)
+
+			var sources []string
+			for _, manifest := range strings.Split(rendered, "---\n")[1:] {
+				source := strings.TrimSuffix(path.Base(strings.SplitN(manifest, "\n", 2)[0]), ".yaml")
+				sources = append(sources, source)
+				resource := decode(t, source, manifest)
+				sc := s.forKind(resource["apiVersion"].(string), resource["kind"].(string))
+				require.NotNil(t, sc, source)
+				assert.Empty(t, s.validate(sc, resource, source, false))
+			}
+			assert.Equal(t, tt.sources, sources)
+		})
+	}
+}
+
+func TestSetupHelm_Hooks(t *testing.T) {
+	rendered, err := renderChart(t, generateChart(t), "orders", "")
+	require.NoError(t, err)
+
+	hooks := map[string]interface{}{}
+	for _, manifest := range strings.Split(rendered, "---\n")[1:] {
+		resource := decode(t, "manifest", manifest)
+		metadata := resource["metadata"].(map[string]interface{})
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			hooks[resource["kind"].(string)] = annotations["helm.sh/hook-weight"]
+			assert.Equal(t, "pre-install,pre-upgrade", annotations["helm.sh/hook"])
+			// hooks are not managed by the release, they are deleted once they succeeded
+			assert.Equal(t, "before-hook-creation,hook-succeeded", annotations["helm.sh/hook-delete-policy"])
+		}
+	}
+	// the secret of the hook is there before the job runs
+	assert.Equal(t, map[string]interface{}{"Secret": "-5", "Job": "0"}, hooks)
+
+	_, err = renderChart(t, generateChart(t), "orders", "database:\n  source: \"\"\n")
+	assert.ErrorContains(t, err, "database.source is required without database.existingSecret")
+
+	rendered, err = renderChart(t, generateChart(t), "orders", "migrations:\n  enabled: false\n")
+	require.NoError(t, err)
+	assert.NotContains(t, rendered, "helm.sh/hook")
+}
+
+func TestSetupHelm_Values(t *testing.T) {
+	files := generateChart(t)
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(files["values.yaml"]), &values))
+
+	// the keys of util.Config, DB_SOURCE is kept out of the ConfigMap
+	assert.Equal(t, map[string]interface{}{"DB_DRIVER": "postgres", "SECURE": "false", "AUTH": "false", "DOCS": "false"}, values["config"])
+	assert.Equal(t, SecretPlaceholder, values["database"].(map[string]interface{})["source"])
+	assert.NotContains(t, files["values.yaml"], "p\"ss")
+	assert.Contains(t, files["templates/_helpers.tpl"], `{{- define "order-service.fullname" -}}`)
+}
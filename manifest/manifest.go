@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/models"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	FileName = "/api-service-generator.yaml"
+	ReadFile = os.ReadFile
+)
+
+const header = `# Generated By API Service Generator
+# read by the api-service-generator db commands, it holds the database credentials like app.env
+`
+
+// Write records the database of the generated service in its manifest
+func Write(dbInputs models.DBInputs) error {
+	content, err := common.MarshalYAML(New(dbInputs))
+	if err != nil {
+		return fmt.Errorf("error in marshalling the manifest")
+	}
+	return common.CreateFileAndItsContent(dbInputs.WrkDir+FileName, nil, header+string(content))
+}
+
+// New is the manifest of the service generated from dbInputs
+func New(dbInputs models.DBInputs) models.Manifest {
+	manifest := models.Manifest{
+		Project: docker.ProjectName(dbInputs.WrkDir),
+		Runtime: dbInputs.Runtime,
+		Database: models.ManifestDatabase{
+			Driver:   dbInputs.DBMS,
+			External: dbInputs.External,
+		},
+	}
+	if manifest.Runtime == "" {
+		manifest.Runtime = "docker"
+	}
+	if dbInputs.External {
+		return manifest
+	}
+
+	database := dbInputs.Table.Database
+	manifest.Database = models.ManifestDatabase{
+		Driver:      dbInputs.DBMS,
+		Container:   dbInputs.ContainerName,
+		Reused:      dbInputs.ReuseContainer,
+		Image:       docker.Image(dbInputs),
+		Port:        dbInputs.ContainerPort,
+		Name:        strings.ToLower(dbInputs.DBName),
+		Env:         database.Env,
+		Args:        database.Args,
+		Memory:      database.Memory,
+		MemoryBytes: database.MemoryBytes,
+		CPUs:        database.CPUs,
+		InitScripts: docker.InitScripts(dbInputs),
+	}
+	switch dbInputs.DBMS {
+	case "postgres":
+		manifest.Database.User = dbInputs.Postgres.PsqlUser
+		manifest.Database.Password = dbInputs.Postgres.PsqlPassword
+	case "mysql":
+		manifest.Database.User = dbInputs.MySQL.MysqlUser
+		manifest.Database.Password = dbInputs.MySQL.MysqlPassword
+		manifest.Database.RootPassword = dbInputs.MySQL.MysqlRootPassword
+	}
+	return manifest
+}
+
+// Read reads the manifest of the service in dir
+func Read(dir string) (manifest models.Manifest, err error) {
+	content, err := ReadFile(dir + FileName)
+	if err != nil {
+		return manifest, fmt.Errorf("no manifest in %s, run the command in a generated service or pass --dir: %w", dir, err)
+	}
+	if err = yaml.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("error parsing %s: %w", dir+FileName, err)
+	}
+	if manifest.Database.Driver == "" || (!manifest.Database.External && manifest.Database.Container == "") {
+		return manifest, fmt.Errorf("%s has no database container", dir+FileName)
+	}
+	return manifest, nil
+}
+
+// DBInputs are the inputs the service in dir was generated with, as far as the database needs them
+func DBInputs(manifest models.Manifest, dir string) models.DBInputs {
+	// absolute, so the project name follows the directory and not "."
+	wrkDir, err := filepath.Abs(dir)
+	if err != nil {
+		wrkDir = dir
+	}
+
+	database := manifest.Database
+	dbInputs := models.DBInputs{
+		WrkDir:         wrkDir,
+		DBMS:           database.Driver,
+		External:       database.External,
+		ContainerName:  database.Container,
+		ContainerPort:  database.Port,
+		DBName:         database.Name,
+		ReuseContainer: database.Reused,
+		Runtime:        manifest.Runtime,
+	}
+	switch database.Driver {
+	case "postgres":
+		dbInputs.Postgres = models.PostgresDriver{PsqlUser: database.User, PsqlPassword: database.Password}
+	case "mysql":
+		dbInputs.MySQL = models.MySQLDriver{MysqlRootPassword: database.RootPassword, MysqlUser: database.User, MysqlPassword: database.Password}
+	}
+
+	if database.Image != "" {
+		name, tag := docker.SplitImage(database.Image)
+		dbInputs.Table.Database = models.DatabaseSpec{
+			Image:       name,
+			Tag:         tag,
+			Env:         database.Env,
+			Args:        database.Args,
+			Memory:      database.Memory,
+			MemoryBytes: database.MemoryBytes,
+			CPUs:        database.CPUs,
+			InitScripts: database.InitScripts,
+		}
+	}
+	return dbInputs
+}
@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/db/docker"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+var mysqlInputs = models.DBInputs{
+	WrkDir:        "services/orders",
+	DBMS:          "mysql",
+	ContainerName: "orders_db",
+	ContainerPort: 3307,
+	DBName:        "Orders",
+	Runtime:       "podman",
+	MySQL: models.MySQLDriver{
+		MysqlRootPassword: "root-secret",
+		MysqlUser:         "orders",
+		MysqlPassword:     "secret",
+	},
+	Table: models.TableSpec{Database: models.DatabaseSpec{
+		Tag:         "8.4.2",
+		Args:        []string{"--max-connections=200"},
+		Memory:      "1g",
+		MemoryBytes: 1 << 30,
+		InitScripts: []string{"specs/users.sql"},
+	}},
+}
+
+func TestNew(t *testing.T) {
+	manifest := New(mysqlInputs)
+	assert.Equal(t, models.Manifest{
+		Project: docker.ProjectName(mysqlInputs.WrkDir),
+		Runtime: "podman",
+		Database: models.ManifestDatabase{
+			Driver:       "mysql",
+			Container:    "orders_db",
+			Image:        "mysql:8.4.2",
+			Port:         3307,
+			Name:         "orders",
+			User:         "orders",
+			Password:     "secret",
+			RootPassword: "root-secret",
+			Args:         []string{"--max-connections=200"},
+			Memory:       "1g",
+			MemoryBytes:  1 << 30,
+			InitScripts:  []string{"docker/init/01_users.sql"},
+		},
+	}, manifest)
+
+	external := New(models.DBInputs{WrkDir: "orders", DBMS: "postgres", DSN: "postgres://app@db/orders", External: true})
+	assert.Equal(t, models.Manifest{Project: docker.ProjectName("orders"), Runtime: "docker", Database: models.ManifestDatabase{Driver: "postgres", External: true}}, external)
+}
+
+func TestWrite(t *testing.T) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	content, _ := yaml.Marshal(New(mysqlInputs))
+	mockCmdsExecutor.On("CreateFileAndItsContent", "services/orders/api-service-generator.yaml", nil, header+string(content)).Return(nil)
+
+	err := Write(mysqlInputs)
+	assert.NoError(t, err)
+
+	mockCmdsExecutor.AssertExpectations(t)
+}
+
+func TestRead_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	content, _ := yaml.Marshal(New(mysqlInputs))
+	require.NoError(t, os.WriteFile(dir+FileName, append([]byte(header), content...), 0644))
+
+	manifest, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, New(mysqlInputs), manifest)
+
+	dbInputs := DBInputs(manifest, dir)
+	assert.Equal(t, dir, dbInputs.WrkDir)
+	assert.Equal(t, "mysql", dbInputs.DBMS)
+	assert.Equal(t, "orders_db", dbInputs.ContainerName)
+	assert.Equal(t, 3307, dbInputs.ContainerPort)
+	assert.Equal(t, "orders", dbInputs.DBName)
+	assert.Equal(t, mysqlInputs.MySQL, dbInputs.MySQL)
+	assert.Equal(t, "podman", dbInputs.Runtime)
+	assert.Equal(t, models.DatabaseSpec{
+		Image:       "mysql",
+		Tag:         "8.4.2",
+		Args:        []string{"--max-connections=200"},
+		Memory:      "1g",
+		MemoryBytes: 1 << 30,
+		InitScripts: []string{"docker/init/01_users.sql"},
+	}, dbInputs.Table.Database)
+}
+
+func TestRead_Errors(t *testing.T) {
+	defer func() { ReadFile = os.ReadFile }()
+
+	ReadFile = func(name string) ([]byte, error) {
+		return nil, errors.New("no such file")
+	}
+	_, err := Read("orders")
+	assert.ErrorContains(t, err, "no manifest in orders")
+
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte("database: ["), nil
+	}
+	_, err = Read("orders")
+	assert.ErrorContains(t, err, "error parsing orders/api-service-generator.yaml")
+
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte("database:\n  driver: postgres\n"), nil
+	}
+	_, err = Read("orders")
+	assert.ErrorContains(t, err, "has no database container")
+}
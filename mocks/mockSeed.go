@@ -0,0 +1,19 @@
+package mocks
+
+import (
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSeed struct {
+	mock.Mock
+}
+
+func NewMockSeed() *MockSeed {
+	return new(MockSeed)
+}
+
+func (m *MockSeed) SetSeeds(dbInputs models.DBInputs, initSchema models.InitSchema) error {
+	args := m.Called(dbInputs, initSchema)
+	return args.Error(0)
+}
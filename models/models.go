@@ -1,17 +1,33 @@
 package models
 
+import "time"
+
 // Inputs from the CLI for DB
 type DBInputs struct {
-	WrkDir        string
-	GoModule      string
-	ContainerName string
-	ContainerPort int
-	DBMS          string
-	DBName        string
-	DriverPackage string
-	Postgres      PostgresDriver
-	MySQL         MySQLDriver
-	TableName     string
+	WrkDir         string
+	GoModule       string
+	ContainerName  string
+	ContainerPort  int
+	DBMS           string
+	DBName         string
+	DriverPackage  string
+	Postgres       PostgresDriver
+	MySQL          MySQLDriver
+	TableName      string
+	SeedRows       int
+	Table          TableSpec
+	WaitTimeout    time.Duration
+	WaitInterval   time.Duration
+	ReuseContainer bool
+	DSN            string
+	External       bool
+	Runtime        string
+	// serve the Service over gRPC on GRPC_PORT
+	GRPC bool
+	// serve the tables over GraphQL at /graphql
+	GraphQL bool
+	// the tables of a spec with groups, in the order of their references, nil for the Table above
+	Tables []Table
 }
 
 // Postgres
@@ -20,7 +36,7 @@ type PostgresDriver struct {
 	PsqlPassword string
 }
 
-//MySQL
+// MySQL
 type MySQLDriver struct {
 	MysqlRootPassword string
 	MysqlUser         string
@@ -35,14 +51,265 @@ type APIInputs struct {
 	APIGroupTitle  string
 	TableName      string
 	TableNameTitle string
+	DBMS           string
+	Table          TableSpec
+	// --transport: GRPC serves the Service over gRPC as well, GRPCOnly leaves out the REST routes
+	GRPC     bool
+	GRPCOnly bool
+	// --graphql: the Service gets the batch methods of the GraphQL loaders
+	GraphQL bool
+	// --framework: the router of the controllers, the middleware and main.go, Gin when empty
+	Framework string
+	// the route tree a route of Routes is served under, v1 when empty
+	Version string
+	// the groups and versions of a spec with groups, nil serves the group above under /v1
+	Routes *APIRoutes
+}
+
+// Groups and versioned route trees of a spec with groups
+type APIRoutes struct {
+	Groups   []APIInputs
+	Versions []APIVersion
+	// a version has the Deprecation or Sunset header
+	Deprecated bool
+}
+
+// Route tree of the service, /v1, /v2, with the headers of a deprecated version
+type APIVersion struct {
+	Name        string `yaml:"name"`
+	Deprecation string `yaml:"deprecation"`
+	Sunset      string `yaml:"sunset"`
+	Link        string `yaml:"link"`
+	// the values of the Deprecation, Sunset and Link headers, empty when not set
+	DeprecationHeader string     `yaml:"-"`
+	SunsetHeader      string     `yaml:"-"`
+	LinkHeader        string     `yaml:"-"`
+	Groups            []APIRoute `yaml:"-"`
+}
+
+// Group served under a version. The package of a version after v1 is api/<Version>/<group>,
+// its Resource embeds the one of Previous and defines the handlers of Overrides.
+type APIRoute struct {
+	APIInputs
+	Previous   string
+	Overrides  []string
+	Deprecated bool
+}
+
+// Spec with tables and groups, read from the --spec file
+type APISpec struct {
+	Tables   []Table        `yaml:"tables"`
+	Groups   []APIGroupSpec `yaml:"groups"`
+	Versions []APIVersion   `yaml:"versions"`
+	Database DatabaseSpec   `yaml:"database"`
+}
+
+// Table of a spec with tables
+type Table struct {
+	Name      string `yaml:"name"`
+	TableSpec `yaml:",inline"`
+}
+
+// API group of a spec with groups, served under Versions, v1 when empty.
+// Overrides names the handlers a version redefines: list, get, create, update or delete.
+type APIGroupSpec struct {
+	Name      string              `yaml:"name"`
+	Table     string              `yaml:"table"`
+	Versions  []string            `yaml:"versions"`
+	Overrides map[string][]string `yaml:"overrides"`
+}
+
+// api/openapi.yaml of the service, Tables holds one group of each table for the schemas of the rows
+// and Routes the groups under each version for the paths
+type OpenAPI struct {
+	Title  string
+	Groups []APIInputs
+	Tables []APIInputs
+	Routes []APIRoute
 }
 
 // Table details
 type InitSchema struct {
-	TableName string
-	WrkDir    string
+	TableName      string
+	TableNameTitle string
+	WrkDir         string
+	DBMS           string
+	Table          TableSpec
+	// the batch queries of the GraphQL loaders
+	GraphQL bool
+	// every table of a spec with tables, the first one is the table above
+	Tables []InitSchema
+}
+
+// Table specification, read from the --spec file
+type TableSpec struct {
+	PrimaryKey  string       `yaml:"primary_key"`
+	Columns     []Column     `yaml:"columns"`
+	Timestamps  bool         `yaml:"timestamps"`
+	SoftDelete  bool         `yaml:"soft_delete"`
+	Versioned   bool         `yaml:"versioned"`
+	Indexes     []Index      `yaml:"indexes"`
+	Constraints []Constraint `yaml:"constraints"`
+	Database    DatabaseSpec `yaml:"database"`
+	Key         Key          `yaml:"-"`
+	Imports     []string     `yaml:"-"`
+	TestImports []string     `yaml:"-"`
+	Packages    []string     `yaml:"-"`
+	// RequiredList names the columns the request binding requires, comma separated
+	RequiredList string `yaml:"-"`
 }
 
+// Database container settings, the default image of the driver is used when Image is empty
+type DatabaseSpec struct {
+	Image       string            `yaml:"image"`
+	Tag         string            `yaml:"tag"`
+	Env         map[string]string `yaml:"env"`
+	Args        []string          `yaml:"args"`
+	Memory      string            `yaml:"memory"`
+	CPUs        float64           `yaml:"cpus"`
+	InitScripts []string          `yaml:"init_scripts"`
+	MemoryBytes int64             `yaml:"-"`
+}
+
+// Index on the generated table, Where makes it a partial index (postgres only)
+type Index struct {
+	Name       string   `yaml:"name"`
+	Columns    []string `yaml:"columns"`
+	Unique     bool     `yaml:"unique"`
+	Where      string   `yaml:"where"`
+	ColumnList string   `yaml:"-"`
+}
+
+// Table constraint, either a unique constraint over columns or a check
+type Constraint struct {
+	Name       string   `yaml:"name"`
+	Unique     []string `yaml:"unique"`
+	Check      string   `yaml:"check"`
+	ColumnList string   `yaml:"-"`
+}
+
+// Column of the generated table, besides the primary key
+type Column struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Nullable bool     `yaml:"nullable"`
+	Values   []string `yaml:"values"`
+	// References names the table whose id the column is a foreign key to
+	References string `yaml:"references"`
+	ValueList  string `yaml:"-"`
+	GoType     string `yaml:"-"`
+	FieldName  string `yaml:"-"`
+	Binding    string `yaml:"-"`
+	Sample     string `yaml:"-"`
+	Example    string `yaml:"-"`
+	Schema     string `yaml:"-"`
+	// Parent is the title of the referenced table when the spec declares it, the table
+	// test creates a row of it for the column
+	Parent string `yaml:"-"`
+}
+
+// Primary key of the generated table, resolved for the driver
+type Key struct {
+	Definition   string
+	GoType       string
+	AppGenerated bool
+}
+
+// Existing docker container, as reported by docker inspect
+type Container struct {
+	Image   string
+	Env     []string
+	Port    int
+	Running bool
+}
+
+// docker-compose.yml of the generated service
+type Compose struct {
+	DBInputs
+	Project     string
+	Database    string
+	Image       string
+	GoImage     string
+	Env         map[string]string
+	Command     string
+	InitScripts bool
+}
+
+// Manifest of a generated service, the db commands read the database settings from it
+type Manifest struct {
+	Project  string           `yaml:"project"`
+	Runtime  string           `yaml:"runtime"`
+	Database ManifestDatabase `yaml:"database"`
+}
+
+// Database of a generated service as it was set up, the container settings are empty when it is external
+type ManifestDatabase struct {
+	Driver       string            `yaml:"driver"`
+	External     bool              `yaml:"external,omitempty"`
+	Container    string            `yaml:"container,omitempty"`
+	Reused       bool              `yaml:"reused,omitempty"`
+	Image        string            `yaml:"image,omitempty"`
+	Port         int               `yaml:"port,omitempty"`
+	Name         string            `yaml:"name,omitempty"`
+	User         string            `yaml:"user,omitempty"`
+	Password     string            `yaml:"password,omitempty"`
+	RootPassword string            `yaml:"root_password,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty"`
+	Args         []string          `yaml:"args,omitempty"`
+	Memory       string            `yaml:"memory,omitempty"`
+	MemoryBytes  int64             `yaml:"memory_bytes,omitempty"`
+	CPUs         float64           `yaml:"cpus,omitempty"`
+	InitScripts  []string          `yaml:"init_scripts,omitempty"`
+}
+
+// Snapshot of the database of a generated service, saved next to its dump
+type Snapshot struct {
+	Name     string    `yaml:"name"`
+	Driver   string    `yaml:"driver"`
+	Database string    `yaml:"database"`
+	Image    string    `yaml:"image"`
+	Version  uint      `yaml:"version"`
+	Dirty    bool      `yaml:"dirty,omitempty"`
+	File     string    `yaml:"file"`
+	Created  time.Time `yaml:"created"`
+}
+
+// Variable of app.env
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+// Kubernetes manifests of a generated service
+type Deploy struct {
+	// Name of the resources, a DNS label
+	Name string
+	// app.env split into the ConfigMap and the Secret, the values quoted for YAML
+	Config []EnvVar
+	Secret []EnvVar
+	// Overlay is set while the files of an overlay are written
+	Overlay Overlay
+}
+
+// Kustomize overlay of an environment
+type Overlay struct {
+	Env           string
+	MinReplicas   int
+	MaxReplicas   int
+	RequestCPU    string
+	RequestMemory string
+	LimitCPU      string
+	LimitMemory   string
+}
+
+// Seed fixture for a table
+type SeedData struct {
+	TableName string
+	DBMS      string
+	Columns   string
+	Rows      []string
+	Sequence  bool
+}
 
 // SQLC YAML File
 type SQLCYAML struct {
@@ -51,12 +318,25 @@ type SQLCYAML struct {
 }
 
 type Packages struct {
-	Name          string `yaml:"name"`
-	Path          string `yaml:"path"`
-	Queries       string `yaml:"queries"`
-	Schema        string `yaml:"schema"`
-	Engine        string `yaml:"engine"`
-	EmitInterface bool   `yaml:"emit_interface"`
+	Name          string     `yaml:"name"`
+	Path          string     `yaml:"path"`
+	Queries       string     `yaml:"queries"`
+	Schema        string     `yaml:"schema"`
+	Engine        string     `yaml:"engine"`
+	EmitInterface bool       `yaml:"emit_interface"`
+	Overrides     []Override `yaml:"overrides,omitempty"`
+}
+
+type Override struct {
+	DBType string       `yaml:"db_type,omitempty"`
+	Column string       `yaml:"column,omitempty"`
+	GoType OverrideType `yaml:"go_type"`
+}
+
+type OverrideType struct {
+	Import  string `yaml:"import,omitempty"`
+	Type    string `yaml:"type"`
+	Pointer bool   `yaml:"pointer,omitempty"`
 }
 
 type UnitTestData struct {
@@ -66,3 +346,155 @@ type UnitTestData struct {
 	TableName   string
 	TableObject string
 }
+
+// gRPC transport of an API group, the messages of its proto and the Go package protoc writes them to
+type GRPC struct {
+	APIInputs
+	Package string
+	KeyType string
+	// Go name of the input field of the create and update requests
+	InputName string
+	Row       []ProtoField
+	Input     []ProtoField
+	Imports   []string
+	Timestamp bool
+}
+
+// Field of a proto message. ToProto and FromProto are the Go conversions of a value, the value replaces %s.
+type ProtoField struct {
+	Name      string
+	GoName    string
+	FieldName string
+	Type      string
+	Number    int
+	ToProto   string
+	FromProto string
+}
+
+// Service generated from an OpenAPI document by from-openapi
+type OpenAPIService struct {
+	Module  string
+	SQLC    bool
+	Schemas []GoType
+	Groups  []OpenAPIGroup
+	Imports []string
+}
+
+// Operations of a tag of the document, in the package api/v1/<Package>
+type OpenAPIGroup struct {
+	Module     string
+	SQLC       bool
+	Tag        string
+	Package    string
+	Title      string
+	Operations []Operation
+	Types      []GoType
+	// operations of the document without a method on the service of service_impl.go
+	Stubs []Operation
+	// imports of the files of the package
+	TypesImports      []string
+	ControllerImports []string
+	ServiceImports    []string
+	ImplImports       []string
+	StubsImports      []string
+}
+
+// Operation of the document, a Gin handler and a method of the Service of its group
+type Operation struct {
+	Name    string
+	Service string
+	Method  string
+	Path    string
+	Route   string
+	Summary string
+	// types of the request and of the success response, empty when there is none
+	Request  string
+	Response string
+	Status   string
+	URI      bool
+	Query    bool
+	Header   bool
+	Body     bool
+	// an optional body may be missing
+	BodyRequired bool
+}
+
+// Go type generated from a schema, a struct when Type is struct{}
+type GoType struct {
+	Name    string
+	Comment string
+	Type    string
+	Fields  []GoField
+}
+
+// Field of a generated struct, embedded when Name is empty
+type GoField struct {
+	Name    string
+	Type    string
+	Binding string
+	Tag     string
+}
+
+// GraphQL schema of the API groups, served at /graphql
+type GraphQL struct {
+	GoModule  string
+	WrkDir    string
+	Framework string
+	Groups    []GraphQLGroup
+	Tables    []GraphQLTable
+	Loaders   []GraphQLLoader
+	// custom scalars of the schema, besides the built-in ones
+	Scalars []string
+	// imports of resolvers.go and loaders.go
+	Imports       []string
+	LoaderImports []string
+}
+
+// Query and mutation fields of an API group, Field is the name of its list field
+type GraphQLGroup struct {
+	APIInputs
+	Field string
+	Input []GraphQLField
+}
+
+// Object type of the rows of a table
+type GraphQLTable struct {
+	Name      string
+	TableName string
+	Fields    []GraphQLField
+	Relations []GraphQLRelation
+}
+
+// Field of an object or input type. ToGraphQL and FromGraphQL are the Go conversions of a value, the value replaces %s.
+type GraphQLField struct {
+	Name        string
+	FieldName   string
+	Type        string
+	GoType      string
+	ToGraphQL   string
+	FromGraphQL string
+}
+
+// Field of a foreign key, the referenced row on the referencing table and the referencing rows on the referenced one
+type GraphQLRelation struct {
+	Name      string
+	Method    string
+	Table     string
+	List      bool
+	Loader    string
+	FieldName string
+	Nullable  bool
+	Comment   string
+}
+
+// Loader batches the lookups of the rows of a table, by id or by a foreign key column when List is set
+type GraphQLLoader struct {
+	Name      string
+	Key       string
+	Table     string
+	List      bool
+	Group     string
+	Method    string
+	FieldName string
+	Nullable  bool
+}
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entry of a YAML mapping, kept in the order of the document
+type entry[T any] struct {
+	Key   string
+	Value T
+}
+
+// ordered is a YAML mapping in the order of the document, so the generated code follows it
+type ordered[T any] []entry[T]
+
+func (o *ordered[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		*o = append(*o, entry[T]{node.Content[i].Value, value})
+	}
+	return nil
+}
+
+func (o ordered[T]) get(key string) (T, bool) {
+	for _, e := range o {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// document is the part of OpenAPI 3 the generator reads
+type document struct {
+	OpenAPI    string                  `yaml:"openapi"`
+	Paths      ordered[*pathItem]      `yaml:"paths"`
+	Components components              `yaml:"components"`
+	Tags       []struct{ Name string } `yaml:"tags"`
+}
+
+type components struct {
+	Schemas       ordered[*schema]      `yaml:"schemas"`
+	Parameters    ordered[*parameter]   `yaml:"parameters"`
+	RequestBodies ordered[*requestBody] `yaml:"requestBodies"`
+	Responses     ordered[*response]    `yaml:"responses"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Post       *operation   `yaml:"post"`
+	Put        *operation   `yaml:"put"`
+	Patch      *operation   `yaml:"patch"`
+	Delete     *operation   `yaml:"delete"`
+}
+
+// operations of the path by their HTTP method, in a fixed order
+func (p *pathItem) operations() []entry[*operation] {
+	all := []entry[*operation]{{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch}, {"DELETE", p.Delete}}
+	var operations []entry[*operation]
+	for _, e := range all {
+		if e.Value != nil {
+			operations = append(operations, e)
+		}
+	}
+	return operations
+}
+
+type operation struct {
+	OperationID string             `yaml:"operationId"`
+	Summary     string             `yaml:"summary"`
+	Tags        []string           `yaml:"tags"`
+	Parameters  []*parameter       `yaml:"parameters"`
+	RequestBody *requestBody       `yaml:"requestBody"`
+	Responses   ordered[*response] `yaml:"responses"`
+}
+
+type parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *schema `yaml:"schema"`
+}
+
+type requestBody struct {
+	Ref      string              `yaml:"$ref"`
+	Required bool                `yaml:"required"`
+	Content  ordered[*mediaType] `yaml:"content"`
+}
+
+type response struct {
+	Ref     string              `yaml:"$ref"`
+	Content ordered[*mediaType] `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type schema struct {
+	Ref                  string           `yaml:"$ref"`
+	Type                 string           `yaml:"type"`
+	Format               string           `yaml:"format"`
+	Description          string           `yaml:"description"`
+	Enum                 []interface{}    `yaml:"enum"`
+	Items                *schema          `yaml:"items"`
+	Properties           ordered[*schema] `yaml:"properties"`
+	Required             []string         `yaml:"required"`
+	AdditionalProperties *additional      `yaml:"additionalProperties"`
+	Nullable             bool             `yaml:"nullable"`
+	AllOf                []*schema        `yaml:"allOf"`
+	OneOf                []*schema        `yaml:"oneOf"`
+	AnyOf                []*schema        `yaml:"anyOf"`
+	Minimum              *float64         `yaml:"minimum"`
+	Maximum              *float64         `yaml:"maximum"`
+	MinLength            *int             `yaml:"minLength"`
+	MaxLength            *int             `yaml:"maxLength"`
+	MinItems             *int             `yaml:"minItems"`
+	MaxItems             *int             `yaml:"maxItems"`
+}
+
+// additional is additionalProperties, true or a schema of the values
+type additional struct {
+	Schema *schema
+}
+
+func (a *additional) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var allowed bool
+		if err := node.Decode(&allowed); err != nil || !allowed {
+			return err
+		}
+		a.Schema = &schema{}
+		return nil
+	}
+	return node.Decode(&a.Schema)
+}
+
+// parse reads an OpenAPI 3 document
+func parse(content []byte) (*document, error) {
+	doc := &document{}
+	if err := yaml.Unmarshal(content, doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi %q is not supported, convert the document to OpenAPI 3", doc.OpenAPI)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("the document has no paths")
+	}
+	return doc, nil
+}
+
+// refName is the name of the component a local $ref points at
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("$ref %s is not a local reference to components/%s", ref, kind)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *document) schema(ref string) (string, *schema, error) {
+	name, err := refName(ref, "schemas")
+	if err != nil {
+		return "", nil, err
+	}
+	s, found := d.Components.Schemas.get(name)
+	if !found || s == nil {
+		return "", nil, fmt.Errorf("$ref %s does not resolve", ref)
+	}
+	return name, s, nil
+}
+
+func (d *document) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, found := d.Components.Parameters.get(name)
+	if !found || resolved == nil {
+		return nil, fmt.Errorf("$ref %s does not resolve", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) requestBody(b *requestBody) (*requestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, found := d.Components.RequestBodies.get(name)
+	if !found || resolved == nil {
+		return nil, fmt.Errorf("$ref %s does not resolve", b.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) response(r *response) (*response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, found := d.Components.Responses.get(name)
+	if !found || resolved == nil {
+		return nil, fmt.Errorf("$ref %s does not resolve", r.Ref)
+	}
+	return resolved, nil
+}
+
+// jsonSchema is the schema of the application/json content, nil without content
+func jsonSchema(content ordered[*mediaType]) (*schema, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	for _, e := range content {
+		if e.Key == "application/json" || strings.HasSuffix(e.Key, "+json") {
+			if e.Value == nil || e.Value.Schema == nil {
+				return &schema{}, nil
+			}
+			return e.Value.Schema, nil
+		}
+	}
+	return nil, fmt.Errorf("content %s is not supported, only JSON is", content[0].Key)
+}
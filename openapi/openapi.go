@@ -0,0 +1,793 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/api"
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+var (
+	ReadFile = os.ReadFile
+	ReadDir  = os.ReadDir
+	Stat     = os.Stat
+	Remove   = os.Remove
+
+	V1Path      = "%s/api/v1/"
+	SchemasPath = "%s/api/v1/schemas/"
+	GroupPath   = "%s/api/v1/%s/"
+)
+
+// Marker starts the files from-openapi regenerates, the others are never overwritten
+const Marker = "// Code generated by api-service-generator from-openapi. DO NOT EDIT."
+
+// packages of the service a group package may not be named like
+var reservedPackages = map[string]bool{"api": true, "db": true, "gin": true, "mw": true, "schemas": true, "util": true, "v1": true}
+
+// untagged is the tag of the operations without one
+const untagged = "operations"
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Setup generates the API layer of the service in dir from the OpenAPI document at specPath.
+// The module defaults to the one of dir/go.mod, the services are backed by sqlc when dir/pkg/db exists.
+func Setup(specPath, dir, module string) error {
+	content, err := ReadFile(specPath)
+	if err != nil {
+		return err
+	}
+	doc, err := parse(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", specPath, err)
+	}
+	if module == "" {
+		module, err = Module(dir)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = Stat(dir + "/pkg/db")
+	sqlc := err == nil
+
+	service, err := build(doc, module, sqlc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", specPath, err)
+	}
+
+	err = errorsPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(service.Schemas) > 0 {
+		filePath := fmt.Sprintf(SchemasPath, dir)
+		err = common.CreateDirectory(filePath)
+		if err != nil {
+			return err
+		}
+		err = writeGenerated(filePath+"schemas.go", service, schemasContent+typesTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range service.Groups {
+		err = setupGroup(dir, &service.Groups[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeGenerated(fmt.Sprintf(V1Path, dir)+"openapi.go", service, routesContent)
+}
+
+// setupGroup writes the package of a group. service_impl.go is only written when it is missing,
+// the operations it does not implement yet get a stub in service_stubs.go.
+func setupGroup(dir string, group *models.OpenAPIGroup) error {
+	filePath := fmt.Sprintf(GroupPath, dir, group.Package)
+	err := common.CreateDirectory(filePath)
+	if err != nil {
+		return err
+	}
+
+	files := []struct{ name, content string }{
+		{"types.go", typesContent + typesTemplate},
+		{"controller.go", controllerContent + importsTemplate},
+		{"service.go", serviceContent + importsTemplate},
+	}
+	for _, file := range files {
+		err = writeGenerated(filePath+file.name, group, file.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := Stat(filePath + "service_impl.go"); err != nil {
+		return common.CreateFileAndItsContent(filePath+"service_impl.go", group, implContent+stubTemplate+importsTemplate)
+	}
+
+	methods, err := implemented(filePath, group.Title+"Service")
+	if err != nil {
+		return err
+	}
+	group.Stubs = nil
+	for _, operation := range group.Operations {
+		if !methods[operation.Name] {
+			group.Stubs = append(group.Stubs, operation)
+		}
+	}
+	group.StubsImports = signatureImports(group.Module, group.Stubs)
+
+	stubsFile := filePath + "service_stubs.go"
+	if len(group.Stubs) > 0 {
+		fmt.Printf("\n%s: %d operations are not implemented in service_impl.go, their stubs are in service_stubs.go\n", group.Package, len(group.Stubs))
+		return writeGenerated(stubsFile, group, stubsContent+stubTemplate+importsTemplate)
+	}
+	if _, err := Stat(stubsFile); err == nil {
+		if err := checkGenerated(stubsFile); err != nil {
+			return err
+		}
+		return Remove(stubsFile)
+	}
+	return nil
+}
+
+// errorsPackage writes the error model the handlers answer with when the service has none,
+// for the DB_DRIVER of its app.env
+func errorsPackage(dir string) error {
+	filePath := fmt.Sprintf(api.ErrorsPath, dir)
+	if _, err := Stat(filePath + "errors.go"); err == nil {
+		return nil
+	}
+	dbms := "postgres"
+	if content, err := ReadFile(dir + "/app.env"); err == nil && strings.Contains("\n"+string(content), "\nDB_DRIVER=mysql\n") {
+		dbms = "mysql"
+	}
+	err := common.CreateDirectory(filePath)
+	if err != nil {
+		return err
+	}
+	return api.CreateErrorsFile(dir, dbms)
+}
+
+// writeGenerated writes a file that starts with Marker, a file without it is left alone
+func writeGenerated(fileName string, data interface{}, content string) error {
+	if err := checkGenerated(fileName); err != nil {
+		return err
+	}
+	return common.CreateFileAndItsContent(fileName, data, content)
+}
+
+func checkGenerated(fileName string) error {
+	content, err := ReadFile(fileName)
+	if err != nil {
+		return nil
+	}
+	if !strings.HasPrefix(string(content), Marker) {
+		return fmt.Errorf("%s was not generated by from-openapi, move it away to generate it", fileName)
+	}
+	return nil
+}
+
+// implemented returns the methods of the receiver in the files of the package that are not generated
+func implemented(filePath, receiver string) (map[string]bool, error) {
+	entries, err := ReadDir(filePath)
+	if err != nil {
+		return nil, err
+	}
+	methods := map[string]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		content, err := ReadFile(filePath + name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(string(content), Marker) {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), name, content, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %w", filePath, name, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok && ident.Name == receiver {
+				methods[fn.Name.Name] = true
+			}
+		}
+	}
+	return methods, nil
+}
+
+// Module is the module path of the go.mod in dir
+func Module(dir string) (string, error) {
+	content, err := ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("no go.mod in %s, set --module: %w", dir, err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module line in %s/go.mod, set --module", dir)
+}
+
+// groupBuilder collects the operations of a tag
+type groupBuilder struct {
+	group models.OpenAPIGroup
+	types *converter
+	names map[string]bool
+}
+
+// build converts the document into the packages of the service, one per tag
+func build(doc *document, module string, sqlc bool) (models.OpenAPIService, error) {
+	service := models.OpenAPIService{Module: module, SQLC: sqlc}
+
+	shared := newConverter(doc, "")
+	for _, e := range doc.Components.Schemas {
+		if e.Value == nil {
+			continue
+		}
+		name := GoName(e.Key)
+		comment := "is the " + e.Key + " schema of the document"
+		if line := oneLine(e.Value.Description); line != "" {
+			comment += ": " + line
+		}
+		var err error
+		if e.Value.Ref == "" && (len(e.Value.AllOf) > 0 || len(e.Value.Properties) > 0) {
+			err = shared.structType(name, comment, e.Value)
+		} else {
+			var goType string
+			goType, err = shared.goType(e.Value, name)
+			if err == nil {
+				err = shared.add(models.GoType{Name: name, Comment: comment, Type: goType})
+			}
+		}
+		if err != nil {
+			return service, fmt.Errorf("schema %s: %w", e.Key, err)
+		}
+	}
+	service.Schemas = shared.types
+	service.Imports = typeImports(module, service.Schemas)
+
+	groups := map[string]*groupBuilder{}
+	packages := map[string]string{}
+	var order []string
+	for _, path := range doc.Paths {
+		if path.Value == nil {
+			continue
+		}
+		for _, method := range path.Value.operations() {
+			tag := untagged
+			if len(method.Value.Tags) > 0 {
+				tag = method.Value.Tags[0]
+			}
+			g, found := groups[tag]
+			if !found {
+				pkg, err := packageName(tag)
+				if err != nil {
+					return service, err
+				}
+				if other, clash := packages[pkg]; clash {
+					return service, fmt.Errorf("tags %s and %s are both generated in package %s", other, tag, pkg)
+				}
+				packages[pkg] = tag
+				g = &groupBuilder{
+					group: models.OpenAPIGroup{Module: module, SQLC: sqlc, Tag: tag, Package: pkg, Title: GoName(tag)},
+					types: newConverter(doc, "schemas."),
+					names: map[string]bool{},
+				}
+				groups[tag] = g
+				order = append(order, tag)
+			}
+			err := g.operation(doc, path.Key, path.Value, method.Key, method.Value)
+			if err != nil {
+				return service, fmt.Errorf("%s %s: %w", method.Key, path.Key, err)
+			}
+		}
+	}
+
+	for _, tag := range order {
+		group := groups[tag].group
+		group.Types = groups[tag].types.types
+		group.TypesImports = typeImports(module, group.Types)
+		group.ControllerImports = controllerImports(module, group.Operations)
+		group.ServiceImports = signatureImports(module, group.Operations, "net/http", "apierrors "+module+"/api/errors")
+		group.ImplImports = signatureImports(module, group.Operations)
+		if sqlc {
+			group.ImplImports = signatureImports(module, group.Operations, module+"/pkg/db")
+		}
+		service.Groups = append(service.Groups, group)
+	}
+	return service, nil
+}
+
+// operation adds the handler, the method of the service and the types of an operation to the group
+func (g *groupBuilder) operation(doc *document, path string, item *pathItem, method string, op *operation) error {
+	name := GoName(op.OperationID)
+	if name == "" {
+		name = GoName(strings.ToLower(method) + " " + path)
+	}
+	if g.names[name] {
+		return fmt.Errorf("operation %s is defined twice in tag %s, set a unique operationId", name, g.group.Tag)
+	}
+	g.names[name] = true
+
+	o := models.Operation{
+		Name:    name,
+		Service: g.group.Title + "Service",
+		Method:  method,
+		Path:    path,
+		Route:   pathParam.ReplaceAllString(path, ":$1"),
+		Summary: oneLine(op.Summary),
+		Status:  "http.StatusOK",
+	}
+
+	params, err := parameters(doc, path, item, op)
+	if err != nil {
+		return err
+	}
+	var request []models.GoField
+	for _, in := range []struct{ in, suffix, tag, comment string }{
+		{"path", "Path", "uri", "path parameters"},
+		{"query", "Query", "form", "query parameters"},
+		{"header", "Header", "header", "headers"},
+	} {
+		var fields []models.GoField
+		structName := name + in.suffix
+		for _, p := range params {
+			if p.In != in.in {
+				continue
+			}
+			field, err := g.types.field(structName, p.Name, p.Schema, p.Required || p.In == "path")
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+			field.Tag = fmt.Sprintf("`%s:\"%s\"%s`", in.tag, p.Name, bindingTag(field))
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		err = g.types.add(models.GoType{Name: structName, Comment: "holds the " + in.comment + " of " + name, Type: "struct{}", Fields: fields})
+		if err != nil {
+			return err
+		}
+		request = append(request, models.GoField{Name: in.suffix, Type: structName})
+		o.URI = o.URI || in.in == "path"
+		o.Query = o.Query || in.in == "query"
+		o.Header = o.Header || in.in == "header"
+	}
+
+	body, err := doc.requestBody(op.RequestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		s, err := jsonSchema(body.Content)
+		if err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		if s != nil {
+			bodyType, err := g.types.goType(s, name+"Body")
+			if err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+			request = append(request, models.GoField{Name: "Body", Type: bodyType})
+			o.Body = true
+			o.BodyRequired = body.Required
+		}
+	}
+	if len(request) > 0 {
+		o.Request = name + "Request"
+		err = g.types.add(models.GoType{Name: o.Request, Comment: "is the request of " + name + ", bound by its handler", Type: "struct{}", Fields: request})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range op.Responses {
+		code, err := strconv.Atoi(e.Key)
+		if err != nil || code < 200 || code > 299 {
+			continue
+		}
+		o.Status = statusName(code)
+		r, err := doc.response(e.Value)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			break
+		}
+		s, err := jsonSchema(r.Content)
+		if err != nil {
+			return fmt.Errorf("response %s: %w", e.Key, err)
+		}
+		if s != nil {
+			o.Response, err = g.types.goType(s, name+"Response")
+			if err != nil {
+				return fmt.Errorf("response %s: %w", e.Key, err)
+			}
+		}
+		break
+	}
+
+	g.group.Operations = append(g.group.Operations, o)
+	return nil
+}
+
+// parameters of the operation, those of the operation override those of the path.
+// A parameter of the path template that is not declared is a string.
+func parameters(doc *document, path string, item *pathItem, op *operation) ([]*parameter, error) {
+	var params []*parameter
+	for _, p := range append(append([]*parameter{}, item.Parameters...), op.Parameters...) {
+		resolved, err := doc.parameter(p)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.In == "cookie" {
+			return nil, fmt.Errorf("cookie parameter %s is not supported", resolved.Name)
+		}
+		replaced := false
+		for i, existing := range params {
+			if existing.Name == resolved.Name && existing.In == resolved.In {
+				params[i], replaced = resolved, true
+			}
+		}
+		if !replaced {
+			params = append(params, resolved)
+		}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		declared := false
+		for _, p := range params {
+			declared = declared || (p.In == "path" && p.Name == match[1])
+		}
+		if !declared {
+			params = append(params, &parameter{Name: match[1], In: "path", Required: true, Schema: &schema{Type: "string"}})
+		}
+	}
+	return params, nil
+}
+
+// packageName is the Go package of a tag, its letters and digits in lower case
+func packageName(tag string) (string, error) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(tag) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	switch {
+	case pkg == "" || pkg[0] < 'a':
+		return "", fmt.Errorf("tag %q does not make a Go package name", tag)
+	case token.IsKeyword(pkg) || reservedPackages[pkg]:
+		return "", fmt.Errorf("tag %q makes the package %s, which is reserved, rename the tag", tag, pkg)
+	}
+	return pkg, nil
+}
+
+func statusName(code int) string {
+	names := map[int]string{200: "OK", 201: "Created", 202: "Accepted", 204: "NoContent"}
+	if name, found := names[code]; found {
+		return "http.Status" + name
+	}
+	return strconv.Itoa(code)
+}
+
+func oneLine(text string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+}
+
+// imports of the Go types, the standard library ones and those of the module
+func imports(module string, goTypes ...string) (std, local []string) {
+	for _, goType := range goTypes {
+		if strings.Contains(goType, "time.Time") {
+			std = addImport(std, "time")
+		}
+		if strings.Contains(goType, "json.RawMessage") {
+			std = addImport(std, "encoding/json")
+		}
+		if strings.Contains(goType, "schemas.") {
+			local = addImport(local, module+"/api/v1/schemas")
+		}
+	}
+	return std, local
+}
+
+func typeImports(module string, goTypes []models.GoType) []string {
+	var all []string
+	for _, t := range goTypes {
+		all = append(all, t.Type)
+		for _, field := range t.Fields {
+			all = append(all, field.Type)
+		}
+	}
+	return join(imports(module, all...))
+}
+
+// signatureImports are the imports of the methods of the service, and the extra ones of the file
+func signatureImports(module string, operations []models.Operation, extra ...string) []string {
+	var responses []string
+	for _, o := range operations {
+		responses = append(responses, o.Response)
+	}
+	std, local := imports(module, responses...)
+	for _, path := range append([]string{"context"}, extra...) {
+		if strings.HasPrefix(importPath(path), module+"/") {
+			local = addImport(local, path)
+		} else {
+			std = addImport(std, path)
+		}
+	}
+	return join(std, local)
+}
+
+func controllerImports(module string, operations []models.Operation) []string {
+	std := []string{"net/http"}
+	for _, o := range operations {
+		if o.Body && !o.BodyRequired {
+			std = addImport(std, "errors")
+			std = addImport(std, "io")
+		}
+	}
+	return join(std, []string{"apierrors " + module + "/api/errors", "github.com/gin-gonic/gin"})
+}
+
+func addImport(imports []string, path string) []string {
+	if contains(imports, path) {
+		return imports
+	}
+	return append(imports, path)
+}
+
+// join sorts the groups of imports and quotes their paths, the empty import between them is a
+// blank line. An aliased import is the alias and the path.
+func join(std, local []string) []string {
+	sort.Strings(std)
+	sort.Slice(local, func(i, j int) bool { return importPath(local[i]) < importPath(local[j]) })
+	if len(std) > 0 && len(local) > 0 {
+		std = append(std, "")
+	}
+	var imports []string
+	for _, path := range append(std, local...) {
+		if alias, path, found := strings.Cut(path, " "); found {
+			imports = append(imports, alias+" "+strconv.Quote(path))
+			continue
+		}
+		if path == "" {
+			imports = append(imports, path)
+			continue
+		}
+		imports = append(imports, strconv.Quote(path))
+	}
+	return imports
+}
+
+// importPath is the path of an import, without its alias
+func importPath(path string) string {
+	if _, path, found := strings.Cut(path, " "); found {
+		return path
+	}
+	return path
+}
+
+const importsTemplate = `
+{{- define "imports"}}
+{{- if .}}
+
+import (
+{{- range .}}
+{{- if .}}
+	{{.}}
+{{- else}}
+{{end}}
+{{- end}}
+)
+{{- end}}
+{{- end}}
+`
+
+const typesTemplate = importsTemplate + `
+{{- define "types"}}
+{{- range .}}
+
+// {{.Name}} {{.Comment}}
+{{- if eq .Type "struct{}"}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{if .Name}}{{.Name}} {{end}}{{.Type}}{{with .Tag}} {{.}}{{end}}
+{{- end}}
+}
+{{- else}}
+type {{.Name}} {{.Type}}
+{{- end}}
+{{- end}}
+{{- end}}
+`
+
+const schemasContent = Marker + `
+
+// Package schemas holds the component schemas of the OpenAPI document
+package schemas
+{{- template "imports" .Imports}}
+{{- template "types" .Schemas}}
+`
+
+const typesContent = Marker + `
+
+package {{.Package}}
+{{- template "imports" .TypesImports}}
+{{- template "types" .Types}}
+`
+
+const controllerContent = Marker + `
+
+package {{.Package}}
+{{- template "imports" .ControllerImports}}
+
+type {{.Title}}Resource struct {
+	service Service
+}
+
+// RegisterHandler registers the operations of the {{.Tag}} tag, with their paths of the document
+func RegisterHandler(r *gin.RouterGroup, service Service) {
+	resource := New{{.Title}}Resource(service)
+{{range .Operations}}
+	r.{{.Method}}("{{.Route}}", resource.{{.Name}})
+{{- end}}
+}
+
+func New{{.Title}}Resource(service Service) {{.Title}}Resource {
+	return {{.Title}}Resource{service}
+}
+{{- range .Operations}}
+
+// {{.Name}} handles {{.Method}} {{.Path}}{{with .Summary}}: {{.}}{{end}}
+func (r *{{$.Title}}Resource) {{.Name}}(c *gin.Context) {
+{{- if .Request}}
+	var req {{.Request}}
+{{- if .URI}}
+	if err := c.ShouldBindUri(&req.Path); err != nil {
+		errorResponse(c, apierrors.Parameters(err))
+		return
+	}
+{{- end}}
+{{- if .Query}}
+	if err := c.ShouldBindQuery(&req.Query); err != nil {
+		errorResponse(c, apierrors.Parameters(err))
+		return
+	}
+{{- end}}
+{{- if .Header}}
+	if err := c.ShouldBindHeader(&req.Header); err != nil {
+		errorResponse(c, apierrors.Parameters(err))
+		return
+	}
+{{- end}}
+{{- if .Body}}
+	if err := c.ShouldBindJSON(&req.Body); err != nil{{if not .BodyRequired}} && !errors.Is(err, io.EOF){{end}} {
+		errorResponse(c, apierrors.Binding(err))
+		return
+	}
+{{- end}}
+{{end}}
+	{{if .Response}}resp, err{{else}}err{{end}} := r.service.{{.Name}}(c{{if .Request}}, req{{end}})
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	{{if .Response}}c.JSON({{.Status}}, resp){{else}}c.Status({{.Status}}){{end}}
+}
+{{- end}}
+
+// errorResponse answers err as a problem of the error model, ErrNotImplemented with 501
+func errorResponse(c *gin.Context, err error) {
+	apierrors.Write(c.Writer, c.Request, err)
+}
+`
+
+const serviceContent = Marker + `
+
+package {{.Package}}
+{{- template "imports" .ServiceImports}}
+
+// Service is the operations of the {{.Tag}} tag of the OpenAPI document
+type Service interface {
+{{- range .Operations}}
+	{{.Name}}(ctx context.Context{{if .Request}}, req {{.Request}}{{end}}) {{if .Response}}({{.Response}}, error){{else}}error{{end}}
+{{- end}}
+}
+
+// ErrNotImplemented is returned by the stubs of the operations, the handlers answer 501
+var ErrNotImplemented = apierrors.New(http.StatusNotImplemented, apierrors.CodeNotImplemented, "not implemented")
+`
+
+const stubTemplate = `
+{{- define "stubs"}}
+{{- range .}}
+
+func (s *{{.Service}}) {{.Name}}(ctx context.Context{{if .Request}}, req {{.Request}}{{end}}) {{if .Response}}(resp {{.Response}}, err error){{else}}error{{end}} {
+	return {{if .Response}}resp, {{end}}ErrNotImplemented
+}
+{{- end}}
+{{- end}}
+`
+
+const implContent = `// Generated By API Service Generator
+// from-openapi writes this file once, implement the operations of Service here.
+
+package {{.Package}}
+{{- template "imports" .ImplImports}}
+
+type {{.Title}}Service struct {
+{{- if .SQLC}}
+	DBConn *db.Queries
+{{- end}}
+}
+
+func New{{.Title}}Service({{if .SQLC}}DBConn *db.Queries{{end}}) {{.Title}}Service {
+	return {{.Title}}Service{ {{- if .SQLC}}DBConn{{end -}} }
+}
+
+var _ Service = (*{{.Title}}Service)(nil)
+{{- template "stubs" .Operations}}
+`
+
+const stubsContent = Marker + `
+// Operations added to the document after service_impl.go was written, move them there to implement them.
+
+package {{.Package}}
+{{- template "imports" .StubsImports}}
+{{- template "stubs" .Stubs}}
+`
+
+const routesContent = Marker + `
+
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+{{- if .SQLC}}
+
+	"{{.Module}}/pkg/db"
+{{- else if .Groups}}
+{{end}}
+{{- range .Groups}}
+	"{{$.Module}}/api/v1/{{.Package}}"
+{{- end}}
+)
+
+// RegisterOpenAPIHandlers registers the operations of the OpenAPI document, with their paths of the document
+func RegisterOpenAPIHandlers(r *gin.RouterGroup{{if .SQLC}}, queries *db.Queries{{end}}) {
+{{- range .Groups}}
+	{{.Package}}Svc := {{.Package}}.New{{.Title}}Service({{if $.SQLC}}queries{{end}})
+	{{.Package}}.RegisterHandler(r, &{{.Package}}Svc)
+{{- end}}
+}
+`
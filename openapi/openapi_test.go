@@ -0,0 +1,316 @@
+package openapi
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+// service is a directory with the go.mod and the sqlc package of a generated service
+func service(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/go.mod", []byte("module example.com/petstore\n\ngo 1.22\n"), 0o644))
+	require.NoError(t, os.MkdirAll(dir+"/pkg/db", 0o755))
+	return dir
+}
+
+func write(t *testing.T, fileName, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0o755))
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+}
+
+// capture returns the files the setup writes, rendered, by their path under dir
+func capture(t *testing.T, dir string, setup func() error) (map[string]string, error) {
+	mockCmdsExecutor := mocks.NewMockCmdsExecutor()
+	common.DefaultExecutor = mockCmdsExecutor
+
+	files := map[string]string{}
+	mockCmdsExecutor.On("CreateDirectory", mock.Anything).Return(nil)
+	mockCmdsExecutor.On("CreateFileAndItsContent", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var rendered bytes.Buffer
+		tmpl := template.Must(template.New("file").Parse(args.String(2)))
+		require.NoError(t, tmpl.Execute(&rendered, args.Get(1)))
+		files[strings.TrimPrefix(args.String(0), dir)] = rendered.String()
+	}).Return(nil)
+
+	err := setup()
+	return files, err
+}
+
+func keys[T any](m map[string]T) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseGo checks that a generated file is Go and returns its declarations by name
+func parseGo(t *testing.T, name, content string) map[string]ast.Decl {
+	file, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ParseComments)
+	require.NoError(t, err, content)
+	decls := map[string]ast.Decl{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			decls[d.Name.Name] = d
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				if spec, ok := s.(*ast.TypeSpec); ok {
+					decls[spec.Name.Name] = d
+				}
+			}
+		}
+	}
+	return decls
+}
+
+func TestSetup(t *testing.T) {
+	dir := service(t)
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"/api/errors/errors.go",
+		"/api/v1/openapi.go",
+		"/api/v1/operations/controller.go",
+		"/api/v1/operations/service.go",
+		"/api/v1/operations/service_impl.go",
+		"/api/v1/operations/types.go",
+		"/api/v1/pets/controller.go",
+		"/api/v1/pets/service.go",
+		"/api/v1/pets/service_impl.go",
+		"/api/v1/pets/types.go",
+		"/api/v1/schemas/schemas.go",
+		"/api/v1/storeinventory/controller.go",
+		"/api/v1/storeinventory/service.go",
+		"/api/v1/storeinventory/service_impl.go",
+		"/api/v1/storeinventory/types.go",
+	}, keys(files))
+
+	for name, content := range files {
+		parseGo(t, name, content)
+		if !strings.HasSuffix(name, "service_impl.go") && name != "/api/errors/errors.go" {
+			assert.True(t, strings.HasPrefix(content, Marker+"\n"), name)
+		}
+	}
+
+	controller := files["/api/v1/pets/controller.go"]
+	assert.Contains(t, controller, `r.GET("/pets/:petId", resource.ShowPetByID)`)
+	assert.Contains(t, controller, `r.PATCH("/pets/:petId", resource.PatchPetsPetID)`)
+	assert.Contains(t, controller, "c.ShouldBindHeader(&req.Header)")
+	assert.Contains(t, controller, "c.JSON(http.StatusCreated, resp)")
+	assert.Contains(t, controller, "c.Status(http.StatusNoContent)")
+	assert.Contains(t, controller, `err != nil && !errors.Is(err, io.EOF)`, "the body of the patch is optional")
+	assert.Equal(t, 1, strings.Count(controller, "io.EOF"))
+	assert.Contains(t, controller, `apierrors "example.com/petstore/api/errors"`)
+	assert.Contains(t, controller, "errorResponse(c, apierrors.Parameters(err))")
+	assert.Contains(t, controller, "errorResponse(c, apierrors.Binding(err))")
+	assert.Contains(t, controller, "apierrors.Write(c.Writer, c.Request, err)")
+	assert.NotContains(t, controller, "gin.H")
+	assert.NotContains(t, controller, "sql.ErrNoRows")
+
+	service := files["/api/v1/pets/service.go"]
+	assert.Contains(t, service, "apierrors.New(http.StatusNotImplemented, apierrors.CodeNotImplemented, \"not implemented\")")
+	assert.Contains(t, service, "ListPets(ctx context.Context, req ListPetsRequest) (schemas.Pets, error)")
+	assert.Contains(t, service, "DeletePet(ctx context.Context, req DeletePetRequest) error")
+	assert.Contains(t, service, `"example.com/petstore/api/v1/schemas"`)
+
+	impl := files["/api/v1/pets/service_impl.go"]
+	assert.Contains(t, impl, "DBConn *db.Queries")
+	assert.Contains(t, impl, `"example.com/petstore/pkg/db"`)
+	assert.Contains(t, impl, "var _ Service = (*PetsService)(nil)")
+
+	assert.Contains(t, files["/api/v1/openapi.go"], "func RegisterOpenAPIHandlers(r *gin.RouterGroup, queries *db.Queries)")
+	assert.Contains(t, files["/api/v1/openapi.go"], "storeinventory.RegisterHandler(r, &storeinventorySvc)")
+	assert.Contains(t, files["/api/v1/operations/controller.go"], `r.GET("/health", resource.GetHealth)`)
+}
+
+func TestSetup_ErrorsPackage(t *testing.T) {
+	// the error model of the service is kept, a missing one is written for its driver
+	dir := service(t)
+	write(t, dir+"/api/errors/errors.go", "package errors\n")
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+	assert.NotContains(t, files, "/api/errors/errors.go")
+
+	dir = service(t)
+	write(t, dir+"/app.env", "DB_DRIVER=mysql\nDB_SOURCE=root@tcp(localhost:3306)/petstore\n")
+	files, err = capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+	assert.Contains(t, files["/api/errors/errors.go"], "github.com/go-sql-driver/mysql")
+}
+
+func TestSetup_Types(t *testing.T) {
+	dir := service(t)
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+
+	schemas := files["/api/v1/schemas/schemas.go"]
+	for _, field := range []string{
+		"Name string `json:\"name\" binding:\"required,min=1,max=50\"`",
+		"Tag *string `json:\"tag,omitempty\"`",
+		"Status *Status `json:\"status,omitempty\" binding:\"omitempty,oneof=available 'on hold' sold\"`",
+		"Owner *string `json:\"owner,omitempty\" binding:\"omitempty,email\"`",
+		"Photos []string `json:\"photos\" binding:\"required,max=5,dive,url\"`",
+		"Attributes map[string]string `json:\"attributes\"`",
+		"Born *time.Time `json:\"born,omitempty\"`",
+		"Weight *float32 `json:\"weight,omitempty\" binding:\"omitempty,min=0\"`",
+		"Extra json.RawMessage `json:\"extra\"`",
+		"Address *NewPetAddress `json:\"address,omitempty\"`",
+		"Code int32 `json:\"code\"`",
+		"\tNewPet\n\tID int64 `json:\"id\"`",
+		"type Pets []Pet",
+		"type Status string",
+	} {
+		assert.Contains(t, schemas, field)
+	}
+	assert.Contains(t, schemas, "// NewPet is the NewPet schema of the document: A pet before it is stored")
+
+	types := files["/api/v1/pets/types.go"]
+	for _, field := range []string{
+		"Limit *int32 `form:\"limit\" binding:\"omitempty,min=1,max=100\"`",
+		"Status *schemas.Status `form:\"status\" binding:\"omitempty,oneof=available 'on hold' sold\"`",
+		"XRequestID string `header:\"X-Request-ID\" binding:\"required\"`",
+		"PetID string `uri:\"petId\" binding:\"required,uuid\"`",
+		"Body schemas.NewPet",
+		"Name *string `json:\"name,omitempty\" binding:\"omitempty,max=50\"`",
+		"Updated bool `json:\"updated\"`",
+	} {
+		assert.Contains(t, types, field)
+	}
+
+	decls := parseGo(t, "types.go", types)
+	for _, name := range []string{"ListPetsQuery", "ListPetsHeader", "ListPetsRequest", "CreatePetRequest", "PatchPetsPetIDBody", "PatchPetsPetIDResponse", "DeletePetRequest"} {
+		assert.Contains(t, decls, name)
+	}
+
+	// the undeclared parameter of the path is a string
+	assert.Contains(t, files["/api/v1/storeinventory/types.go"], "StoreID string `uri:\"storeId\" binding:\"required\"`")
+	assert.Contains(t, files["/api/v1/storeinventory/service.go"], "(map[string]int32, error)")
+}
+
+func TestSetup_WithoutSQLC(t *testing.T) {
+	dir := t.TempDir()
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "example.com/other") })
+	require.NoError(t, err)
+
+	assert.Contains(t, files["/api/v1/pets/service_impl.go"], "type PetsService struct {\n}")
+	assert.Contains(t, files["/api/v1/pets/service_impl.go"], "func NewPetsService() PetsService {\n\treturn PetsService{}\n}")
+	assert.NotContains(t, files["/api/v1/pets/service_impl.go"], "pkg/db")
+	assert.Contains(t, files["/api/v1/openapi.go"], "func RegisterOpenAPIHandlers(r *gin.RouterGroup) {")
+	assert.Contains(t, files["/api/v1/openapi.go"], `"example.com/other/api/v1/pets"`)
+	for name, content := range files {
+		parseGo(t, name, content)
+	}
+}
+
+func TestSetup_KeepsImplementation(t *testing.T) {
+	dir := service(t)
+	write(t, dir+"/api/v1/pets/service_impl.go", `package pets
+
+import "context"
+
+type PetsService struct{}
+
+func (s *PetsService) ListPets(ctx context.Context, req ListPetsRequest) (schemas.Pets, error) {
+	return nil, nil
+}
+`)
+	write(t, dir+"/api/v1/pets/delete.go", `package pets
+
+func (s PetsService) DeletePet(ctx context.Context, req DeletePetRequest) error {
+	return nil
+}
+`)
+	// a stub of a generated file is not an implementation
+	write(t, dir+"/api/v1/pets/service_stubs.go", Marker+`
+
+package pets
+
+func (s *PetsService) CreatePet(ctx context.Context, req CreatePetRequest) (resp schemas.Pet, err error) {
+	return resp, ErrNotImplemented
+}
+`)
+
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+
+	assert.NotContains(t, files, "/api/v1/pets/service_impl.go")
+	stubs := files["/api/v1/pets/service_stubs.go"]
+	decls := parseGo(t, "service_stubs.go", stubs)
+	assert.ElementsMatch(t, []string{"CreatePet", "ShowPetByID", "PatchPetsPetID"}, keys(decls))
+	assert.Contains(t, stubs, `"example.com/petstore/api/v1/schemas"`)
+	assert.Contains(t, files, "/api/v1/pets/controller.go")
+	assert.Contains(t, files, "/api/v1/storeinventory/service_impl.go")
+}
+
+func TestSetup_RemovesStubs(t *testing.T) {
+	dir := service(t)
+	impl := "package pets\n"
+	for _, name := range []string{"ListPets", "CreatePet", "ShowPetByID", "PatchPetsPetID", "DeletePet"} {
+		impl += "\nfunc (s *PetsService) " + name + "() {}\n"
+	}
+	write(t, dir+"/api/v1/pets/service_impl.go", impl)
+	write(t, dir+"/api/v1/pets/service_stubs.go", Marker+"\n\npackage pets\n")
+
+	removed := []string{}
+	Remove = func(name string) error {
+		removed = append(removed, strings.TrimPrefix(name, dir))
+		return nil
+	}
+	defer func() { Remove = os.Remove }()
+
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.NoError(t, err)
+	assert.NotContains(t, files, "/api/v1/pets/service_stubs.go")
+	assert.Equal(t, []string{"/api/v1/pets/service_stubs.go"}, removed)
+}
+
+func TestSetup_DoesNotOverwrite(t *testing.T) {
+	dir := service(t)
+	write(t, dir+"/api/v1/pets/controller.go", "// Generated By API Service Generator\npackage pets\n")
+
+	files, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	require.ErrorContains(t, err, "pets/controller.go was not generated by from-openapi")
+	assert.NotContains(t, files, "/api/v1/pets/controller.go")
+}
+
+func TestSetup_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := capture(t, dir, func() error { return Setup("testdata/petstore.yaml", dir, "") })
+	assert.ErrorContains(t, err, "no go.mod in")
+
+	_, err = capture(t, dir, func() error { return Setup("testdata/missing.yaml", dir, "example.com/x") })
+	assert.Error(t, err)
+}
+
+func TestModule(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir+"/go.mod", "// the service\nmodule \"GoModule/orders\"\n\ngo 1.22\n")
+	module, err := Module(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "GoModule/orders", module)
+
+	write(t, dir+"/go.mod", "go 1.22\n")
+	_, err = Module(dir)
+	assert.ErrorContains(t, err, "no module line")
+}
@@ -0,0 +1,279 @@
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/abhijithk1/api-service-generator/models"
+)
+
+// converter turns the schemas of the document into Go types. The named types it
+// needs are collected in types, in the order they are found.
+type converter struct {
+	doc *document
+	// qualifier of the component schemas, "schemas." outside their package
+	qualifier string
+	types     []models.GoType
+	named     map[string]bool
+}
+
+func newConverter(doc *document, qualifier string) *converter {
+	return &converter{doc: doc, qualifier: qualifier, named: map[string]bool{}}
+}
+
+// add collects a named type, the names of a package are unique
+func (c *converter) add(t models.GoType) error {
+	if c.named[t.Name] {
+		return fmt.Errorf("type %s is generated twice, rename the schema or the operation", t.Name)
+	}
+	c.named[t.Name] = true
+	c.types = append(c.types, t)
+	return nil
+}
+
+// empty reports whether s is {}, any value
+func (s *schema) empty() bool {
+	return s.Ref == "" && s.Type == "" && len(s.Properties) == 0 && len(s.AllOf) == 0 && len(s.OneOf) == 0 && len(s.AnyOf) == 0
+}
+
+// resolve follows the $ref of s to the schema it points at
+func (c *converter) resolve(s *schema) (*schema, error) {
+	for s != nil && s.Ref != "" {
+		_, resolved, err := c.doc.schema(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		s = resolved
+	}
+	return s, nil
+}
+
+// goType is the Go type of s, name is the name an inline object gets
+func (c *converter) goType(s *schema, name string) (string, error) {
+	if s == nil {
+		return "json.RawMessage", nil
+	}
+	if s.Ref != "" {
+		ref, _, err := c.doc.schema(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		return c.qualifier + GoName(ref), nil
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		// the handler passes the value on as it came
+		return "json.RawMessage", nil
+	}
+	if len(s.AllOf) > 0 || len(s.Properties) > 0 {
+		err := c.structType(name, "is the "+name+" object", s)
+		return name, err
+	}
+
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		items, err := c.goType(s.Items, name+"Item")
+		return "[]" + items, err
+	case "object":
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil && !s.AdditionalProperties.Schema.empty() {
+			value, err := c.goType(s.AdditionalProperties.Schema, name+"Value")
+			return "map[string]" + value, err
+		}
+		return "map[string]interface{}", nil
+	}
+	return "json.RawMessage", nil
+}
+
+// structType collects the struct of an object schema. The $ref parts of allOf
+// are embedded, so their fields are on the JSON object like in the document.
+func (c *converter) structType(name, comment string, s *schema) error {
+	t := models.GoType{Name: name, Comment: comment, Type: "struct{}"}
+	parts := append([]*schema{s}, s.AllOf...)
+	for _, part := range parts {
+		if part != s && part.Ref != "" {
+			embedded, err := c.goType(part, "")
+			if err != nil {
+				return err
+			}
+			t.Fields = append(t.Fields, models.GoField{Type: embedded})
+			continue
+		}
+		for _, property := range part.Properties {
+			field, err := c.field(name, property.Key, property.Value, contains(part.Required, property.Key) || contains(s.Required, property.Key))
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", name, property.Key, err)
+			}
+			field.Tag = fmt.Sprintf("`json:\"%s%s\"%s`", property.Key, omitEmpty(field), bindingTag(field))
+			t.Fields = append(t.Fields, field)
+		}
+	}
+	return c.add(t)
+}
+
+// field of a struct, optional and nullable values are pointers. The tag is left to the caller.
+func (c *converter) field(parent, key string, s *schema, required bool) (models.GoField, error) {
+	fieldName := GoName(key)
+	fieldType, err := c.goType(s, parent+fieldName)
+	if err != nil {
+		return models.GoField{}, err
+	}
+	resolved, err := c.resolve(s)
+	if err != nil {
+		return models.GoField{}, err
+	}
+	nullable := resolved != nil && resolved.Nullable
+	if (!required || nullable) && pointerable(fieldType) {
+		fieldType = "*" + fieldType
+	}
+	binding, err := c.rules(s, required && !nullable, fieldType)
+	return models.GoField{Name: fieldName, Type: fieldType, Binding: binding}, err
+}
+
+// rules are the validator rules of the binding tag of a value of s
+func (c *converter) rules(s *schema, required bool, fieldType string) (string, error) {
+	resolved, err := c.resolve(s)
+	if err != nil || resolved == nil {
+		return "", err
+	}
+	rules := valueRules(resolved)
+	if resolved.Type == "array" && resolved.Items != nil {
+		items, err := c.rules(resolved.Items, false, "")
+		if err != nil {
+			return "", err
+		}
+		if items != "" {
+			rules = append(rules, "dive", items)
+		}
+	}
+
+	// gin cannot tell a zero number or false from a missing one, like the request structs of the tables
+	presence := fieldType == "string" || strings.HasPrefix(fieldType, "[]") || strings.HasPrefix(fieldType, "map[")
+	switch {
+	case required && presence:
+		rules = append([]string{"required"}, rules...)
+	case len(rules) > 0 && strings.HasPrefix(fieldType, "*"):
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ","), nil
+}
+
+// valueRules are the rules of the value of s itself
+func valueRules(s *schema) []string {
+	var rules []string
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, value := range s.Enum {
+			values[i] = fmt.Sprint(value)
+			if strings.ContainsAny(values[i], " '") {
+				values[i] = "'" + strings.ReplaceAll(values[i], "'", "") + "'"
+			}
+		}
+		rules = append(rules, "oneof="+strings.Join(values, " "))
+	}
+	switch s.Format {
+	case "email":
+		rules = append(rules, "email")
+	case "uuid":
+		rules = append(rules, "uuid")
+	case "uri":
+		rules = append(rules, "url")
+	}
+	bound := func(rule string, value *float64) {
+		if value != nil {
+			rules = append(rules, rule+"="+strconv.FormatFloat(*value, 'f', -1, 64))
+		}
+	}
+	length := func(rule string, value *int) {
+		if value != nil {
+			rules = append(rules, rule+"="+strconv.Itoa(*value))
+		}
+	}
+	switch s.Type {
+	case "string":
+		length("min", s.MinLength)
+		length("max", s.MaxLength)
+	case "integer", "number":
+		bound("min", s.Minimum)
+		bound("max", s.Maximum)
+	case "array":
+		length("min", s.MinItems)
+		length("max", s.MaxItems)
+	}
+	return rules
+}
+
+func bindingTag(field models.GoField) string {
+	if field.Binding == "" {
+		return ""
+	}
+	return fmt.Sprintf(" binding:\"%s\"", field.Binding)
+}
+
+func omitEmpty(field models.GoField) string {
+	if strings.HasPrefix(field.Type, "*") {
+		return ",omitempty"
+	}
+	return ""
+}
+
+// pointerable reports whether a missing value needs a pointer to be told from the zero value
+func pointerable(goType string) bool {
+	return !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "json.RawMessage"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GoName is the exported Go name of a name of the document, e.g. list_pets or listPets -> ListPets, pet_id -> PetID
+func GoName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	var b strings.Builder
+	for _, part := range parts {
+		if strings.EqualFold(part, "id") {
+			b.WriteString("ID")
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		part = string(runes)
+		if strings.HasSuffix(part, "Id") && len(part) > 2 && unicode.IsLower(runes[len(runes)-3]) {
+			part = strings.TrimSuffix(part, "Id") + "ID"
+		}
+		b.WriteString(part)
+	}
+	goName := b.String()
+	if goName != "" && unicode.IsDigit([]rune(goName)[0]) {
+		goName = "N" + goName
+	}
+	return goName
+}
@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"listPets":       "ListPets",
+		"list_pets":      "ListPets",
+		"pet_id":         "PetID",
+		"petId":          "PetID",
+		"id":             "ID",
+		"X-Request-ID":   "XRequestID",
+		"get /pets/{id}": "GetPetsID",
+		"Idle":           "Idle",
+		"2fa":            "N2fa",
+	} {
+		assert.Equal(t, expected, GoName(name), name)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	pkg, err := packageName("Store-Inventory")
+	require.NoError(t, err)
+	assert.Equal(t, "storeinventory", pkg)
+
+	for _, tag := range []string{"type", "db", "schemas", "v1", "1st", "---"} {
+		_, err := packageName(tag)
+		assert.Error(t, err, tag)
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	for name, test := range map[string]struct {
+		document string
+		err      string
+	}{
+		"swagger": {
+			document: "swagger: \"2.0\"\npaths: {}\n",
+			err:      "convert the document to OpenAPI 3",
+		},
+		"no paths": {
+			document: "openapi: 3.0.3\n",
+			err:      "the document has no paths",
+		},
+		"duplicate operation": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    get: {operationId: list, responses: {}}
+  /b:
+    get: {operationId: list, responses: {}}
+`,
+			err: "operation List is defined twice",
+		},
+		"cookie": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    get:
+      parameters: [{name: session, in: cookie, schema: {type: string}}]
+      responses: {}
+`,
+			err: "cookie parameter session is not supported",
+		},
+		"xml": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    post:
+      requestBody: {content: {application/xml: {schema: {type: string}}}}
+      responses: {}
+`,
+			err: "content application/xml is not supported",
+		},
+		"unresolved": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    get:
+      responses:
+        "200": {description: ok, content: {application/json: {schema: {$ref: "#/components/schemas/Missing"}}}}
+`,
+			err: "$ref #/components/schemas/Missing does not resolve",
+		},
+		"remote": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    get:
+      parameters: [{$ref: "common.yaml#/parameters/Limit"}]
+      responses: {}
+`,
+			err: "is not a local reference",
+		},
+		"same package": {
+			document: `openapi: 3.0.3
+paths:
+  /a:
+    get: {tags: [pet-store], responses: {}}
+  /b:
+    get: {tags: [PetStore], responses: {}}
+`,
+			err: "tags pet-store and PetStore are both generated in package petstore",
+		},
+	} {
+		doc, err := parse([]byte(test.document))
+		if err == nil {
+			_, err = build(doc, "example.com/x", false)
+		}
+		assert.ErrorContains(t, err, test.err, name)
+	}
+}
+
+func TestGoType(t *testing.T) {
+	doc, err := parse([]byte(`openapi: 3.0.3
+paths:
+  /a:
+    get: {responses: {}}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+`))
+	require.NoError(t, err)
+
+	c := newConverter(doc, "schemas.")
+	for expected, s := range map[string]*schema{
+		"schemas.Pet":            {Ref: "#/components/schemas/Pet"},
+		"[]schemas.Pet":          {Type: "array", Items: &schema{Ref: "#/components/schemas/Pet"}},
+		"int":                    {Type: "integer"},
+		"int64":                  {Type: "integer", Format: "int64"},
+		"float64":                {Type: "number"},
+		"bool":                   {Type: "boolean"},
+		"string":                 {Type: "string", Format: "date"},
+		"[]byte":                 {Type: "string", Format: "byte"},
+		"map[string]interface{}": {Type: "object"},
+		"map[string][]int32":     {Type: "object", AdditionalProperties: &additional{&schema{Type: "array", Items: &schema{Type: "integer", Format: "int32"}}}},
+		"json.RawMessage":        {OneOf: []*schema{{Type: "string"}, {Type: "integer"}}},
+	} {
+		goType, err := c.goType(s, "Inline")
+		require.NoError(t, err)
+		assert.Equal(t, expected, goType)
+	}
+	assert.Empty(t, c.types)
+
+	goType, err := c.goType(&schema{Type: "array", Items: &schema{Properties: ordered[*schema]{{"n", &schema{Type: "integer"}}}}}, "List")
+	require.NoError(t, err)
+	assert.Equal(t, "[]ListItem", goType)
+	require.Len(t, c.types, 1)
+	assert.Equal(t, "ListItem", c.types[0].Name)
+	assert.Equal(t, "*int", c.types[0].Fields[0].Type)
+}
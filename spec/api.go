@@ -0,0 +1,245 @@
+package spec
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	versionName = regexp.MustCompile(`^v[1-9][0-9]*$`)
+	groupName   = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// names main.go already uses for its imports and variables
+	reservedGroups = map[string]bool{
+		"alog": true, "api": true, "auth": true, "bind": true, "chi": true, "cors": true, "db": true, "deprecation": true,
+		"flag": true, "gin": true, "graphql": true, "http": true, "json": true, "logger": true, "main": true, "mw": true,
+		"net": true, "os": true, "router": true, "rpc": true, "util": true,
+	}
+	// the handlers a later version can override
+	Handlers = []string{"list", "get", "create", "update", "delete"}
+)
+
+// LoadAPI reads a spec with tables and groups at path and resolves it for the driver.
+// The spec is empty when path is empty or holds a table spec, which Load reads.
+func LoadAPI(path, dbms string) (api models.APISpec, err error) {
+	if path == "" {
+		return api, nil
+	}
+	content, err := ReadFile(path)
+	if err != nil {
+		return api, fmt.Errorf("error reading table spec %s: %w", path, err)
+	}
+	if err = yaml.Unmarshal(content, &api); err != nil {
+		return api, fmt.Errorf("error parsing table spec %s: %w", path, err)
+	}
+	if len(api.Tables) == 0 && len(api.Groups) == 0 {
+		return models.APISpec{}, nil
+	}
+	for i, script := range api.Database.InitScripts {
+		if !filepath.IsAbs(script) {
+			api.Database.InitScripts[i] = filepath.Join(filepath.Dir(path), script)
+		}
+	}
+
+	err = ResolveAPI(&api, dbms)
+	return api, err
+}
+
+// ResolveAPI validates the tables, groups and versions of the spec. The database settings
+// go to the first table, the versions are sorted and get the headers of their deprecation.
+func ResolveAPI(api *models.APISpec, dbms string) error {
+	if len(api.Tables) == 0 || len(api.Groups) == 0 {
+		return fmt.Errorf("a spec with groups needs tables and groups")
+	}
+
+	var names []string
+	for _, table := range api.Tables {
+		names = append(names, table.Name)
+	}
+	declared := map[string]bool{}
+	for i := range api.Tables {
+		table := &api.Tables[i]
+		if table.Name == "" || !common.IsValidString(table.Name) || declared[table.Name] {
+			return fmt.Errorf("invalid table name %q", table.Name)
+		}
+		if !isEmptyDatabase(table.Database) {
+			return fmt.Errorf("table %s: database goes at the top of a spec with tables", table.Name)
+		}
+		// the referenced tables are created first
+		for _, column := range table.Columns {
+			if column.References != "" && column.References != table.Name && !declared[column.References] && declaredLater(api.Tables[i:], column.References) {
+				return fmt.Errorf("table %s: declare %s before the tables that reference it", table.Name, column.References)
+			}
+		}
+		declared[table.Name] = true
+
+		if i == 0 {
+			table.Database = api.Database
+		}
+		if err := Resolve(&table.TableSpec, dbms, names...); err != nil {
+			return fmt.Errorf("table %s: %w", table.Name, err)
+		}
+		resolveParents(table, declared)
+	}
+	api.Database = api.Tables[0].Database
+
+	versions := map[string]bool{}
+	groups := map[string]bool{}
+	for i := range api.Groups {
+		group := &api.Groups[i]
+		if !groupName.MatchString(group.Name) || reservedGroups[group.Name] || versionName.MatchString(group.Name) || groups[group.Name] {
+			return fmt.Errorf("invalid group name %q", group.Name)
+		}
+		groups[group.Name] = true
+		if !declared[group.Table] {
+			return fmt.Errorf("group %s: table %q is not declared", group.Name, group.Table)
+		}
+
+		if len(group.Versions) == 0 {
+			group.Versions = []string{"v1"}
+		}
+		served := map[string]bool{}
+		for _, version := range group.Versions {
+			if !versionName.MatchString(version) || served[version] {
+				return fmt.Errorf("group %s: invalid version %q", group.Name, version)
+			}
+			served[version] = true
+			versions[version] = true
+		}
+		sortVersions(group.Versions)
+
+		for version, handlers := range group.Overrides {
+			if !served[version] {
+				return fmt.Errorf("group %s: overrides of %s, a version it is not served under", group.Name, version)
+			}
+			if version == "v1" {
+				return fmt.Errorf("group %s: the handlers of v1 are the ones the later versions override", group.Name)
+			}
+			for _, handler := range handlers {
+				if !contains(Handlers, handler) {
+					return fmt.Errorf("group %s: invalid handler %q, use one of %v", group.Name, handler, Handlers)
+				}
+			}
+		}
+	}
+
+	listed := map[string]bool{}
+	for i := range api.Versions {
+		version := &api.Versions[i]
+		if !versions[version.Name] || listed[version.Name] {
+			return fmt.Errorf("invalid version %q, it needs to be one of the groups", version.Name)
+		}
+		listed[version.Name] = true
+		if err := resolveVersion(version); err != nil {
+			return fmt.Errorf("version %s: %w", version.Name, err)
+		}
+	}
+	for version := range versions {
+		if !listed[version] {
+			api.Versions = append(api.Versions, models.APIVersion{Name: version})
+		}
+	}
+	sort.Slice(api.Versions, func(i, j int) bool {
+		return versionNumber(api.Versions[i].Name) < versionNumber(api.Versions[j].Name)
+	})
+	return nil
+}
+
+// resolveVersion checks the dates of a deprecated version and writes its headers:
+// Deprecation (RFC 9745) is the unix time of the date, Sunset (RFC 8594) an HTTP date.
+func resolveVersion(version *models.APIVersion) error {
+	var deprecation, sunset time.Time
+	var err error
+	if version.Deprecation != "" {
+		deprecation, err = time.Parse(time.DateOnly, version.Deprecation)
+		if err != nil {
+			return fmt.Errorf("invalid deprecation date %q, use YYYY-MM-DD", version.Deprecation)
+		}
+		version.DeprecationHeader = "@" + strconv.FormatInt(deprecation.Unix(), 10)
+	}
+	if version.Sunset != "" {
+		sunset, err = time.Parse(time.DateOnly, version.Sunset)
+		if err != nil {
+			return fmt.Errorf("invalid sunset date %q, use YYYY-MM-DD", version.Sunset)
+		}
+		if !deprecation.IsZero() && sunset.Before(deprecation) {
+			return fmt.Errorf("sunset %s is before the deprecation %s", version.Sunset, version.Deprecation)
+		}
+		version.SunsetHeader = sunset.Format(http.TimeFormat)
+	}
+	if version.Link != "" {
+		link, err := url.Parse(version.Link)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return fmt.Errorf("invalid link %q", version.Link)
+		}
+		switch {
+		case version.DeprecationHeader != "":
+			version.LinkHeader = fmt.Sprintf(`<%s>; rel="deprecation"`, version.Link)
+		case version.SunsetHeader != "":
+			version.LinkHeader = fmt.Sprintf(`<%s>; rel="sunset"`, version.Link)
+		default:
+			return fmt.Errorf("a link needs a deprecation or sunset date")
+		}
+	}
+	return nil
+}
+
+func isEmptyDatabase(database models.DatabaseSpec) bool {
+	return database.Image == "" && database.Tag == "" && len(database.Env) == 0 && len(database.Args) == 0 &&
+		database.Memory == "" && database.CPUs == 0 && len(database.InitScripts) == 0
+}
+
+// resolveParents points the foreign keys to the declared tables at a row of them, the table
+// test creates it first so the sample of the column is a key that exists
+func resolveParents(table *models.Table, declared map[string]bool) {
+	for i := range table.Columns {
+		column := &table.Columns[i]
+		if column.References == "" || column.References == table.Name || !declared[column.References] {
+			continue
+		}
+		column.Parent = common.ToCamelCase(column.References)
+		column.Sample = strings.TrimPrefix(column.GoType, "*") + "(parent" + column.FieldName + ".ID)"
+		if strings.HasPrefix(column.GoType, "*") {
+			column.Sample = "ptr(" + column.Sample + ")"
+		}
+	}
+}
+
+func declaredLater(tables []models.Table, name string) bool {
+	for _, table := range tables {
+		if table.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return versionNumber(versions[i]) < versionNumber(versions[j])
+	})
+}
+
+func versionNumber(version string) int {
+	number, _ := strconv.Atoi(version[1:])
+	return number
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package spec
+
+import (
+	"os"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const librarySpec = `
+database:
+  memory: 512m
+  init_scripts: [seed.sql]
+tables:
+  - name: authors
+    columns:
+      - name: name
+        type: TEXT
+  - name: books
+    columns:
+      - name: title
+        type: TEXT
+      - name: author_id
+        type: INTEGER
+        references: authors
+groups:
+  - name: authors
+    table: authors
+  - name: books
+    table: books
+    versions: [v2, v1]
+    overrides:
+      v2: [get, update]
+versions:
+  - name: v1
+    deprecation: 2026-01-01
+    sunset: 2027-01-01
+    link: https://example.com/migrate
+`
+
+func TestLoadAPI(t *testing.T) {
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte(librarySpec), nil
+	}
+	Stat = func(name string) (os.FileInfo, error) {
+		return nil, nil
+	}
+	defer func() {
+		ReadFile = os.ReadFile
+		Stat = os.Stat
+	}()
+
+	api, err := LoadAPI("specs/library.yaml", "postgres")
+	require.NoError(t, err)
+
+	require.Len(t, api.Tables, 2)
+	assert.Equal(t, "512m", api.Tables[0].Database.Memory)
+	assert.Equal(t, []string{"specs/seed.sql"}, api.Database.InitScripts)
+	assert.Equal(t, "int32", api.Tables[1].Columns[1].GoType)
+	// the table test of books creates the author it references
+	assert.Equal(t, "Authors", api.Tables[1].Columns[1].Parent)
+	assert.Equal(t, "int32(parentAuthorID.ID)", api.Tables[1].Columns[1].Sample)
+	assert.Empty(t, api.Tables[1].Columns[0].Parent)
+	assert.Equal(t, []string{"v1"}, api.Groups[0].Versions)
+	assert.Equal(t, []string{"v1", "v2"}, api.Groups[1].Versions)
+
+	require.Len(t, api.Versions, 2)
+	v1 := api.Versions[0]
+	assert.Equal(t, "@1767225600", v1.DeprecationHeader)
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", v1.SunsetHeader)
+	assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, v1.LinkHeader)
+	assert.Equal(t, models.APIVersion{Name: "v2"}, api.Versions[1])
+}
+
+func TestLoadAPI_TableSpec(t *testing.T) {
+	defer func() { ReadFile = os.ReadFile }()
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte("columns:\n  - name: name\n    type: TEXT\n"), nil
+	}
+
+	// a table spec is left to Load
+	api, err := LoadAPI("spec.yaml", "postgres")
+	require.NoError(t, err)
+	assert.Empty(t, api.Groups)
+
+	api, err = LoadAPI("", "postgres")
+	require.NoError(t, err)
+	assert.Empty(t, api.Tables)
+}
+
+func TestResolveAPI_Errors(t *testing.T) {
+	columns := []models.Column{{Name: "name", Type: "TEXT"}}
+	table := func(name string) models.Table {
+		return models.Table{Name: name, TableSpec: models.TableSpec{Columns: columns}}
+	}
+	books := models.Table{Name: "books", TableSpec: models.TableSpec{Columns: []models.Column{{Name: "author_id", Type: "INTEGER", References: "authors"}}}}
+
+	tests := []struct {
+		name     string
+		api      models.APISpec
+		expected string
+	}{
+		{"no groups", models.APISpec{Tables: []models.Table{table("authors")}}, "needs tables and groups"},
+		{"table name", models.APISpec{Tables: []models.Table{table("a-b")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a-b"}}}, "invalid table name"},
+		{"duplicate table", models.APISpec{Tables: []models.Table{table("a"), table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}}, "invalid table name"},
+		{"table database", models.APISpec{Tables: []models.Table{{Name: "a", TableSpec: models.TableSpec{Columns: columns, Database: models.DatabaseSpec{Tag: "16"}}}}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}}, "database goes at the top"},
+		{"undeclared reference", models.APISpec{Tables: []models.Table{books}, Groups: []models.APIGroupSpec{{Name: "books", Table: "books"}}}, "table books: column author_id: referenced table authors is not a table of the spec"},
+		{"table order", models.APISpec{Tables: []models.Table{books, table("authors")}, Groups: []models.APIGroupSpec{{Name: "books", Table: "books"}}}, "declare authors before"},
+		{"table spec", models.APISpec{Tables: []models.Table{{Name: "a"}}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}}, "table a: table spec needs at least one column"},
+		{"group name", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "Books", Table: "a"}}}, "invalid group name"},
+		{"reserved group", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "router", Table: "a"}}}, "invalid group name"},
+		{"version group", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "v2", Table: "a"}}}, "invalid group name"},
+		{"group table", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "b", Table: "b"}}}, "table \"b\" is not declared"},
+		{"version", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a", Versions: []string{"v0"}}}}, "invalid version \"v0\""},
+		{"override version", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a", Overrides: map[string][]string{"v2": {"get"}}}}}, "not served under"},
+		{"override v1", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a", Overrides: map[string][]string{"v1": {"get"}}}}}, "the handlers of v1"},
+		{"override handler", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a", Versions: []string{"v1", "v2"}, Overrides: map[string][]string{"v2": {"patch"}}}}}, "invalid handler \"patch\""},
+		{"unused version", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}, Versions: []models.APIVersion{{Name: "v3"}}}, "invalid version \"v3\""},
+		{"deprecation date", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}, Versions: []models.APIVersion{{Name: "v1", Deprecation: "01/01/2026"}}}, "invalid deprecation date"},
+		{"sunset before deprecation", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}, Versions: []models.APIVersion{{Name: "v1", Deprecation: "2026-01-01", Sunset: "2025-01-01"}}}, "is before the deprecation"},
+		{"link", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}, Versions: []models.APIVersion{{Name: "v1", Sunset: "2026-01-01", Link: "ftp://example.com"}}}, "invalid link"},
+		{"link without dates", models.APISpec{Tables: []models.Table{table("a")}, Groups: []models.APIGroupSpec{{Name: "a", Table: "a"}}, Versions: []models.APIVersion{{Name: "v1", Link: "https://example.com"}}}, "needs a deprecation or sunset date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResolveAPI(&tt.api, "postgres")
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
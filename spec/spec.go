@@ -0,0 +1,661 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhijithk1/api-service-generator/common"
+	"github.com/abhijithk1/api-service-generator/models"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ReadFile    = os.ReadFile
+	Stat        = os.Stat
+	typeLength  = regexp.MustCompile(`\((\d+)\)`)
+	enumValue   = regexp.MustCompile(`^[\w-]+$`)
+	imageName   = regexp.MustCompile(`^[a-z0-9]+([._/-][a-z0-9]+)*(:[0-9]+/[a-z0-9]+([._/-][a-z0-9]+)*)?$`)
+	imageTag    = regexp.MustCompile(`^\w[\w.-]{0,127}$`)
+	envName     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	memorySize  = regexp.MustCompile(`^(\d+)([bkmg]?)$`)
+	DefaultSpec = models.TableSpec{
+		PrimaryKey: "serial",
+		Columns:    []models.Column{{Name: "name", Type: "VARCHAR(255)"}},
+	}
+)
+
+// Go type of a column and the import it needs
+type goType struct {
+	Type   string
+	Import string
+}
+
+var (
+	stringType  = goType{Type: "string"}
+	int16Type   = goType{Type: "int16"}
+	int32Type   = goType{Type: "int32"}
+	int64Type   = goType{Type: "int64"}
+	boolType    = goType{Type: "bool"}
+	float32Type = goType{Type: "float32"}
+	float64Type = goType{Type: "float64"}
+	bytesType   = goType{Type: "[]byte"}
+	timeType    = goType{Type: "time.Time", Import: "time"}
+	uuidType    = goType{Type: "uuid.UUID", Import: "github.com/google/uuid"}
+	jsonType    = goType{Type: "json.RawMessage", Import: "encoding/json"}
+	ulidPackage = "github.com/oklog/ulid/v2"
+)
+
+// Load reads the table spec at path, or the default spec when path is empty,
+// and resolves it for the driver. name is the table, the only one its columns can reference.
+func Load(path, dbms, name string) (table models.TableSpec, err error) {
+	table = DefaultSpec
+	table.Columns = append([]models.Column{}, DefaultSpec.Columns...)
+	if path != "" {
+		content, err := ReadFile(path)
+		if err != nil {
+			return table, fmt.Errorf("error reading table spec %s: %w", path, err)
+		}
+		table = models.TableSpec{}
+		if err = yaml.Unmarshal(content, &table); err != nil {
+			return table, fmt.Errorf("error parsing table spec %s: %w", path, err)
+		}
+		// init scripts are relative to the spec file
+		for i, script := range table.Database.InitScripts {
+			if !filepath.IsAbs(script) {
+				table.Database.InitScripts[i] = filepath.Join(filepath.Dir(path), script)
+			}
+		}
+	}
+
+	err = Resolve(&table, dbms, name)
+	return table, err
+}
+
+// Resolve validates the spec and fills in the driver specific details. tables are the tables
+// of the spec, the ones a column can reference.
+func Resolve(table *models.TableSpec, dbms string, tables ...string) error {
+	if table.PrimaryKey == "" {
+		table.PrimaryKey = DefaultSpec.PrimaryKey
+	}
+	if len(table.Columns) == 0 {
+		return fmt.Errorf("table spec needs at least one column besides id")
+	}
+
+	key, err := resolveKey(table.PrimaryKey, dbms)
+	if err != nil {
+		return err
+	}
+	table.Key = key
+
+	imports := map[string]bool{}
+	packages := map[string]bool{}
+	addImport := func(imp string) {
+		if imp == "" {
+			return
+		}
+		imports[imp] = true
+		if strings.Contains(imp, ".") {
+			packages[imp] = true
+		}
+	}
+
+	testImports := map[string]bool{}
+	switch table.PrimaryKey {
+	case "uuid":
+		addImport(uuidType.Import)
+	case "ulid":
+		addImport(ulidPackage)
+	}
+	if key.AppGenerated {
+		for imp := range imports {
+			testImports[imp] = true
+		}
+	}
+
+	reserved := reservedColumns(*table)
+	required := []string{}
+	for i := range table.Columns {
+		column := &table.Columns[i]
+		if column.Name == "" || !common.IsValidString(column.Name) || reserved[strings.ToLower(column.Name)] {
+			return fmt.Errorf("invalid column name %q", column.Name)
+		}
+		t, err := columnGoType(column.Type, dbms)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		if err := resolveValues(column); err != nil {
+			return fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		if err := resolveReference(*column, t, tables); err != nil {
+			return fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		addImport(t.Import)
+		if t.Import != "" {
+			testImports[t.Import] = true
+		}
+
+		column.FieldName = FieldName(column.Name)
+		column.GoType = t.Type
+		if column.Nullable && !isSlice(t) {
+			column.GoType = "*" + t.Type
+		}
+		column.Binding = binding(*column, t)
+		if strings.HasPrefix(column.Binding, "required") {
+			required = append(required, column.Name)
+		}
+		column.Sample = sample(*column, t)
+		column.Example = example(*column, t)
+		column.Schema = schema(*column, t)
+	}
+
+	if err := resolveIndexes(table, dbms); err != nil {
+		return err
+	}
+	if err := resolveDatabase(&table.Database, dbms); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+
+	table.RequiredList = strings.Join(required, ", ")
+	table.Imports = sortedKeys(imports)
+	table.TestImports = sortedKeys(testImports)
+	table.Packages = sortedKeys(packages)
+	return nil
+}
+
+// resolveValues checks the values of an enum column
+func resolveValues(column *models.Column) error {
+	if !strings.EqualFold(strings.TrimSpace(column.Type), "ENUM") {
+		if len(column.Values) > 0 {
+			return fmt.Errorf("values are only allowed on ENUM columns")
+		}
+		return nil
+	}
+	if len(column.Values) == 0 {
+		return fmt.Errorf("ENUM column needs values")
+	}
+	quoted := make([]string, len(column.Values))
+	for i, value := range column.Values {
+		if !enumValue.MatchString(value) {
+			return fmt.Errorf("invalid enum value %q", value)
+		}
+		quoted[i] = "'" + value + "'"
+	}
+	column.ValueList = strings.Join(quoted, ", ")
+	return nil
+}
+
+// resolveReference checks the table a foreign key column references, one of the tables of the spec
+// so the migration creates it and the seeds have its keys
+func resolveReference(column models.Column, t goType, tables []string) error {
+	if column.References == "" {
+		return nil
+	}
+	if !common.IsValidString(column.References) {
+		return fmt.Errorf("invalid referenced table %q", column.References)
+	}
+	if len(column.Values) > 0 || isSlice(t) || t == jsonType {
+		return fmt.Errorf("a %s column cannot reference a table", column.Type)
+	}
+	if !contains(tables, column.References) {
+		return fmt.Errorf("referenced table %s is not a table of the spec", column.References)
+	}
+	return nil
+}
+
+// isSlice reports whether the Go type is nil-able without a pointer
+func isSlice(t goType) bool {
+	return strings.HasPrefix(t.Type, "[]")
+}
+
+// arrayElement is the element column of a postgres array column
+func arrayElement(column models.Column) (models.Column, bool) {
+	sqlType := strings.TrimSpace(column.Type)
+	if !strings.HasSuffix(sqlType, "[]") {
+		return column, false
+	}
+	return models.Column{Name: column.Name, Type: strings.TrimSuffix(sqlType, "[]")}, true
+}
+
+// resolveIndexes validates the indexes and constraints and names the unnamed ones.
+// The names are prefixed with the table name in the migration.
+func resolveIndexes(table *models.TableSpec, dbms string) error {
+	known := reservedColumns(*table)
+	for _, column := range table.Columns {
+		known[strings.ToLower(column.Name)] = true
+	}
+	columnList := func(columns []string) (string, error) {
+		if len(columns) == 0 {
+			return "", fmt.Errorf("needs at least one column")
+		}
+		for _, column := range columns {
+			if !known[strings.ToLower(column)] {
+				return "", fmt.Errorf("unknown column %q", column)
+			}
+		}
+		return strings.Join(columns, ", "), nil
+	}
+	validName := func(name string) error {
+		if !common.IsValidString(name) {
+			return fmt.Errorf("invalid name %q", name)
+		}
+		return nil
+	}
+
+	for i := range table.Indexes {
+		index := &table.Indexes[i]
+		list, err := columnList(index.Columns)
+		if err != nil {
+			return fmt.Errorf("index %d: %w", i+1, err)
+		}
+		index.ColumnList = list
+		if index.Name == "" {
+			suffix := "idx"
+			if index.Unique {
+				suffix = "key"
+			}
+			index.Name = strings.Join(append(append([]string{}, index.Columns...), suffix), "_")
+		}
+		if err := validName(index.Name); err != nil {
+			return fmt.Errorf("index %d: %w", i+1, err)
+		}
+		if index.Where != "" && dbms != "postgres" {
+			return fmt.Errorf("index %s: partial indexes are only supported on postgres", index.Name)
+		}
+	}
+
+	for i := range table.Constraints {
+		constraint := &table.Constraints[i]
+		switch {
+		case constraint.Check != "" && len(constraint.Unique) > 0:
+			return fmt.Errorf("constraint %d: set either unique or check, not both", i+1)
+		case constraint.Check != "":
+			if constraint.Name == "" {
+				constraint.Name = fmt.Sprintf("check_%d", i+1)
+			}
+		default:
+			list, err := columnList(constraint.Unique)
+			if err != nil {
+				return fmt.Errorf("constraint %d: %w", i+1, err)
+			}
+			constraint.ColumnList = list
+			if constraint.Name == "" {
+				constraint.Name = strings.Join(append(append([]string{}, constraint.Unique...), "key"), "_")
+			}
+		}
+		if err := validName(constraint.Name); err != nil {
+			return fmt.Errorf("constraint %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// credentialEnv is the environment the generator sets from the prompts
+var credentialEnv = map[string][]string{
+	"postgres": {"POSTGRES_USER", "POSTGRES_PASSWORD", "POSTGRES_DB"},
+	"mysql":    {"MYSQL_ROOT_PASSWORD", "MYSQL_USER", "MYSQL_PASSWORD", "MYSQL_DATABASE"},
+}
+
+// resolveDatabase validates the container settings. A custom image has to be pinned to a tag.
+func resolveDatabase(database *models.DatabaseSpec, dbms string) error {
+	if database.Image != "" && !imageName.MatchString(database.Image) {
+		return fmt.Errorf("invalid image %q, set the version with tag", database.Image)
+	}
+	if database.Tag != "" && !imageTag.MatchString(database.Tag) {
+		return fmt.Errorf("invalid tag %q", database.Tag)
+	}
+	if database.Image != "" && database.Tag == "" {
+		return fmt.Errorf("image %s needs a tag", database.Image)
+	}
+
+	for name := range database.Env {
+		if !envName.MatchString(name) {
+			return fmt.Errorf("invalid env name %q", name)
+		}
+		for _, credential := range credentialEnv[dbms] {
+			if strings.EqualFold(name, credential) {
+				return fmt.Errorf("env %s is set from the prompts", name)
+			}
+		}
+	}
+
+	if database.Memory != "" {
+		match := memorySize.FindStringSubmatch(strings.ToLower(database.Memory))
+		if match == nil {
+			return fmt.Errorf("invalid memory %q, use a size like 512m or 1g", database.Memory)
+		}
+		size, _ := strconv.ParseInt(match[1], 10, 64)
+		unit := match[2]
+		if unit == "" {
+			unit = "b"
+		}
+		database.MemoryBytes = size << (10 * strings.Index("bkmg", unit))
+		if database.MemoryBytes < 6<<20 {
+			return fmt.Errorf("memory %s is below the 6m minimum", database.Memory)
+		}
+	}
+	if database.CPUs < 0 {
+		return fmt.Errorf("invalid cpus %v", database.CPUs)
+	}
+
+	for _, script := range database.InitScripts {
+		if !strings.HasSuffix(script, ".sql") && !strings.HasSuffix(script, ".sql.gz") && !strings.HasSuffix(script, ".sh") {
+			return fmt.Errorf("init script %s should be a .sql, .sql.gz or .sh file", script)
+		}
+		if _, err := Stat(script); err != nil {
+			return fmt.Errorf("init script %s: %w", script, err)
+		}
+	}
+	return nil
+}
+
+// reservedColumns are the columns the generator adds itself
+func reservedColumns(table models.TableSpec) map[string]bool {
+	reserved := map[string]bool{"id": true}
+	if table.Timestamps {
+		reserved["created_at"], reserved["updated_at"] = true, true
+	}
+	if table.SoftDelete {
+		reserved["deleted_at"] = true
+	}
+	if table.Versioned {
+		reserved["version"] = true
+	}
+	return reserved
+}
+
+func resolveKey(primaryKey, dbms string) (models.Key, error) {
+	switch dbms + "/" + primaryKey {
+	case "postgres/serial":
+		return models.Key{Definition: "SERIAL PRIMARY KEY", GoType: int32Type.Type}, nil
+	case "postgres/bigserial":
+		return models.Key{Definition: "BIGSERIAL PRIMARY KEY", GoType: int64Type.Type}, nil
+	case "postgres/uuid":
+		return models.Key{Definition: "UUID PRIMARY KEY DEFAULT gen_random_uuid()", GoType: uuidType.Type}, nil
+	case "mysql/serial":
+		return models.Key{Definition: "INT AUTO_INCREMENT PRIMARY KEY", GoType: int32Type.Type}, nil
+	case "mysql/bigserial":
+		return models.Key{Definition: "BIGINT AUTO_INCREMENT PRIMARY KEY", GoType: int64Type.Type}, nil
+	case "mysql/uuid":
+		// MySQL cannot return the default, so the application generates it
+		return models.Key{Definition: "CHAR(36) PRIMARY KEY DEFAULT (UUID())", GoType: uuidType.Type, AppGenerated: true}, nil
+	case "postgres/ulid", "mysql/ulid":
+		return models.Key{Definition: "CHAR(26) PRIMARY KEY", GoType: stringType.Type, AppGenerated: true}, nil
+	}
+
+	if dbms != "postgres" && dbms != "mysql" {
+		return models.Key{}, fmt.Errorf("driver not supported")
+	}
+	return models.Key{}, fmt.Errorf("primary key %q not supported, use serial, bigserial, uuid or ulid", primaryKey)
+}
+
+// columnGoType mirrors the types sqlc generates for database/sql
+func columnGoType(sqlType, dbms string) (goType, error) {
+	upper := strings.ToUpper(strings.TrimSpace(sqlType))
+	base := strings.TrimSpace(strings.SplitN(upper, "(", 2)[0])
+
+	if strings.HasSuffix(upper, "[]") {
+		if dbms == "mysql" {
+			return goType{}, fmt.Errorf("array columns are only supported on postgres")
+		}
+		element, err := columnGoType(strings.TrimSuffix(strings.TrimSpace(sqlType), "[]"), dbms)
+		if err != nil {
+			return goType{}, err
+		}
+		return goType{Type: "[]" + element.Type, Import: element.Import}, nil
+	}
+
+	switch base {
+	case "VARCHAR", "CHAR", "CHARACTER", "CHARACTER VARYING", "BPCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "DECIMAL", "NUMERIC", "ENUM":
+		return stringType, nil
+	case "SMALLINT", "INT2":
+		return int16Type, nil
+	case "INT", "INTEGER", "INT4", "MEDIUMINT":
+		return int32Type, nil
+	case "BIGINT", "INT8":
+		return int64Type, nil
+	case "BOOLEAN", "BOOL":
+		return boolType, nil
+	case "TINYINT":
+		if upper == "TINYINT(1)" {
+			return boolType, nil
+		}
+		return goType{Type: "int8"}, nil
+	case "REAL", "FLOAT4":
+		if dbms == "mysql" {
+			return float64Type, nil
+		}
+		return float32Type, nil
+	case "FLOAT", "FLOAT8", "DOUBLE", "DOUBLE PRECISION":
+		return float64Type, nil
+	case "DATE", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "DATETIME":
+		return timeType, nil
+	case "UUID":
+		return uuidType, nil
+	case "JSON", "JSONB":
+		return jsonType, nil
+	case "BYTEA", "BLOB", "BINARY", "VARBINARY":
+		return bytesType, nil
+	}
+
+	return goType{}, fmt.Errorf("column type %s not supported", sqlType)
+}
+
+// SQLCOverrides pins the Go types of the primary key and nullable columns
+func SQLCOverrides(tableName string, table models.TableSpec) []models.Override {
+	overrides := []models.Override{}
+	switch table.PrimaryKey {
+	case "uuid":
+		overrides = append(overrides, models.Override{
+			Column: tableName + ".id",
+			GoType: models.OverrideType{Import: uuidType.Import, Type: "UUID"},
+		})
+	case "ulid":
+		overrides = append(overrides, models.Override{
+			Column: tableName + ".id",
+			GoType: models.OverrideType{Type: stringType.Type},
+		})
+	}
+
+	for _, column := range table.Columns {
+		if len(column.Values) > 0 && !column.Nullable {
+			// sqlc would generate its own type for the enum
+			overrides = append(overrides, models.Override{
+				Column: tableName + "." + column.Name,
+				GoType: models.OverrideType{Type: stringType.Type},
+			})
+			continue
+		}
+		if !strings.HasPrefix(column.GoType, "*") {
+			continue
+		}
+		override := models.OverrideType{Type: strings.TrimPrefix(column.GoType, "*"), Pointer: true}
+		if dot := strings.LastIndex(override.Type, "."); dot >= 0 {
+			t, _ := columnGoType(column.Type, "")
+			override.Import = t.Import
+			override.Type = override.Type[dot+1:]
+		}
+		overrides = append(overrides, models.Override{Column: tableName + "." + column.Name, GoType: override})
+	}
+
+	if table.SoftDelete {
+		overrides = append(overrides, models.Override{
+			Column: tableName + ".deleted_at",
+			GoType: models.OverrideType{Import: timeType.Import, Type: "Time", Pointer: true},
+		})
+	}
+
+	if len(overrides) == 0 {
+		return nil
+	}
+	return overrides
+}
+
+// FieldName is the Go field sqlc generates for a column, e.g. user_id -> UserID
+func FieldName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if strings.ToLower(part) == "id" {
+			parts[i] = "ID"
+			continue
+		}
+		parts[i] = common.ToCamelCase(part)
+	}
+	return strings.Join(parts, "")
+}
+
+func binding(column models.Column, t goType) string {
+	if len(column.Values) > 0 {
+		rule := "oneof=" + strings.Join(column.Values, " ")
+		if column.Nullable {
+			return "omitempty," + rule
+		}
+		return "required," + rule
+	}
+	if column.Nullable || t != stringType {
+		return ""
+	}
+	rules := []string{"required"}
+	if length := typeLength.FindStringSubmatch(column.Type); length != nil && !strings.Contains(strings.ToUpper(column.Type), "DECIMAL") && !strings.Contains(strings.ToUpper(column.Type), "NUMERIC") {
+		rules = append(rules, "max="+length[1])
+	}
+	return strings.Join(rules, ",")
+}
+
+// sample is a Go expression of the column type, used in the generated tests
+func sample(column models.Column, t goType) string {
+	if column.Nullable && !isSlice(t) {
+		column.Nullable = false
+		return "ptr(" + sample(column, t) + ")"
+	}
+	if len(column.Values) > 0 {
+		return strconv.Quote(column.Values[0])
+	}
+	if element, ok := arrayElement(column); ok {
+		return t.Type + "{" + sample(element, goType{Type: strings.TrimPrefix(t.Type, "[]"), Import: t.Import}) + "}"
+	}
+	switch t {
+	case stringType:
+		upper := strings.ToUpper(column.Type)
+		if strings.HasPrefix(upper, "DECIMAL") || strings.HasPrefix(upper, "NUMERIC") {
+			return strconv.Quote("1.00")
+		}
+		value := column.Name
+		if length := typeLength.FindStringSubmatch(column.Type); length != nil {
+			if n, _ := strconv.Atoi(length[1]); n < len(value) {
+				value = value[:n]
+			}
+		}
+		return strconv.Quote(value)
+	case boolType:
+		return "true"
+	case timeType:
+		return "time.Now().UTC().Truncate(time.Second)"
+	case uuidType:
+		return "uuid.New()"
+	case jsonType:
+		return "json.RawMessage(`{}`)"
+	case bytesType:
+		return "[]byte(\"" + column.Name + "\")"
+	default:
+		return "1"
+	}
+}
+
+// example is a JSON value of the column type, used in api.http
+func example(column models.Column, t goType) string {
+	if len(column.Values) > 0 {
+		return strconv.Quote(column.Values[0])
+	}
+	if element, ok := arrayElement(column); ok {
+		return "[" + example(element, goType{Type: strings.TrimPrefix(t.Type, "[]"), Import: t.Import}) + "]"
+	}
+	switch t {
+	case int16Type, int32Type, int64Type, float32Type, float64Type, goType{Type: "int8"}:
+		return "1"
+	case boolType:
+		return "true"
+	case timeType:
+		return strconv.Quote("2024-01-01T00:00:00Z")
+	case uuidType:
+		return strconv.Quote("00000000-0000-4000-8000-000000000000")
+	case jsonType:
+		return "{}"
+	case bytesType:
+		return strconv.Quote("")
+	default:
+		s, _ := strconv.Unquote(sample(models.Column{Name: column.Name, Type: column.Type}, t))
+		return strconv.Quote(s)
+	}
+}
+
+// schema is the OpenAPI schema of the column as a YAML flow mapping, used in api/openapi.yaml
+func schema(column models.Column, t goType) string {
+	fields := typeSchema(column, t)
+	if column.Nullable {
+		fields = append(fields, "nullable: true")
+	}
+	fields = append(fields, "example: "+column.Example)
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// typeSchema is the type of the column in OpenAPI, a JSON column takes any value
+func typeSchema(column models.Column, t goType) []string {
+	if len(column.Values) > 0 {
+		quoted := make([]string, len(column.Values))
+		for i, value := range column.Values {
+			quoted[i] = strconv.Quote(value)
+		}
+		return []string{"type: string", "enum: [" + strings.Join(quoted, ", ") + "]"}
+	}
+	if element, ok := arrayElement(column); ok {
+		items := typeSchema(element, goType{Type: strings.TrimPrefix(t.Type, "[]"), Import: t.Import})
+		return []string{"type: array", "items: {" + strings.Join(items, ", ") + "}"}
+	}
+	switch t {
+	case stringType:
+		upper := strings.ToUpper(column.Type)
+		if strings.HasPrefix(upper, "DECIMAL") || strings.HasPrefix(upper, "NUMERIC") {
+			return []string{"type: string", "format: decimal"}
+		}
+		if length := typeLength.FindStringSubmatch(column.Type); length != nil {
+			return []string{"type: string", "maxLength: " + length[1]}
+		}
+		return []string{"type: string"}
+	case goType{Type: "int8"}:
+		return []string{"type: integer", "minimum: -128", "maximum: 127"}
+	case int16Type:
+		return []string{"type: integer", "minimum: -32768", "maximum: 32767"}
+	case int32Type:
+		return []string{"type: integer", "format: int32"}
+	case int64Type:
+		return []string{"type: integer", "format: int64"}
+	case float32Type:
+		return []string{"type: number", "format: float"}
+	case float64Type:
+		return []string{"type: number", "format: double"}
+	case boolType:
+		return []string{"type: boolean"}
+	case timeType:
+		return []string{"type: string", "format: date-time"}
+	case uuidType:
+		return []string{"type: string", "format: uuid"}
+	case bytesType:
+		return []string{"type: string", "format: byte"}
+	default:
+		return nil
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,373 @@
+package spec
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/abhijithk1/api-service-generator/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+func TestLoad_Default(t *testing.T) {
+	table, err := Load("", "postgres", "api_table")
+	require.NoError(t, err)
+
+	assert.Equal(t, "serial", table.PrimaryKey)
+	assert.Equal(t, models.Key{Definition: "SERIAL PRIMARY KEY", GoType: "int32"}, table.Key)
+	assert.Len(t, table.Columns, 1)
+	assert.Equal(t, "Name", table.Columns[0].FieldName)
+	assert.Equal(t, "string", table.Columns[0].GoType)
+	assert.Equal(t, "required,max=255", table.Columns[0].Binding)
+	assert.Equal(t, `{type: string, maxLength: 255, example: "name"}`, table.Columns[0].Schema)
+	assert.Empty(t, table.Packages)
+
+	// resolving must not leak into the default spec
+	assert.Empty(t, DefaultSpec.Columns[0].GoType)
+}
+
+func TestLoad_File(t *testing.T) {
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte(`
+primary_key: ulid
+columns:
+  - name: owner_id
+    type: UUID
+  - name: born_on
+    type: DATE
+    nullable: true
+`), nil
+	}
+	defer func() { ReadFile = os.ReadFile }()
+
+	table, err := Load("spec.yaml", "postgres", "api_table")
+	require.NoError(t, err)
+
+	assert.Equal(t, models.Key{Definition: "CHAR(26) PRIMARY KEY", GoType: "string", AppGenerated: true}, table.Key)
+	assert.Equal(t, "OwnerID", table.Columns[0].FieldName)
+	assert.Equal(t, "uuid.UUID", table.Columns[0].GoType)
+	assert.Equal(t, "*time.Time", table.Columns[1].GoType)
+	assert.Equal(t, "ptr(time.Now().UTC().Truncate(time.Second))", table.Columns[1].Sample)
+	assert.Equal(t, []string{"github.com/google/uuid", "github.com/oklog/ulid/v2", "time"}, table.Imports)
+	assert.Equal(t, []string{"github.com/google/uuid", "github.com/oklog/ulid/v2"}, table.Packages)
+	assert.Equal(t, table.Imports, table.TestImports)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	defer func() { ReadFile = os.ReadFile }()
+
+	ReadFile = func(name string) ([]byte, error) {
+		return nil, errors.New("no such file")
+	}
+	_, err := Load("spec.yaml", "postgres", "api_table")
+	assert.ErrorContains(t, err, "error reading table spec")
+
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte("columns: ["), nil
+	}
+	_, err = Load("spec.yaml", "postgres", "api_table")
+	assert.ErrorContains(t, err, "error parsing table spec")
+}
+
+func TestLoad_References(t *testing.T) {
+	defer func() { ReadFile = os.ReadFile }()
+
+	// a single table can only reference itself, other tables are not generated
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte("columns:\n  - name: parent_id\n    type: INTEGER\n    references: categories\n"), nil
+	}
+	_, err := Load("spec.yaml", "postgres", "categories")
+	assert.NoError(t, err)
+
+	_, err = Load("spec.yaml", "postgres", "products")
+	assert.EqualError(t, err, "column parent_id: referenced table categories is not a table of the spec")
+}
+
+func TestResolve_PrimaryKeys(t *testing.T) {
+	tests := []struct {
+		dbms       string
+		primaryKey string
+		expected   models.Key
+	}{
+		{"postgres", "bigserial", models.Key{Definition: "BIGSERIAL PRIMARY KEY", GoType: "int64"}},
+		{"postgres", "uuid", models.Key{Definition: "UUID PRIMARY KEY DEFAULT gen_random_uuid()", GoType: "uuid.UUID"}},
+		{"mysql", "serial", models.Key{Definition: "INT AUTO_INCREMENT PRIMARY KEY", GoType: "int32"}},
+		{"mysql", "bigserial", models.Key{Definition: "BIGINT AUTO_INCREMENT PRIMARY KEY", GoType: "int64"}},
+		{"mysql", "uuid", models.Key{Definition: "CHAR(36) PRIMARY KEY DEFAULT (UUID())", GoType: "uuid.UUID", AppGenerated: true}},
+		{"mysql", "ulid", models.Key{Definition: "CHAR(26) PRIMARY KEY", GoType: "string", AppGenerated: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dbms+"/"+tt.primaryKey, func(t *testing.T) {
+			table := models.TableSpec{PrimaryKey: tt.primaryKey, Columns: []models.Column{{Name: "name", Type: "TEXT"}}}
+			require.NoError(t, Resolve(&table, tt.dbms))
+			assert.Equal(t, tt.expected, table.Key)
+		})
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		dbms     string
+		table    models.TableSpec
+		expected string
+	}{
+		{"no columns", "postgres", models.TableSpec{}, "at least one column"},
+		{"primary key", "postgres", models.TableSpec{PrimaryKey: "snowflake", Columns: []models.Column{{Name: "a", Type: "TEXT"}}}, "primary key \"snowflake\" not supported"},
+		{"driver", "oracle", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "TEXT"}}}, "driver not supported"},
+		{"column name", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a-b", Type: "TEXT"}}}, "invalid column name"},
+		{"id column", "postgres", models.TableSpec{Columns: []models.Column{{Name: "id", Type: "TEXT"}}}, "invalid column name"},
+		{"timestamp column", "postgres", models.TableSpec{Timestamps: true, Columns: []models.Column{{Name: "created_at", Type: "TIMESTAMP"}}}, "invalid column name"},
+		{"version column", "mysql", models.TableSpec{Versioned: true, Columns: []models.Column{{Name: "version", Type: "INT"}}}, "invalid column name"},
+		{"enum without values", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "ENUM"}}}, "ENUM column needs values"},
+		{"enum value", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "ENUM", Values: []string{"it's"}}}}, "invalid enum value"},
+		{"values without enum", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "TEXT", Values: []string{"x"}}}}, "only allowed on ENUM columns"},
+		{"array on mysql", "mysql", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "TEXT[]"}}}, "only supported on postgres"},
+		{"column type", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "GEOMETRY"}}}, "column type GEOMETRY not supported"},
+		{"referenced table", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "INTEGER", References: "public.users"}}}, "invalid referenced table"},
+		{"array reference", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "INTEGER[]", References: "users"}}}, "a INTEGER[] column cannot reference a table"},
+		{"undeclared reference", "postgres", models.TableSpec{Columns: []models.Column{{Name: "a", Type: "INTEGER", References: "users"}}}, "referenced table users is not a table of the spec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Resolve(&tt.table, tt.dbms)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestColumnGoType(t *testing.T) {
+	tests := []struct {
+		sqlType  string
+		dbms     string
+		expected string
+	}{
+		{"varchar(20)", "postgres", "string"},
+		{"NUMERIC(10,2)", "postgres", "string"},
+		{"SMALLINT", "mysql", "int16"},
+		{"INTEGER", "postgres", "int32"},
+		{"BIGINT", "mysql", "int64"},
+		{"TINYINT(1)", "mysql", "bool"},
+		{"TINYINT", "mysql", "int8"},
+		{"REAL", "postgres", "float32"},
+		{"REAL", "mysql", "float64"},
+		{"DOUBLE PRECISION", "postgres", "float64"},
+		{"TIMESTAMPTZ", "postgres", "time.Time"},
+		{"JSONB", "postgres", "json.RawMessage"},
+		{"BYTEA", "postgres", "[]byte"},
+		{"ENUM", "mysql", "string"},
+		{"INTEGER[]", "postgres", "[]int32"},
+		{"timestamptz[]", "postgres", "[]time.Time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sqlType, func(t *testing.T) {
+			goType, err := columnGoType(tt.sqlType, tt.dbms)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, goType.Type)
+		})
+	}
+}
+
+func TestResolve_EnumAndArray(t *testing.T) {
+	table := models.TableSpec{Columns: []models.Column{
+		{Name: "status", Type: "ENUM", Values: []string{"draft", "live"}},
+		{Name: "mood", Type: "enum", Values: []string{"ok"}, Nullable: true},
+		{Name: "tags", Type: "TEXT[]", Nullable: true},
+	}}
+	require.NoError(t, Resolve(&table, "postgres"))
+
+	status, mood, tags := table.Columns[0], table.Columns[1], table.Columns[2]
+	assert.Equal(t, "string", status.GoType)
+	assert.Equal(t, "'draft', 'live'", status.ValueList)
+	assert.Equal(t, "required,oneof=draft live", status.Binding)
+	assert.Equal(t, `"draft"`, status.Sample)
+	assert.Equal(t, "*string", mood.GoType)
+	assert.Equal(t, "omitempty,oneof=ok", mood.Binding)
+	assert.Equal(t, "[]string", tags.GoType)
+	assert.Equal(t, `[]string{"tags"}`, tags.Sample)
+	assert.Equal(t, `["tags"]`, tags.Example)
+	assert.Equal(t, `{type: string, enum: ["draft", "live"], example: "draft"}`, status.Schema)
+	assert.Equal(t, `{type: string, enum: ["ok"], nullable: true, example: "ok"}`, mood.Schema)
+	assert.Equal(t, `{type: array, items: {type: string}, nullable: true, example: ["tags"]}`, tags.Schema)
+
+	expected := []models.Override{
+		{Column: "posts.status", GoType: models.OverrideType{Type: "string"}},
+		{Column: "posts.mood", GoType: models.OverrideType{Type: "string", Pointer: true}},
+	}
+	assert.Equal(t, expected, SQLCOverrides("posts", table))
+}
+
+func TestResolve_Indexes(t *testing.T) {
+	table := models.TableSpec{
+		SoftDelete: true,
+		Columns:    []models.Column{{Name: "email", Type: "TEXT"}, {Name: "price", Type: "INTEGER"}},
+		Indexes: []models.Index{
+			{Columns: []string{"email", "deleted_at"}},
+			{Name: "live_email", Columns: []string{"email"}, Unique: true, Where: "deleted_at IS NULL"},
+		},
+		Constraints: []models.Constraint{
+			{Unique: []string{"email", "price"}},
+			{Check: "price >= 0"},
+		},
+	}
+	require.NoError(t, Resolve(&table, "postgres"))
+
+	assert.Equal(t, "email_deleted_at_idx", table.Indexes[0].Name)
+	assert.Equal(t, "email, deleted_at", table.Indexes[0].ColumnList)
+	assert.Equal(t, "live_email", table.Indexes[1].Name)
+	assert.Equal(t, "email_price_key", table.Constraints[0].Name)
+	assert.Equal(t, "email, price", table.Constraints[0].ColumnList)
+	assert.Equal(t, "check_2", table.Constraints[1].Name)
+}
+
+func TestResolve_IndexErrors(t *testing.T) {
+	columns := []models.Column{{Name: "email", Type: "TEXT"}}
+	tests := []struct {
+		name     string
+		dbms     string
+		table    models.TableSpec
+		expected string
+	}{
+		{"no columns", "postgres", models.TableSpec{Columns: columns, Indexes: []models.Index{{}}}, "needs at least one column"},
+		{"unknown column", "postgres", models.TableSpec{Columns: columns, Indexes: []models.Index{{Columns: []string{"deleted_at"}}}}, "unknown column \"deleted_at\""},
+		{"partial on mysql", "mysql", models.TableSpec{Columns: columns, Indexes: []models.Index{{Columns: []string{"email"}, Where: "email <> ''"}}}, "only supported on postgres"},
+		{"invalid name", "postgres", models.TableSpec{Columns: columns, Indexes: []models.Index{{Name: "a-b", Columns: []string{"email"}}}}, "invalid name"},
+		{"unique and check", "postgres", models.TableSpec{Columns: columns, Constraints: []models.Constraint{{Unique: []string{"email"}, Check: "email <> ''"}}}, "not both"},
+		{"empty constraint", "postgres", models.TableSpec{Columns: columns, Constraints: []models.Constraint{{}}}, "needs at least one column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Resolve(&tt.table, tt.dbms)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLoad_Database(t *testing.T) {
+	ReadFile = func(name string) ([]byte, error) {
+		return []byte(`
+columns:
+  - name: email
+    type: TEXT
+database:
+  tag: "16.4"
+  env:
+    TZ: UTC
+  args: ["-c", "max_connections=200"]
+  memory: 512m
+  cpus: 1.5
+  init_scripts: [init/extensions.sql, /opt/seed.sh]
+`), nil
+	}
+	var checked []string
+	Stat = func(name string) (os.FileInfo, error) {
+		checked = append(checked, name)
+		return nil, nil
+	}
+	defer func() {
+		ReadFile = os.ReadFile
+		Stat = os.Stat
+	}()
+
+	table, err := Load("specs/table.yaml", "postgres", "api_table")
+	require.NoError(t, err)
+
+	database := table.Database
+	assert.Equal(t, "16.4", database.Tag)
+	assert.Equal(t, map[string]string{"TZ": "UTC"}, database.Env)
+	assert.Equal(t, []string{"-c", "max_connections=200"}, database.Args)
+	assert.Equal(t, int64(512<<20), database.MemoryBytes)
+	assert.Equal(t, 1.5, database.CPUs)
+	// relative init scripts are found next to the spec
+	assert.Equal(t, []string{"specs/init/extensions.sql", "/opt/seed.sh"}, database.InitScripts)
+	assert.Equal(t, database.InitScripts, checked)
+}
+
+func TestResolve_DatabaseErrors(t *testing.T) {
+	Stat = func(name string) (os.FileInfo, error) {
+		return nil, os.ErrNotExist
+	}
+	defer func() { Stat = os.Stat }()
+
+	columns := []models.Column{{Name: "email", Type: "TEXT"}}
+	tests := []struct {
+		name     string
+		dbms     string
+		database models.DatabaseSpec
+		expected string
+	}{
+		{"image without tag", "postgres", models.DatabaseSpec{Image: "postgis/postgis"}, "image postgis/postgis needs a tag"},
+		{"tag in image", "postgres", models.DatabaseSpec{Image: "postgis/postgis:16-3.4"}, "set the version with tag"},
+		{"invalid tag", "postgres", models.DatabaseSpec{Tag: "16 beta"}, "invalid tag"},
+		{"credential env", "mysql", models.DatabaseSpec{Env: map[string]string{"MYSQL_PASSWORD": "x"}}, "set from the prompts"},
+		{"invalid env", "postgres", models.DatabaseSpec{Env: map[string]string{"1TZ": "UTC"}}, "invalid env name"},
+		{"invalid memory", "postgres", models.DatabaseSpec{Memory: "lots"}, "invalid memory"},
+		{"memory below minimum", "postgres", models.DatabaseSpec{Memory: "1m"}, "below the 6m minimum"},
+		{"negative cpus", "postgres", models.DatabaseSpec{CPUs: -1}, "invalid cpus"},
+		{"init script type", "postgres", models.DatabaseSpec{InitScripts: []string{"init.txt"}}, "should be a .sql"},
+		{"missing init script", "postgres", models.DatabaseSpec{InitScripts: []string{"init.sql"}}, "init script init.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := models.TableSpec{Columns: columns, Database: tt.database}
+			err := Resolve(&table, tt.dbms)
+			assert.ErrorContains(t, err, "database: ")
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	table := models.TableSpec{Columns: columns, Database: models.DatabaseSpec{Image: "registry.local:5000/db/postgres", Tag: "16.4-alpine", Memory: "1G"}}
+	require.NoError(t, Resolve(&table, "postgres"))
+	assert.Equal(t, int64(1<<30), table.Database.MemoryBytes)
+}
+
+func TestSQLCOverrides(t *testing.T) {
+	table := models.TableSpec{
+		PrimaryKey: "uuid",
+		Columns: []models.Column{
+			{Name: "name", Type: "TEXT"},
+			{Name: "nickname", Type: "TEXT", Nullable: true},
+			{Name: "born_on", Type: "DATE", Nullable: true},
+		},
+	}
+	require.NoError(t, Resolve(&table, "mysql"))
+
+	expected := []models.Override{
+		{Column: "users.id", GoType: models.OverrideType{Import: "github.com/google/uuid", Type: "UUID"}},
+		{Column: "users.nickname", GoType: models.OverrideType{Type: "string", Pointer: true}},
+		{Column: "users.born_on", GoType: models.OverrideType{Import: "time", Type: "Time", Pointer: true}},
+	}
+	assert.Equal(t, expected, SQLCOverrides("users", table))
+
+	assert.Nil(t, SQLCOverrides("users", models.TableSpec{PrimaryKey: "serial", Columns: table.Columns[:1]}))
+}
+
+func TestSQLCOverrides_SoftDelete(t *testing.T) {
+	table := models.TableSpec{SoftDelete: true, Columns: []models.Column{{Name: "name", Type: "TEXT"}}}
+	require.NoError(t, Resolve(&table, "postgres"))
+
+	expected := []models.Override{
+		{Column: "users.deleted_at", GoType: models.OverrideType{Import: "time", Type: "Time", Pointer: true}},
+	}
+	assert.Equal(t, expected, SQLCOverrides("users", table))
+
+	// deleted_at is only reserved while soft delete is on
+	table = models.TableSpec{Columns: []models.Column{{Name: "deleted_at", Type: "TIMESTAMP"}}}
+	assert.NoError(t, Resolve(&table, "postgres"))
+}
+
+func TestFieldName(t *testing.T) {
+	assert.Equal(t, "Name", FieldName("name"))
+	assert.Equal(t, "UserID", FieldName("user_id"))
+	assert.Equal(t, "CreatedAt", FieldName("created_at"))
+}
@@ -42,21 +42,31 @@ type Config struct {
 	DBSource               string ` + "`mapstructure:\"DB_SOURCE\"`" + `
 	SECURE                 bool   ` + "`mapstrucure:\"SECURE\"`" + `
 	AUTH                   string ` + "`mapstructure:\"AUTH\"`" + `
+	DOCS                   string ` + "`mapstructure:\"DOCS\"`" + `
+	GRPCPort               string ` + "`mapstructure:\"GRPC_PORT\"`" + `
+	GraphQLPlayground      string ` + "`mapstructure:\"GRAPHQL_PLAYGROUND\"`" + `
 }
 
 var vpr = viper.ReadInConfig
 
+// keys of Config, bound to the environment so it configures the service without app.env,
+// like in the container image
+var keys = []string{"DB_DRIVER", "DB_SOURCE", "SECURE", "AUTH", "DOCS", "GRPC_PORT", "GRAPHQL_PLAYGROUND"}
+
 func LoadConfig(path string) (config Config, err error) {
 	viper.AddConfigPath(path)
 	viper.SetConfigName("app")
 	viper.SetConfigType("env")
 
 	viper.AutomaticEnv()
+	for _, key := range keys {
+		viper.BindEnv(key)
+	}
 	err = vpr()
-	if err != nil {
-		return
+	// a missing app.env is still reported, the values of the environment are read anyway
+	if unmarshalErr := viper.Unmarshal(&config); err == nil {
+		err = unmarshalErr
 	}
-	err = viper.Unmarshal(&config)
 	return
 }
 `